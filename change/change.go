@@ -9,6 +9,7 @@ type Change struct {
 	key     string
 	value   string
 	version uint64
+	deleted bool
 }
 
 // New constructor.
@@ -16,6 +17,11 @@ func New(src config.Source, key, value string, version uint64) *Change {
 	return &Change{src: src, key: key, value: value, version: version}
 }
 
+// NewDeleted creates the change of a key which was deleted.
+func NewDeleted(src config.Source, key string, version uint64) *Change {
+	return &Change{src: src, key: key, version: version, deleted: true}
+}
+
 // Source of the change.
 func (c Change) Source() config.Source {
 	return c.src
@@ -35,3 +41,8 @@ func (c Change) Value() string {
 func (c Change) Version() uint64 {
 	return c.version
 }
+
+// Deleted returns true if the key was deleted.
+func (c Change) Deleted() bool {
+	return c.deleted
+}
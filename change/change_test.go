@@ -13,4 +13,14 @@ func TestChange(t *testing.T) {
 	assert.Equal(t, "key", c.Key())
 	assert.Equal(t, "value", c.Value())
 	assert.Equal(t, uint64(1), c.Version())
+	assert.False(t, c.Deleted())
+}
+
+func TestNewDeleted(t *testing.T) {
+	c := NewDeleted(config.SourceConsul, "key", 2)
+	assert.Equal(t, config.SourceConsul, c.Source())
+	assert.Equal(t, "key", c.Key())
+	assert.Empty(t, c.Value())
+	assert.Equal(t, uint64(2), c.Version())
+	assert.True(t, c.Deleted())
 }
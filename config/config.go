@@ -5,8 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Source definition.
@@ -25,9 +31,58 @@ const (
 	SourceFlag Source = "flag"
 	// SourceFile defines a value from external file.
 	SourceFile Source = "file"
+	// SourceVault defines a value from vault.
+	SourceVault Source = "vault"
+	// SourceEtcd defines a value from etcd.
+	SourceEtcd Source = "etcd"
 )
 
-var sourceTags = [...]Source{SourceSeed, SourceEnv, SourceConsul, SourceRedis, SourceFlag, SourceFile}
+const (
+	precedenceTag = "precedence"
+	harvesterTag  = "harvester"
+	requiredTag   = "required"
+	optionalOpt   = "optional"
+	onDeleteTag   = "on_delete"
+	strictTag     = "strict"
+	schemaTag     = "schema"
+	// consulPrefixTag binds a nested struct to a Consul key prefix.
+	consulPrefixTag = "consul_prefix"
+	// etcdPrefixTag binds a nested struct to an etcd key prefix.
+	etcdPrefixTag = "etcd_prefix"
+	// RedisHashTag sets the Redis key of a field to the field of a hash, e.g. svc-config#age.
+	RedisHashTag = "redis_hash"
+	// RedisJSONTag sets the Redis key of a field to the path of a RedisJSON document, e.g. svc-limits#$.max.
+	RedisJSONTag = "redis_json"
+)
+
+// keyTags set the key of a source like the tag of the source, while telling the getters and watchers how to read it.
+var keyTags = map[string]Source{
+	RedisHashTag: SourceRedis,
+	RedisJSONTag: SourceRedis,
+}
+
+// Phase in which a change was applied.
+type Phase string
+
+const (
+	// PhaseSeed defines a change applied while seeding.
+	PhaseSeed Phase = "seed"
+	// PhaseMonitor defines a change applied while monitoring.
+	PhaseMonitor Phase = "monitor"
+)
+
+// DeletePolicy defines how a field handles the deletion of its key in a monitored source.
+type DeletePolicy string
+
+const (
+	// DeleteKeep keeps the last value of the field, which is the default.
+	DeleteKeep DeletePolicy = "keep"
+	// DeleteRevert reverts the field to the value which the other sources provided while seeding, e.g. the seed tag
+	// or an environment variable, or to its zero value if none did.
+	DeleteRevert DeletePolicy = "revert"
+	// DeleteZero resets the field to its zero value, which requires its type to implement Resetter.
+	DeleteZero DeletePolicy = "zero"
+)
 
 // CfgType represents an interface which any config field type must implement.
 type CfgType interface {
@@ -35,31 +90,109 @@ type CfgType interface {
 	SetString(string) error
 }
 
+// Resetter is implemented by config field types which can be reset to their zero value, e.g. the types of the sync
+// package.
+type Resetter interface {
+	Reset()
+}
+
+// EntryType is implemented by config field types which hold the values of all keys of a prefix as entries, e.g.
+// sync.Map bound with the consul_prefix tag. Each entry is parsed, set and deleted on its own.
+type EntryType interface {
+	CfgType
+	// SetEntry parses and sets the value of the entry, whose key is relative to the prefix.
+	SetEntry(key, value string) error
+	// DeleteEntry deletes the entry, if it exists.
+	DeleteEntry(key string) error
+	// Entries returns the formatted values of the entries by key, which SetEntry parses back.
+	Entries() map[string]string
+}
+
+// StructuredType is implemented by config field types which decode the whole value as a document, e.g. sync.JSON and
+// sync.YAML. The strict and schema tags configure the decoding.
+type StructuredType interface {
+	CfgType
+	// SetStrict enables the strict mode, which rejects documents with unknown fields.
+	SetStrict(strict bool)
+	// SetSchema sets the path of the JSON Schema which the documents are validated against.
+	SetSchema(path string) error
+}
+
 // ChangeNotification definition for a configuration change.
+// Previous and Current are the string representations of the field, which means that secrets are redacted.
 type ChangeNotification struct {
 	Name     string
 	Type     string
 	Previous string
 	Current  string
+	// Source of the change, empty if the value was set without one.
+	Source Source
+	// Key of the change in the source, e.g. the Consul key.
+	Key string
+	// Version of the change, e.g. the Consul ModifyIndex. Seeded values have version 0.
+	Version uint64
+	// Time the change was applied.
+	Time time.Time
+	// Phase in which the change was applied.
+	Phase Phase
+	// Entry is the key of the entry which changed, relative to the prefix of the field, e.g. the name of a flag of
+	// a sync.Map. It is empty if the whole value changed.
+	Entry string
+	// Deleted is true if the key of the change was deleted, in which case the field handled the deletion with its
+	// policy, or if the entry was deleted.
+	Deleted bool
 }
 
 func (n ChangeNotification) String() string {
 	return fmt.Sprintf("field [%s] of type [%s] changed from [%s] to [%s]", n.Name, n.Type, n.Previous, n.Current)
 }
 
+// ParseError is returned when a value cannot be parsed into the type of a field.
+// Its message is the one of the underlying error.
+type ParseError struct {
+	Field string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
 // Field definition of a config value that can change.
 type Field struct {
 	name        string
 	tp          string
+	rtype       reflect.Type
 	version     uint64
 	structField CfgType
 	sources     map[Source]string
+	precedence  []Source
+	prefixes    map[Source]string
+	keyTags     map[Source]string
+	entrySrc    Source
+	optional    bool
+	required    []Source
+	onDelete    DeletePolicy
+	rules       []rule
+	strict      bool
+	schema      string
+	cfg         *Config
 	chNotify    chan<- ChangeNotification
-	mu          sync.Mutex // protects version field
+	mu          sync.Mutex // protects version field, the entry versions and the provenance
+	entries     map[string]uint64
+	seeded      []Update // values applied while seeding, in order, to revert to on deletion
+	seedOrder   []Source // order in which the sources were applied while seeding
+	origin      Update
+	updated     time.Time
+	attempts    []Attempt
 }
 
 // newField constructor.
-func newField(prefix string, fld reflect.StructField, val reflect.Value, chNotify chan<- ChangeNotification) (*Field, error) {
+func newField(prefix string, b binding, fld reflect.StructField, val reflect.Value, ss []Source, chNotify chan<- ChangeNotification) (*Field, error) {
 	sf, ok := val.Addr().Interface().(CfgType)
 	if !ok {
 		return nil, errors.New("failed to type assert to CfgType")
@@ -67,23 +200,83 @@ func newField(prefix string, fld reflect.StructField, val reflect.Value, chNotif
 
 	f := &Field{
 		name:        prefix + fld.Name,
-		tp:          fld.Type.Name(),
+		tp:          typeName(fld.Type),
+		rtype:       fld.Type,
 		version:     0,
 		structField: sf,
 		sources:     make(map[Source]string),
 		chNotify:    chNotify,
+		onDelete:    DeleteKeep,
 	}
 
-	for _, tag := range sourceTags {
+	for _, tag := range ss {
 		value, ok := fld.Tag.Lookup(string(tag))
 		if ok {
 			f.sources[tag] = value
 		}
 	}
 
+	err := f.parseKeyTags(fld)
+	if err != nil {
+		return nil, err
+	}
+
+	pt, _, ok, err := lookupPrefixTag(fld)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		err := f.bindEntries(b, fld, pt)
+		if err != nil {
+			return nil, err
+		}
+	} else if b.prefix != "" {
+		key, ok := f.sources[b.src]
+		if !ok {
+			key = fld.Name
+		}
+		f.sources[b.src] = b.prefix + strings.TrimPrefix(key, "/")
+		f.prefixes = map[Source]string{b.src: b.root}
+	}
+
+	value, ok := fld.Tag.Lookup(precedenceTag)
+	if ok {
+		for _, src := range strings.Split(value, ",") {
+			f.precedence = append(f.precedence, Source(strings.TrimSpace(src)))
+		}
+		_, err = SourceOrder(nil, f.precedence...)
+		if err != nil {
+			return nil, fmt.Errorf("invalid precedence of field %s: %w", f.name, err)
+		}
+	}
+
+	err = f.parsePolicy(fld.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid policy of field %s: %w", f.name, err)
+	}
+
+	f.rules, err = newRules(fld.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid validation of field %s: %w", f.name, err)
+	}
+
+	err = f.parseDecoding(fld.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("invalid decoding of field %s: %w", f.name, err)
+	}
+
 	return f, nil
 }
 
+// packagePaths matches the import paths of the packages in the name of a type, except the last element.
+var packagePaths = regexp.MustCompile(`([\w.-]+/)+`)
+
+// typeName returns the name of the type, whose type arguments are qualified by package name instead of import path,
+// e.g. JSON[config.Limits].
+func typeName(t reflect.Type) string {
+	return packagePaths.ReplaceAllString(t.Name(), "")
+}
+
 // Name getter.
 func (f *Field) Name() string {
 	return f.name
@@ -99,6 +292,197 @@ func (f *Field) Sources() map[Source]string {
 	return f.sources
 }
 
+// Precedence returns the seeding order of the sources defined with the precedence tag of the field.
+// It returns nil when the field does not override the seeding order.
+func (f *Field) Precedence() []Source {
+	return f.precedence
+}
+
+// SetSeedOrder records the order in which the sources are applied while seeding the field. Once seeded, changes
+// from sources which come before the source of the seeded value are dropped, so that the higher precedence source
+// keeps winning while monitoring. Without a seed order, every change of a monitored source is applied.
+func (f *Field) SetSeedOrder(ss []Source) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seedOrder = slices.Clone(ss)
+}
+
+// isOverriddenLocked returns true if the update comes from a source with a lower precedence than the one which
+// seeded the field.
+func (f *Field) isOverriddenLocked(u Update) bool {
+	if u.Phase == PhaseSeed || u.Source == "" || len(f.seeded) == 0 {
+		return false
+	}
+	winner := f.seeded[len(f.seeded)-1].Source
+	rank, winnerRank := slices.Index(f.seedOrder, u.Source), slices.Index(f.seedOrder, winner)
+	if rank < 0 || winnerRank < 0 || rank >= winnerRank {
+		return false
+	}
+	slog.Debug("source has a lower precedence than the seeded one", "field", f.name, "source", u.Source,
+		"seeded", winner)
+	return true
+}
+
+// Prefix returns the key prefix of the source to which the field is bound, e.g. with the consul_prefix tag of its
+// enclosing struct, which allows fetching the values of all fields of the prefix at once. It returns an empty string
+// when the field is not bound to a prefix.
+func (f *Field) Prefix(src Source) string {
+	return f.prefixes[src]
+}
+
+// KeyTag returns the tag which set the key of the source, when it is not the tag of the source itself, e.g.
+// RedisHashTag for the field of a Redis hash. It returns an empty string otherwise.
+func (f *Field) KeyTag(src Source) string {
+	return f.keyTags[src]
+}
+
+// parseKeyTags sets the keys of the key tags, which conflict with the tag of their source and with each other.
+func (f *Field) parseKeyTags(fld reflect.StructField) error {
+	for _, tag := range slices.Sorted(maps.Keys(keyTags)) {
+		value, ok := fld.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		src := keyTags[tag]
+		if _, ok := f.sources[src]; ok {
+			other := string(src)
+			if t, ok := f.keyTags[src]; ok {
+				other = t
+			}
+			return fmt.Errorf("%s tag conflicts with the %s tag, field %s", tag, other, fld.Name)
+		}
+		if f.keyTags == nil {
+			f.keyTags = make(map[Source]string)
+		}
+		f.sources[src] = value
+		f.keyTags[src] = tag
+	}
+	return nil
+}
+
+// HasEntries returns true if the key of the source is a prefix, whose keys are the entries of the field, e.g. a
+// sync.Map bound with the consul_prefix tag. The keys of the entries are relative to the prefix.
+func (f *Field) HasEntries(src Source) bool {
+	return f.entrySrc != "" && f.entrySrc == src
+}
+
+// bindEntries binds a field of an entry type to the key prefix of its consul_prefix tag.
+func (f *Field) bindEntries(b binding, fld reflect.StructField, pt prefixTag) error {
+	if _, ok := f.structField.(EntryType); !ok {
+		return fmt.Errorf("%s tag is only supported on nested structs and entry types, field %s", pt.name, fld.Name)
+	}
+	if _, ok := f.sources[pt.src]; ok {
+		return fmt.Errorf("%s tag conflicts with the %s tag, field %s", pt.name, pt.src, fld.Name)
+	}
+	nb, err := b.nest(fld)
+	if err != nil {
+		return err
+	}
+	f.sources[pt.src] = nb.prefix
+	f.prefixes = map[Source]string{pt.src: nb.root}
+	f.entrySrc = pt.src
+	return nil
+}
+
+// OnDelete returns the policy of the field for the deletion of its key, which is set with the on_delete tag.
+func (f *Field) OnDelete() DeletePolicy {
+	return f.onDelete
+}
+
+// Optional returns true if the field is declared optional with the harvester tag, which means that it keeps its
+// zero value when no source provides one.
+func (f *Field) Optional() bool {
+	return f.optional
+}
+
+// Required returns the sources declared with the required tag, one of which has to provide the seeded value.
+// It returns nil when any source can provide the value.
+func (f *Field) Required() []Source {
+	return f.required
+}
+
+// parsePolicy parses the harvester and required tags of the field.
+func (f *Field) parsePolicy(tag reflect.StructTag) error {
+	if value, ok := tag.Lookup(harvesterTag); ok {
+		for _, opt := range strings.Split(value, ",") {
+			switch strings.TrimSpace(opt) {
+			case optionalOpt:
+				f.optional = true
+			default:
+				return fmt.Errorf("%s tag option %q is not supported", harvesterTag, opt)
+			}
+		}
+	}
+
+	if value, ok := tag.Lookup(onDeleteTag); ok {
+		switch DeletePolicy(value) {
+		case DeleteKeep, DeleteRevert:
+		case DeleteZero:
+			if _, ok := f.structField.(Resetter); !ok {
+				return fmt.Errorf("%s tag %q requires a type which implements Resetter", onDeleteTag, value)
+			}
+		default:
+			return fmt.Errorf("%s tag %q is not supported", onDeleteTag, value)
+		}
+		f.onDelete = DeletePolicy(value)
+	}
+
+	value, ok := tag.Lookup(requiredTag)
+	if !ok {
+		return nil
+	}
+	if f.optional {
+		return fmt.Errorf("%s tag conflicts with the %s option", requiredTag, optionalOpt)
+	}
+	for _, src := range strings.Split(value, ",") {
+		f.required = append(f.required, Source(strings.TrimSpace(src)))
+	}
+	_, err := SourceOrder(nil, f.required...)
+	if err != nil {
+		return fmt.Errorf("invalid %s tag: %w", requiredTag, err)
+	}
+	for _, src := range f.required {
+		if _, ok := f.sources[src]; !ok {
+			return fmt.Errorf("required source %s is not declared", src)
+		}
+	}
+	return nil
+}
+
+// parseDecoding parses the strict and schema tags of the field and configures its structured type.
+func (f *Field) parseDecoding(tag reflect.StructTag) error {
+	for _, name := range []string{strictTag, schemaTag} {
+		if _, ok := tag.Lookup(name); !ok {
+			continue
+		}
+		if _, ok := f.structField.(StructuredType); !ok {
+			return fmt.Errorf("%s tag requires a type which implements StructuredType", name)
+		}
+	}
+	if value, ok := tag.Lookup(strictTag); ok {
+		strict, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s tag: %w", strictTag, err)
+		}
+		f.strict = strict
+	}
+	f.schema = tag.Get(schemaTag)
+	return f.configure(f.structField)
+}
+
+// configure the decoding of the value of a structured type, which is a no-op for other types.
+func (f *Field) configure(v CfgType) error {
+	st, ok := v.(StructuredType)
+	if !ok {
+		return nil
+	}
+	st.SetStrict(f.strict)
+	if f.schema == "" {
+		return nil
+	}
+	return st.SetSchema(f.schema)
+}
+
 // String returns string representation of field's value.
 func (f *Field) String() string {
 	return f.structField.String()
@@ -109,48 +493,393 @@ func (f *Field) String() string {
 // the version check. All seeding sources (seed tag, env, file, consul, redis, flag)
 // use version 0. Only the monitoring path (consul, redis watchers) supplies a
 // non-zero version, enabling the "reject older/same version" guard below.
-func (f *Field) Set(value string, version uint64) error {
+// The changes of the file watcher are exempt from the guard, since its versions are per-file counters.
+func (f *Field) Set(value string, version uint64, oo ...SetOption) error {
+	u := Update{Field: f, Value: value, Version: version}
+	for _, o := range oo {
+		o(&u)
+	}
+	return f.cfg.Apply(u)
+}
+
+// SetOption for describing the origin of a value set on a field.
+type SetOption func(*Update)
+
+// WithOrigin sets the source and the key in the source of the value.
+func WithOrigin(src Source, key string) SetOption {
+	return func(u *Update) {
+		u.Source = src
+		u.Key = key
+	}
+}
+
+// WithStale marks the value as possibly stale, e.g. because it was read from a Consul follower.
+func WithStale() SetOption {
+	return func(u *Update) {
+		u.Stale = true
+	}
+}
+
+// WithEntry sets the value of an entry of the field instead of the whole value, see EntryType. The key of the entry
+// is relative to the prefix of the field.
+func WithEntry(key string) SetOption {
+	return func(u *Update) {
+		u.Entry = key
+	}
+}
+
+// WithDeleted reports the deletion of the key of the field, which is handled with the deletion policy of the field,
+// or deletes the entry of the field instead of setting it.
+func WithDeleted() SetOption {
+	return func(u *Update) {
+		u.Deleted = true
+	}
+}
+
+// WithPhase sets the phase in which the value is set.
+func WithPhase(phase Phase) SetOption {
+	return func(u *Update) {
+		u.Phase = phase
+	}
+}
+
+// isOutdated returns true if the update should not be applied, since its version is not newer than the field's,
+// or the entry's if it updates an entry, or since its source has a lower precedence than the seeded one.
+func (f *Field) isOutdated(u Update) bool {
 	f.mu.Lock()
 	defer f.mu.Unlock()
+	return f.isOutdatedLocked(u)
+}
 
+func (f *Field) isOutdatedLocked(u Update) bool {
+	if f.isOverriddenLocked(u) {
+		return true
+	}
+	if !u.versioned() {
+		return false
+	}
+	version, current := u.Version, f.version
+	if u.Entry != "" {
+		current = f.entries[u.Entry]
+	}
 	// version == 0 is the seeding sentinel; skip version guards and always apply.
-	if version != 0 && version < f.version {
-		slog.Warn("version is older than the field's", "field", f.name, "old", f.version, "new", version)
-		return nil
+	if version != 0 && version < current {
+		slog.Warn("version is older than the field's", "field", f.name, "entry", u.Entry, "old", current,
+			"new", version)
+		return true
+	}
+
+	if version != 0 && version == current {
+		slog.Debug("version is the same as field", "field", f.name, "entry", u.Entry, "version", version)
+		return true
+	}
+	return false
+}
+
+// versioned returns true if the version of the update is comparable with the version of the field. The versions of
+// the file watcher count the changes of each file, which cannot be compared with the versions of the other sources
+// of the field, e.g. the Consul ModifyIndex. They are not needed either, since only changed contents are reported.
+func (u Update) versioned() bool {
+	return u.Source != SourceFile
+}
+
+// check the value against the validation rules of the field.
+func (f *Field) check(value string) error {
+	for _, r := range f.rules {
+		err := r.validate(value)
+		if err != nil {
+			return &ValidationError{Field: f.name, Rule: r.name, Err: err}
+		}
 	}
+	return nil
+}
 
-	if version != 0 && version == f.version {
-		slog.Debug("version is the same as field", "field", f.name, "version", version)
+// validate checks the value and parses it into a scratch instance of the field's type, leaving the field untouched.
+// The entries of a batch are validated together, see validate.
+func (f *Field) validate(u Update) error {
+	if u.Deleted {
 		return nil
 	}
+	value := u.Value
+	err := f.check(value)
+	if err != nil {
+		return err
+	}
+	scratch, ok := reflect.New(f.rtype).Interface().(CfgType)
+	if !ok {
+		return errors.New("failed to type assert to CfgType")
+	}
+	err = f.configure(scratch)
+	if err != nil {
+		return err
+	}
+	err = scratch.SetString(value)
+	if err != nil {
+		return fmt.Errorf("field %s: %w", f.name, &ParseError{Field: f.name, Err: err})
+	}
+	return nil
+}
+
+// entryValue returns the value of the field with the entry of the update set or deleted, which the rules are checked
+// against, since they apply to the whole value. The field is left untouched.
+func (f *Field) entryValue(u Update) (string, error) {
+	scratch, err := f.entryCopy()
+	if err != nil {
+		return "", err
+	}
+	err = applyEntry(scratch, u)
+	if err != nil {
+		return "", &ParseError{Field: f.name, Err: err}
+	}
+	return scratch.String(), nil
+}
+
+// entryCopy returns a copy of the field, which entries can be set on and deleted from without touching the field.
+// The copy is built entry by entry, since the format of the whole value cannot hold every entry value.
+func (f *Field) entryCopy() (EntryType, error) {
+	et, ok := f.structField.(EntryType)
+	if !ok {
+		return nil, fmt.Errorf("field %s has no entries", f.name)
+	}
+	scratch, ok := reflect.New(f.rtype).Interface().(EntryType)
+	if !ok {
+		return nil, fmt.Errorf("field %s has no entries", f.name)
+	}
+	for key, value := range et.Entries() {
+		err := scratch.SetEntry(key, value)
+		if err != nil {
+			return nil, &ParseError{Field: f.name, Err: err}
+		}
+	}
+	return scratch, nil
+}
+
+// applyEntry sets or deletes the entry of the update.
+func applyEntry(et EntryType, u Update) error {
+	if u.Deleted {
+		return et.DeleteEntry(u.Entry)
+	}
+	return et.SetEntry(u.Entry, u.Value)
+}
+
+// set the value of the field and return the notification of the change, if the value was applied. Validated is true
+// when the update belongs to a batch which was validated as a whole.
+func (f *Field) set(u Update, validated bool) (ChangeNotification, bool, error) {
+	if u.Entry != "" {
+		return f.setEntry(u, validated)
+	}
+	if u.Deleted {
+		return f.delete(u)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	value, version := u.Value, u.Version
+	if f.isOutdatedLocked(u) {
+		return ChangeNotification{}, false, nil
+	}
+
+	if err := f.check(value); err != nil {
+		return ChangeNotification{}, false, err
+	}
 
 	prevValue := f.structField.String()
 
 	if err := f.structField.SetString(value); err != nil {
-		return err
+		return ChangeNotification{}, false, &ParseError{Field: f.name, Err: err}
 	}
 
-	f.version = version
+	if u.versioned() {
+		f.version = version
+	}
+	f.entries = nil
+	f.origin = Update{Source: u.Source, Key: u.Key, Version: u.Version, Phase: u.Phase, Stale: u.Stale}
+	if u.Phase == PhaseSeed {
+		f.seeded = slices.DeleteFunc(f.seeded, func(s Update) bool { return s.Source == u.Source })
+		f.seeded = append(f.seeded, Update{Source: u.Source, Value: value})
+	}
+	f.updated = time.Now()
 	slog.Debug("field updated", "field", f.name, "version", version)
-	f.sendNotification(prevValue, value)
+	return ChangeNotification{
+		Name:     f.name,
+		Type:     f.tp,
+		Previous: prevValue,
+		Current:  f.structField.String(),
+		Source:   u.Source,
+		Key:      u.Key,
+		Version:  version,
+		Time:     f.updated,
+		Phase:    u.Phase,
+	}, true, nil
+}
+
+// delete handles the deletion of the key of the field with its deletion policy and returns the notification of the
+// deletion, which is sent even if the value is kept.
+func (f *Field) delete(u Update) (ChangeNotification, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.isOutdatedLocked(u) {
+		return ChangeNotification{}, false, nil
+	}
+
+	prevValue := f.structField.String()
+	switch f.onDelete {
+	case DeleteRevert:
+		err := f.revert(u.Source)
+		if err != nil {
+			return ChangeNotification{}, false, err
+		}
+	case DeleteZero:
+		f.reset()
+	case DeleteKeep:
+	}
+
+	if u.versioned() {
+		f.version = u.Version
+	}
+	f.entries = nil
+	f.origin = Update{Source: u.Source, Key: u.Key, Version: u.Version, Phase: u.Phase, Deleted: true}
+	f.updated = time.Now()
+	slog.Debug("field key deleted", "field", f.name, "policy", f.onDelete, "version", u.Version)
+	return ChangeNotification{
+		Name:     f.name,
+		Type:     f.tp,
+		Previous: prevValue,
+		Current:  f.structField.String(),
+		Source:   u.Source,
+		Key:      u.Key,
+		Version:  u.Version,
+		Time:     f.updated,
+		Phase:    u.Phase,
+		Deleted:  true,
+	}, true, nil
+}
+
+// revert the field to the last value which was seeded by another source than the one of the deleted key, or to its
+// zero value if there is none.
+func (f *Field) revert(src Source) error {
+	for i := len(f.seeded) - 1; i >= 0; i-- {
+		if f.seeded[i].Source == src {
+			continue
+		}
+		err := f.structField.SetString(f.seeded[i].Value)
+		if err != nil {
+			return &ParseError{Field: f.name, Err: err}
+		}
+		return nil
+	}
+	f.reset()
 	return nil
 }
 
-func (f *Field) sendNotification(prev string, current string) {
-	if f.chNotify == nil {
+// reset the field to its zero value, if its type supports it.
+func (f *Field) reset() {
+	r, ok := f.structField.(Resetter)
+	if !ok {
+		slog.Warn("field cannot be reset, value kept", "field", f.name)
 		return
 	}
-	f.chNotify <- ChangeNotification{
+	r.Reset()
+}
+
+// setEntry sets or deletes an entry of the field and returns the notification of the change, if the entry changed.
+func (f *Field) setEntry(u Update, validated bool) (ChangeNotification, bool, error) {
+	et, ok := f.structField.(EntryType)
+	if !ok {
+		return ChangeNotification{}, false, fmt.Errorf("field %s has no entries", f.name)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.isOutdatedLocked(u) {
+		return ChangeNotification{}, false, nil
+	}
+
+	// the rules of a validated batch were checked against its result, which the entries in between might not satisfy
+	if len(f.rules) > 0 && !validated {
+		value, err := f.entryValue(u)
+		if err != nil {
+			return ChangeNotification{}, false, err
+		}
+		err = f.check(value)
+		if err != nil {
+			return ChangeNotification{}, false, err
+		}
+	}
+
+	prevValue := f.structField.String()
+	err := applyEntry(et, u)
+	if err != nil {
+		return ChangeNotification{}, false, &ParseError{Field: f.name, Err: err}
+	}
+
+	if f.entries == nil {
+		f.entries = make(map[string]uint64)
+	}
+	f.entries[u.Entry] = u.Version
+	current := f.structField.String()
+	if current == prevValue {
+		return ChangeNotification{}, false, nil
+	}
+	f.origin = Update{Source: u.Source, Key: u.Key, Version: u.Version, Phase: u.Phase, Stale: u.Stale}
+	f.updated = time.Now()
+	slog.Debug("field entry updated", "field", f.name, "entry", u.Entry, "deleted", u.Deleted, "version", u.Version)
+	return ChangeNotification{
 		Name:     f.name,
 		Type:     f.tp,
-		Previous: prev,
+		Previous: prevValue,
 		Current:  current,
+		Source:   u.Source,
+		Key:      u.Key,
+		Version:  u.Version,
+		Time:     f.updated,
+		Phase:    u.Phase,
+		Entry:    u.Entry,
+		Deleted:  u.Deleted,
+	}, true, nil
+}
+
+func (f *Field) sendNotification(n ChangeNotification) {
+	if f.chNotify == nil {
+		return
 	}
+	f.chNotify <- n
+}
+
+// Update describes a new value of a field.
+type Update struct {
+	Field   *Field
+	Value   string
+	Version uint64
+	// Source and Key describe where the value originates from.
+	Source Source
+	Key    string
+	// Phase in which the update is applied.
+	Phase Phase
+	// Stale is true if the value might be outdated, e.g. because it was read from a Consul follower.
+	Stale bool
+	// Entry is the key of the entry to set instead of the whole value, relative to the prefix of the field.
+	Entry string
+	// Deleted is true if the entry is deleted instead of set.
+	Deleted bool
+}
+
+// Snapshot of the configuration values, taken at a specific generation.
+type Snapshot struct {
+	// Generation of the configuration at the time the snapshot was taken.
+	Generation uint64
+	// Values by field name, as returned by the String method of the field.
+	Values map[string]string
 }
 
 // Config manages configuration and handles updates on the values.
 type Config struct {
-	Fields []*Field
+	Fields     []*Field
+	mu         sync.RWMutex // protects the publishing of updates and the generation
+	generation uint64
+	subMu      sync.RWMutex // protects the subscriptions
+	subs       []*Subscription
 }
 
 // New creates a new monitor.
@@ -164,5 +893,125 @@ func New(cfg interface{}, chNotify chan<- ChangeNotification) (*Config, error) {
 		return nil, err
 	}
 
-	return &Config{Fields: ff}, nil
+	c := &Config{Fields: ff}
+	for _, f := range ff {
+		f.cfg = c
+	}
+	return c, nil
+}
+
+// Apply the updates atomically.
+// When more than one update is provided, all values are validated first and if any of them is invalid,
+// none of them is applied. The values are then published at once, so readers using View or Snapshot never
+// observe a partially applied batch. Updates which are not newer than the field's version are skipped.
+func (c *Config) Apply(uu ...Update) error {
+	_, err := c.ApplyChanges(uu...)
+	return err
+}
+
+// ApplyChanges applies the updates like Apply and returns the notifications of the ones which changed a field, which
+// leaves out the skipped updates, e.g. the outdated ones.
+func (c *Config) ApplyChanges(uu ...Update) ([]ChangeNotification, error) {
+	validated := len(uu) > 1
+	if validated {
+		err := validate(uu)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	nn := make([]ChangeNotification, 0, len(uu))
+	ff := make([]*Field, 0, len(uu))
+	var errs []error
+
+	c.mu.Lock()
+	for _, u := range uu {
+		n, ok, err := u.Field.set(u, validated)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			nn = append(nn, n)
+			ff = append(ff, u.Field)
+		}
+	}
+	if len(nn) > 0 {
+		c.generation++
+	}
+	c.mu.Unlock()
+
+	for i, n := range nn {
+		ff[i].sendNotification(n)
+		c.publish(n)
+	}
+	return nn, errors.Join(errs...)
+}
+
+func validate(uu []Update) error {
+	var errs []error
+	// the entries are applied in order to a copy per field, whose result is checked against the rules of the field
+	copies := make(map[*Field]EntryType)
+	var ff []*Field
+	for _, u := range uu {
+		f := u.Field
+		if f.isOutdated(u) {
+			continue
+		}
+		if u.Entry == "" {
+			err := f.validate(u)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		scratch, ok := copies[f]
+		if !ok {
+			var err error
+			scratch, err = f.entryCopy()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("field %s: %w", f.name, err))
+				continue
+			}
+			copies[f] = scratch
+			ff = append(ff, f)
+		}
+		err := applyEntry(scratch, u)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("field %s: %w", f.name, &ParseError{Field: f.name, Err: err}))
+		}
+	}
+	for _, f := range ff {
+		err := f.check(copies[f].String())
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Generation returns a counter which is incremented every time updates are applied.
+func (c *Config) Generation() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.generation
+}
+
+// View calls the function while no updates can be applied, which allows reading multiple fields consistently.
+// The function must not apply updates itself, since this would deadlock.
+func (c *Config) View(fn func()) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	fn()
+}
+
+// Snapshot returns the current values of all fields, taken consistently.
+func (c *Config) Snapshot() Snapshot {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	values := make(map[string]string, len(c.Fields))
+	for _, f := range c.Fields {
+		values[f.name] = f.String()
+	}
+	return Snapshot{Generation: c.generation, Values: values}
 }
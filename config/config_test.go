@@ -1,7 +1,9 @@
 package config
 
 import (
+	"net"
 	"testing"
+	"time"
 
 	"github.com/beatlabs/harvester/sync"
 	"github.com/stretchr/testify/assert"
@@ -93,6 +95,416 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNew_PolicyTags(t *testing.T) {
+	cfg, err := New(&struct {
+		Name     sync.String `harvester:"optional" env:"ENV_NAME"`
+		Password sync.Secret `seed:"secret" consul:"/config/password" redis:"password" required:"consul, redis"`
+		Age      sync.Int64  `seed:"18"`
+	}{}, nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.Fields[0].Optional())
+	assert.Nil(t, cfg.Fields[0].Required())
+	assert.False(t, cfg.Fields[1].Optional())
+	assert.Equal(t, []Source{SourceConsul, SourceRedis}, cfg.Fields[1].Required())
+	assert.False(t, cfg.Fields[2].Optional())
+	assert.Nil(t, cfg.Fields[2].Required())
+	assert.Equal(t, DeleteKeep, cfg.Fields[0].OnDelete())
+
+	tests := map[string]struct {
+		cfg interface{}
+		err string
+	}{
+		"unsupported option": {
+			cfg: &struct {
+				Name sync.String `env:"ENV_NAME" harvester:"mandatory"`
+			}{},
+			err: `invalid policy of field Name: harvester tag option "mandatory" is not supported`,
+		},
+		"unregistered required source": {
+			cfg: &struct {
+				Name sync.String `env:"ENV_NAME" required:"unknown"`
+			}{},
+			err: "invalid policy of field Name: invalid required tag: source unknown is not registered",
+		},
+		"undeclared required source": {
+			cfg: &struct {
+				Name sync.String `env:"ENV_NAME" required:"consul"`
+			}{},
+			err: "invalid policy of field Name: required source consul is not declared",
+		},
+		"optional and required": {
+			cfg: &struct {
+				Name sync.String `consul:"/config/name" harvester:"optional" required:"consul"`
+			}{},
+			err: "invalid policy of field Name: required tag conflicts with the optional option",
+		},
+		"unsupported delete policy": {
+			cfg: &struct {
+				Name sync.String `consul:"/config/name" on_delete:"drop"`
+			}{},
+			err: `invalid policy of field Name: on_delete tag "drop" is not supported`,
+		},
+		"zero delete policy without reset": {
+			cfg: &struct {
+				Name testNoReset `consul:"/config/name" on_delete:"zero"`
+			}{},
+			err: `invalid policy of field Name: on_delete tag "zero" requires a type which implements Resetter`,
+		},
+		"strict without structured type": {
+			cfg: &struct {
+				Name sync.String `seed:"John" strict:"true"`
+			}{},
+			err: "invalid decoding of field Name: strict tag requires a type which implements StructuredType",
+		},
+		"invalid strict": {
+			cfg: &struct {
+				Limits sync.JSON[testLimit] `consul:"/config/limits" strict:"yes"`
+			}{},
+			err: `invalid decoding of field Limits: invalid strict tag: strconv.ParseBool: parsing "yes": invalid syntax`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := New(tt.cfg, nil)
+			require.EqualError(t, err, tt.err)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestNew_ConsulPrefix(t *testing.T) {
+	cfg, err := New(&testConsulPrefixConfig{}, nil)
+	require.NoError(t, err)
+	require.Len(t, cfg.Fields, 5)
+	assertField(t, cfg.Fields[0], "Name", "String", map[Source]string{SourceConsul: "/config/name"})
+	assertField(t, cfg.Fields[1], "DBHost", "String", map[Source]string{SourceConsul: "svc/db/Host"})
+	assertField(t, cfg.Fields[2], "DBMaxConns", "Int64",
+		map[Source]string{SourceSeed: "10", SourceEnv: "ENV_DB_MAX_CONNS", SourceConsul: "svc/db/max-conns"})
+	assertField(t, cfg.Fields[3], "DBReplicaHost", "String", map[Source]string{SourceConsul: "svc/db/replica/Host"})
+	assertField(t, cfg.Fields[4], "DBReplicaPort", "Int64", map[Source]string{SourceConsul: "svc/db/replica/port"})
+	assert.Empty(t, cfg.Fields[0].Prefix(SourceConsul))
+	for _, f := range cfg.Fields[1:] {
+		assert.Equal(t, "svc/db/", f.Prefix(SourceConsul))
+		assert.Empty(t, f.Prefix(SourceEtcd))
+	}
+
+	tests := map[string]struct {
+		cfg interface{}
+		err string
+	}{
+		"empty prefix": {
+			cfg: &struct {
+				DB struct {
+					Host sync.String
+				} `consul_prefix:"/"`
+			}{},
+			err: "consul_prefix tag of field DB is empty",
+		},
+		"prefix on field": {
+			cfg: &struct {
+				Host sync.String `env:"ENV_HOST" consul_prefix:"svc/db/"`
+			}{},
+			err: "consul_prefix tag is only supported on nested structs and entry types, field Host",
+		},
+		"duplicate key": {
+			cfg: &struct {
+				Host sync.String `consul:"svc/db/Host"`
+				DB   struct {
+					Host sync.String
+				} `consul_prefix:"svc/db"`
+			}{},
+			err: "duplicate value  for source consul",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := New(tt.cfg, nil)
+			require.EqualError(t, err, tt.err)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestNew_EtcdPrefix(t *testing.T) {
+	cfg, err := New(&testEtcdPrefixConfig{}, nil)
+	require.NoError(t, err)
+	require.Len(t, cfg.Fields, 3)
+	assertField(t, cfg.Fields[0], "DBHost", "String", map[Source]string{SourceEtcd: "/svc/db/Host"})
+	assertField(t, cfg.Fields[1], "DBPort", "Int64", map[Source]string{SourceEtcd: "/svc/db/port"})
+	assertField(t, cfg.Fields[2], "DBFlags", "Map[string,bool]", map[Source]string{SourceEtcd: "/svc/db/flags/"})
+	for _, f := range cfg.Fields {
+		assert.Equal(t, "/svc/db/", f.Prefix(SourceEtcd))
+		assert.Empty(t, f.Prefix(SourceConsul))
+	}
+	assert.True(t, cfg.Fields[2].HasEntries(SourceEtcd))
+
+	tests := map[string]struct {
+		cfg interface{}
+		err string
+	}{
+		"both prefixes": {
+			cfg: &struct {
+				DB struct {
+					Host sync.String
+				} `consul_prefix:"svc/db" etcd_prefix:"/svc/db"`
+			}{},
+			err: "consul_prefix tag conflicts with the etcd_prefix tag, field DB",
+		},
+		"nested in consul prefix": {
+			cfg: &struct {
+				DB struct {
+					Flags sync.Map[string, bool] `etcd_prefix:"flags"`
+				} `consul_prefix:"svc/db"`
+			}{},
+			err: "etcd_prefix tag of field Flags conflicts with the consul prefix it is nested in",
+		},
+		"etcd tag": {
+			cfg: &struct {
+				Flags sync.Map[string, bool] `etcd:"/flags" etcd_prefix:"/flags/"`
+			}{},
+			err: "etcd_prefix tag conflicts with the etcd tag, field Flags",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := New(tt.cfg, nil)
+			require.EqualError(t, err, tt.err)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestNew_RedisKeyTags(t *testing.T) {
+	cfg, err := New(&struct {
+		Name sync.String `redis:"hash:name"`
+		Age  sync.Int64  `redis_hash:"svc-config#age"`
+		Max  sync.Int64  `redis_json:"svc-limits#$.max"`
+	}{}, nil)
+	require.NoError(t, err)
+	require.Len(t, cfg.Fields, 3)
+	assertField(t, cfg.Fields[0], "Name", "String", map[Source]string{SourceRedis: "hash:name"})
+	assertField(t, cfg.Fields[1], "Age", "Int64", map[Source]string{SourceRedis: "svc-config#age"})
+	assertField(t, cfg.Fields[2], "Max", "Int64", map[Source]string{SourceRedis: "svc-limits#$.max"})
+	assert.Empty(t, cfg.Fields[0].KeyTag(SourceRedis))
+	assert.Equal(t, RedisHashTag, cfg.Fields[1].KeyTag(SourceRedis))
+	assert.Equal(t, RedisJSONTag, cfg.Fields[2].KeyTag(SourceRedis))
+
+	tests := map[string]struct {
+		cfg interface{}
+		err string
+	}{
+		"redis tag": {
+			cfg: &struct {
+				Age sync.Int64 `redis:"age" redis_hash:"svc-config#age"`
+			}{},
+			err: "redis_hash tag conflicts with the redis tag, field Age",
+		},
+		"hash and json": {
+			cfg: &struct {
+				Age sync.Int64 `redis_hash:"svc-config#age" redis_json:"svc-config#$.age"`
+			}{},
+			err: "redis_json tag conflicts with the redis_hash tag, field Age",
+		},
+		"duplicate key": {
+			cfg: &struct {
+				Age1 sync.Int64 `redis:"svc-config#age"`
+				Age2 sync.Int64 `redis_hash:"svc-config#age"`
+			}{},
+			err: "duplicate value 0 for source redis",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := New(tt.cfg, nil)
+			require.EqualError(t, err, tt.err)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestNew_Entries(t *testing.T) {
+	cfg, err := New(&testEntriesConfig{}, nil)
+	require.NoError(t, err)
+	require.Len(t, cfg.Fields, 3)
+	assertField(t, cfg.Fields[0], "Flags", "Map[string,bool]",
+		map[Source]string{SourceSeed: "beta=true", SourceConsul: "flags/"})
+	assert.Equal(t, "flags/", cfg.Fields[0].Prefix(SourceConsul))
+	assert.True(t, cfg.Fields[0].HasEntries(SourceConsul))
+	assert.False(t, cfg.Fields[0].HasEntries(SourceSeed))
+	assertField(t, cfg.Fields[1], "SvcHost", "String", map[Source]string{SourceConsul: "svc/Host"})
+	assertField(t, cfg.Fields[2], "SvcLimits", "Map[string,int64]", map[Source]string{SourceConsul: "svc/limits/"})
+	assert.Equal(t, "svc/", cfg.Fields[2].Prefix(SourceConsul))
+	assert.True(t, cfg.Fields[2].HasEntries(SourceConsul))
+	assert.False(t, cfg.Fields[1].HasEntries(SourceConsul))
+
+	tests := map[string]struct {
+		cfg interface{}
+		err string
+	}{
+		"consul tag": {
+			cfg: &struct {
+				Flags sync.Map[string, bool] `consul:"flags" consul_prefix:"flags/"`
+			}{},
+			err: "consul_prefix tag conflicts with the consul tag, field Flags",
+		},
+		"empty prefix": {
+			cfg: &struct {
+				Flags sync.Map[string, bool] `consul_prefix:""`
+			}{},
+			err: "consul_prefix tag of field Flags is empty",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := New(tt.cfg, nil)
+			require.EqualError(t, err, tt.err)
+			assert.Nil(t, cfg)
+		})
+	}
+}
+
+func TestField_Set_Entry(t *testing.T) {
+	c := testEntriesConfig{}
+	chNotify := make(chan ChangeNotification, 10)
+	cfg, err := New(&c, chNotify)
+	require.NoError(t, err)
+	f := cfg.Fields[0]
+	require.NoError(t, f.Set("beta=true", 0))
+	<-chNotify
+
+	err = f.Set("true", 5, WithOrigin(SourceConsul, "flags/alpha"), WithEntry("alpha"), WithPhase(PhaseMonitor))
+	require.NoError(t, err)
+	n := <-chNotify
+	assert.Equal(t, "beta=true", n.Previous)
+	assert.Equal(t, "alpha=true,beta=true", n.Current)
+	assert.Equal(t, "flags/alpha", n.Key)
+	assert.Equal(t, "alpha", n.Entry)
+	assert.False(t, n.Deleted)
+	assert.Equal(t, uint64(5), n.Version)
+
+	// the versions are tracked per entry
+	require.NoError(t, f.Set("false", 4, WithEntry("alpha")))
+	require.NoError(t, f.Set("false", 3, WithEntry("gamma")))
+	n = <-chNotify
+	assert.Equal(t, "alpha=true,beta=true,gamma=false", n.Current)
+	assert.Empty(t, chNotify)
+
+	require.EqualError(t, f.Set("maybe", 6, WithEntry("alpha")),
+		`invalid value of key "alpha": strconv.ParseBool: parsing "maybe": invalid syntax`)
+	assert.Equal(t, map[string]bool{"alpha": true, "beta": true, "gamma": false}, c.Flags.Get())
+
+	require.NoError(t, f.Set("", 7, WithEntry("alpha"), WithDeleted()))
+	n = <-chNotify
+	assert.Equal(t, "beta=true,gamma=false", n.Current)
+	assert.Equal(t, "alpha", n.Entry)
+	assert.True(t, n.Deleted)
+
+	// deleting a missing entry changes nothing
+	require.NoError(t, f.Set("", 8, WithEntry("delta"), WithDeleted()))
+	assert.Empty(t, chNotify)
+
+	// setting the whole value resets the versions of the entries
+	require.NoError(t, f.Set("beta=false", 0))
+	<-chNotify
+	require.NoError(t, f.Set("true", 1, WithEntry("alpha")))
+	assert.Equal(t, map[string]bool{"alpha": true, "beta": false}, c.Flags.Get())
+
+	require.EqualError(t, cfg.Fields[1].Set("x", 1, WithEntry("host")), "field SvcHost has no entries")
+
+	t.Run("batch rejected", func(t *testing.T) {
+		err := cfg.Apply(
+			Update{Field: f, Value: "false", Version: 10, Entry: "alpha"},
+			Update{Field: f, Value: "XXX", Version: 10, Entry: "beta"},
+			Update{Field: f, Version: 10, Entry: "gamma", Deleted: true},
+		)
+		require.EqualError(t, err,
+			`field Flags: invalid value of key "beta": strconv.ParseBool: parsing "XXX": invalid syntax`)
+		assert.Equal(t, map[string]bool{"alpha": true, "beta": false}, c.Flags.Get())
+	})
+}
+
+func TestField_Set_Deleted(t *testing.T) {
+	tests := map[string]struct {
+		policy   string
+		seed     []string
+		expected string
+	}{
+		"keep":                   {policy: "keep", seed: []string{"1s", "2s"}, expected: "3s"},
+		"default":                {policy: "", seed: []string{"1s", "2s"}, expected: "3s"},
+		"revert to env":          {policy: "revert", seed: []string{"1s", "2s"}, expected: "2s"},
+		"revert to seed":         {policy: "revert", seed: []string{"1s", ""}, expected: "1s"},
+		"revert without sources": {policy: "revert", expected: "0s"},
+		"zero":                   {policy: "zero", seed: []string{"1s", "2s"}, expected: "0s"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := testDeleteConfig{}
+			chNotify := make(chan ChangeNotification, 10)
+			cfg, err := New(&c, chNotify)
+			require.NoError(t, err)
+			var f *Field
+			for _, fld := range cfg.Fields {
+				if fld.OnDelete() == DeletePolicy(tt.policy) || (tt.policy == "" && fld.Name() == "Default") {
+					f = fld
+				}
+			}
+			require.NotNil(t, f)
+			for i, src := range []Source{SourceSeed, SourceEnv} {
+				if i < len(tt.seed) && tt.seed[i] != "" {
+					require.NoError(t, f.Set(tt.seed[i], 0, WithOrigin(src, ""), WithPhase(PhaseSeed)))
+				}
+			}
+			require.NoError(t, f.Set("5s", 0, WithOrigin(SourceConsul, "timeout"), WithPhase(PhaseSeed)))
+			require.NoError(t, f.Set("3s", 4, WithOrigin(SourceConsul, "timeout"), WithPhase(PhaseMonitor)))
+			for len(chNotify) > 0 {
+				<-chNotify
+			}
+
+			require.NoError(t, f.Set("", 6, WithOrigin(SourceConsul, "timeout"), WithPhase(PhaseMonitor),
+				WithDeleted()))
+			assert.Equal(t, tt.expected, f.String())
+			n := <-chNotify
+			assert.True(t, n.Deleted)
+			assert.Equal(t, "3s", n.Previous)
+			assert.Equal(t, tt.expected, n.Current)
+			assert.Equal(t, "timeout", n.Key)
+			assert.Equal(t, uint64(6), n.Version)
+			p := f.provenance()
+			assert.True(t, p.Deleted)
+			assert.Equal(t, SourceConsul, p.Source)
+
+			// an outdated deletion is ignored and the key which is created again is applied
+			require.NoError(t, f.Set("", 5, WithDeleted()))
+			assert.Empty(t, chNotify)
+			require.NoError(t, f.Set("3s", 7, WithOrigin(SourceConsul, "timeout"), WithPhase(PhaseMonitor)))
+			assert.Equal(t, "3s", f.String())
+			assert.False(t, f.provenance().Deleted)
+		})
+	}
+}
+
+func TestField_Set_FileVersions(t *testing.T) {
+	c := testFileConfig{}
+	cfg, err := New(&c, nil)
+	require.NoError(t, err)
+	f := cfg.Fields[0]
+
+	require.NoError(t, f.Set("3s", 5000, WithOrigin(SourceConsul, "timeout"), WithPhase(PhaseMonitor)))
+	// the versions of the file watcher are not comparable with the Consul ModifyIndex
+	require.NoError(t, f.Set("4s", 1, WithOrigin(SourceFile, "timeout"), WithPhase(PhaseMonitor)))
+	assert.Equal(t, "4s", f.String())
+	assert.Equal(t, uint64(1), f.provenance().Version)
+	require.NoError(t, f.Set("", 2, WithOrigin(SourceFile, "timeout"), WithPhase(PhaseMonitor), WithDeleted()))
+	assert.True(t, f.provenance().Deleted)
+
+	// the Consul version is kept for the guard
+	require.NoError(t, f.Set("5s", 4999, WithOrigin(SourceConsul, "timeout"), WithPhase(PhaseMonitor)))
+	assert.Equal(t, "4s", f.String())
+	require.NoError(t, f.Set("5s", 5001, WithOrigin(SourceConsul, "timeout"), WithPhase(PhaseMonitor)))
+	assert.Equal(t, "5s", f.String())
+	assert.Equal(t, uint64(5001), f.provenance().Version)
+}
+
 func assertField(t *testing.T, fld *Field, name, typ string, sources map[Source]string) {
 	assert.Equal(t, name, fld.Name())
 	assert.Equal(t, typ, fld.Type())
@@ -142,10 +554,254 @@ func TestConfig_Set(t *testing.T) {
 	assert.True(t, c.IsAdult.Get())
 }
 
+func TestConfig_Set_Var(t *testing.T) {
+	c := testVarConfig{}
+	chNotify := make(chan ChangeNotification, 1)
+	cfg, err := New(&c, chNotify)
+	require.NoError(t, err)
+	require.Len(t, cfg.Fields, 3)
+	assertField(t, cfg.Fields[0], "Port", "Var[int]", map[Source]string{SourceSeed: "8080", SourceConsul: "/config/port"})
+
+	require.NoError(t, cfg.Fields[0].Set("9090", 1))
+	change := <-chNotify
+	assert.Equal(t, "field [Port] of type [Var[int]] changed from [0] to [9090]", change.String())
+	require.NoError(t, cfg.Fields[1].Set("10.0.0.1", 1))
+	<-chNotify
+	require.NoError(t, cfg.Fields[2].Set("1s,2s", 1))
+	<-chNotify
+
+	err = cfg.Fields[0].Set("port", 2)
+	var perr *ParseError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, 9090, c.Port.Get())
+	assert.Equal(t, "10.0.0.1", c.Host.String())
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, c.Backoff.Get())
+}
+
+func TestField_Set_Structured(t *testing.T) {
+	c := testStructuredConfig{}
+	chNotify := make(chan ChangeNotification, 1)
+	cfg, err := New(&c, chNotify)
+	require.NoError(t, err)
+
+	require.NoError(t, cfg.Fields[0].Set(`{"limit":10}`, 1))
+	change := <-chNotify
+	assert.Equal(t, `field [Limits] of type [JSON[config.testLimit]] changed from [{"limit":0}] to [{"limit":10}]`, change.String())
+	require.NoError(t, cfg.Fields[1].Set("limit: 20", 1))
+	<-chNotify
+
+	tests := map[string]struct {
+		field int
+		value string
+		err   string
+	}{
+		"unknown json field":  {field: 0, value: `{"limit":10,"burst":1}`, err: `json: unknown field "burst"`},
+		"schema mismatch":     {field: 0, value: `{"limit":"ten"}`, err: "document does not match schema"},
+		"invalid json":        {field: 0, value: `{"limit":`, err: "unexpected EOF"},
+		"unknown yaml field":  {field: 1, value: "limit: 20\nburst: 1", err: "field burst not found"},
+		"invalid yaml":        {field: 1, value: "limit: [", err: "yaml:"},
+		"lenient json field":  {field: 2, value: `{"limit":30,"burst":1}`},
+		"lenient yaml fields": {field: 3, value: "limit: 40\nburst: 1"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := cfg.Fields[tt.field].Set(tt.value, 2)
+			if tt.err == "" {
+				require.NoError(t, err)
+				<-chNotify
+				return
+			}
+			var perr *ParseError
+			require.ErrorAs(t, err, &perr)
+			require.ErrorContains(t, err, tt.err)
+		})
+	}
+	assert.Equal(t, testLimit{Limit: 10}, c.Limits.Get())
+	assert.Equal(t, testLimit{Limit: 20}, c.Routes.Get())
+	assert.Equal(t, testLimit{Limit: 30}, c.Lenient.Get())
+	assert.Equal(t, testLimit{Limit: 40}, c.LenientYAML.Get())
+}
+
+func TestField_Validate_Structured(t *testing.T) {
+	c := testStructuredConfig{}
+	cfg, err := New(&c, nil)
+	require.NoError(t, err)
+
+	_, err = New(&struct {
+		Limits sync.JSON[testLimit] `consul:"/config/limits" schema:"testdata/missing.json"`
+	}{}, nil)
+	require.ErrorContains(t, err, "invalid decoding of field Limits: failed to compile schema testdata/missing.json")
+
+	// the scratch instance is configured like the field, so that the update is rejected before it is applied
+	err = cfg.Apply(Update{Field: cfg.Fields[0], Value: `{"limit":1,"burst":1}`, Version: 1})
+	var perr *ParseError
+	require.ErrorAs(t, err, &perr)
+	assert.Equal(t, testLimit{}, c.Limits.Get())
+}
+
+func TestField_Set_Notification(t *testing.T) {
+	c := testSecretConfig{}
+	chNotify := make(chan ChangeNotification, 2)
+	cfg, err := New(&c, chNotify)
+	require.NoError(t, err)
+
+	before := time.Now()
+	err = cfg.Fields[0].Set("25", 42, WithOrigin(SourceConsul, "/config/age"), WithPhase(PhaseMonitor))
+	require.NoError(t, err)
+	n := <-chNotify
+	assert.Equal(t, "0", n.Previous)
+	assert.Equal(t, "25", n.Current)
+	assert.Equal(t, SourceConsul, n.Source)
+	assert.Equal(t, "/config/age", n.Key)
+	assert.Equal(t, uint64(42), n.Version)
+	assert.Equal(t, PhaseMonitor, n.Phase)
+	assert.False(t, n.Time.Before(before))
+
+	err = cfg.Fields[1].Set("s3cr3t", 0, WithOrigin(SourceEnv, "ENV_PASSWORD"), WithPhase(PhaseSeed))
+	require.NoError(t, err)
+	n = <-chNotify
+	assert.Equal(t, "***", n.Previous)
+	assert.Equal(t, "***", n.Current)
+	assert.Equal(t, SourceEnv, n.Source)
+	assert.Equal(t, PhaseSeed, n.Phase)
+	assert.Equal(t, "s3cr3t", c.Password.Get())
+}
+
+func TestConfig_Apply(t *testing.T) {
+	c := testConfig{}
+	chNotify := make(chan ChangeNotification, 10)
+	cfg, err := New(&c, chNotify)
+	require.NoError(t, err)
+
+	t.Run("batch applied", func(t *testing.T) {
+		err := cfg.Apply(
+			Update{Field: cfg.Fields[0], Value: "John Doe", Version: 1},
+			Update{Field: cfg.Fields[1], Value: "18", Version: 1},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), cfg.Generation())
+		assert.Equal(t, "John Doe", c.Name.Get())
+		assert.Equal(t, int64(18), c.Age.Get())
+		assert.Len(t, chNotify, 2)
+	})
+
+	t.Run("batch rejected", func(t *testing.T) {
+		err := cfg.Apply(
+			Update{Field: cfg.Fields[0], Value: "Jane Doe", Version: 2},
+			Update{Field: cfg.Fields[1], Value: "XXX", Version: 2},
+		)
+		require.EqualError(t, err, `field Age: strconv.ParseInt: parsing "XXX": invalid syntax`)
+		assert.Equal(t, uint64(1), cfg.Generation())
+		assert.Equal(t, "John Doe", c.Name.Get())
+		assert.Equal(t, int64(18), c.Age.Get())
+		assert.Len(t, chNotify, 2)
+	})
+
+	t.Run("outdated updates skipped", func(t *testing.T) {
+		err := cfg.Apply(
+			Update{Field: cfg.Fields[0], Value: "XXX", Version: 1},
+			Update{Field: cfg.Fields[1], Value: "XXX", Version: 1},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(1), cfg.Generation())
+		assert.Equal(t, "John Doe", c.Name.Get())
+	})
+
+	t.Run("snapshot", func(t *testing.T) {
+		snapshot := cfg.Snapshot()
+		assert.Equal(t, uint64(1), snapshot.Generation)
+		assert.Equal(t, "John Doe", snapshot.Values["Name"])
+		assert.Equal(t, "18", snapshot.Values["Age"])
+		assert.Equal(t, "", snapshot.Values["LevelOneLevelTwoDeepField"])
+	})
+
+	t.Run("view", func(t *testing.T) {
+		var name string
+		var age int64
+		cfg.View(func() {
+			name = c.Name.Get()
+			age = c.Age.Get()
+		})
+		assert.Equal(t, "John Doe", name)
+		assert.Equal(t, int64(18), age)
+	})
+
+	t.Run("notifications of the applied updates", func(t *testing.T) {
+		nn, err := cfg.ApplyChanges(
+			Update{Field: cfg.Fields[0], Value: "XXX", Version: 1},
+			Update{Field: cfg.Fields[1], Value: "21", Version: 3},
+		)
+		require.NoError(t, err)
+		require.Len(t, nn, 1)
+		assert.Equal(t, "Age", nn[0].Name)
+		assert.Equal(t, "21", nn[0].Current)
+		assert.Equal(t, uint64(2), cfg.Generation())
+		<-chNotify
+	})
+}
+
+type testSecretConfig struct {
+	Age      sync.Int64  `consul:"/config/age"`
+	Password sync.Secret `env:"ENV_PASSWORD"`
+}
+
 type testNestedConfig struct {
 	Salary sync.Int64 `seed:"2000" env:"ENV_SALARY"`
 }
 
+type testFileConfig struct {
+	Timeout sync.TimeDuration `file:"timeout" consul:"timeout"`
+}
+
+type testDeleteConfig struct {
+	Default sync.TimeDuration `consul:"default"`
+	Keep    sync.TimeDuration `consul:"keep" on_delete:"keep"`
+	Revert  sync.TimeDuration `consul:"revert" on_delete:"revert"`
+	Zero    sync.TimeDuration `consul:"zero" on_delete:"zero"`
+}
+
+// testNoReset is a config field type which cannot be reset.
+type testNoReset struct {
+	value string
+}
+
+func (t *testNoReset) String() string {
+	return t.value
+}
+
+func (t *testNoReset) SetString(value string) error {
+	t.value = value
+	return nil
+}
+
+type testEntriesConfig struct {
+	Flags sync.Map[string, bool] `seed:"beta=true" consul_prefix:"flags/"`
+	Svc   struct {
+		Host   sync.String
+		Limits sync.Map[string, int64] `consul_prefix:"limits"`
+	} `consul_prefix:"svc"`
+}
+
+type testConsulPrefixConfig struct {
+	Name sync.String `consul:"/config/name"`
+	DB   struct {
+		Host     sync.String
+		MaxConns sync.Int64 `seed:"10" env:"ENV_DB_MAX_CONNS" consul:"max-conns"`
+		Replica  struct {
+			Host sync.String
+			Port sync.Int64 `consul:"port"`
+		} `consul_prefix:"replica"`
+	} `consul_prefix:"svc/db/"`
+}
+
+type testEtcdPrefixConfig struct {
+	DB struct {
+		Host  sync.String
+		Port  sync.Int64             `etcd:"port"`
+		Flags sync.Map[string, bool] `etcd_prefix:"flags"`
+	} `etcd_prefix:"/svc/db"`
+}
+
 type testConfig struct {
 	Name     sync.String  `seed:"John Doe" env:"ENV_NAME"`
 	Age      sync.Int64   `env:"ENV_AGE" consul:"/config/age"`
@@ -160,6 +816,23 @@ type testConfig struct {
 	IsAdult sync.Bool `seed:"true" env:"ENV_IS_ADULT" redis:"is-adult"`
 }
 
+type testLimit struct {
+	Limit int `json:"limit" yaml:"limit"`
+}
+
+type testStructuredConfig struct {
+	Limits      sync.JSON[testLimit] `consul:"/config/limits" strict:"true" schema:"testdata/limit.schema.json"`
+	Routes      sync.YAML[testLimit] `consul:"/config/routes" strict:"true"`
+	Lenient     sync.JSON[testLimit] `consul:"/config/lenient"`
+	LenientYAML sync.YAML[testLimit] `consul:"/config/lenient-yaml" strict:"false"`
+}
+
+type testVarConfig struct {
+	Port    sync.Var[int]             `seed:"8080" consul:"/config/port"`
+	Host    sync.Var[net.IP]          `seed:"127.0.0.1"`
+	Backoff sync.Var[[]time.Duration] `seed:"1s"`
+}
+
 type testDuplicateNestedConsulConfig struct {
 	Age1   sync.Int64 `env:"ENV_AGE" consul:"/config/age"`
 	Nested struct {
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 type structFieldType uint
@@ -15,7 +16,8 @@ const (
 )
 
 type parser struct {
-	dups map[Source]map[string]bool
+	dups    map[Source]map[string]bool
+	sources []Source
 }
 
 func newParser() *parser {
@@ -24,35 +26,107 @@ func newParser() *parser {
 
 func (p *parser) ParseCfg(cfg interface{}, chNotify chan<- ChangeNotification) ([]*Field, error) {
 	p.dups = make(map[Source]map[string]bool)
+	p.sources = RegisteredSources()
 
 	tp := reflect.TypeOf(cfg)
 	if tp.Kind() != reflect.Ptr {
 		return nil, errors.New("configuration should be a pointer type")
 	}
 
-	return p.getFields("", tp.Elem(), reflect.ValueOf(cfg).Elem(), chNotify)
+	return p.getFields("", binding{}, tp.Elem(), reflect.ValueOf(cfg).Elem(), chNotify)
 }
 
-func (p *parser) getFields(prefix string, tp reflect.Type, val reflect.Value, chNotify chan<- ChangeNotification) ([]*Field, error) {
+// binding of a nested struct to a Consul key prefix with the consul_prefix tag.
+type binding struct {
+	// src is the source whose keys the struct is bound to.
+	src Source
+	// root is the prefix of the outermost bound struct, which covers the prefixes of the nested ones.
+	root string
+	// prefix of the keys of the fields of the struct.
+	prefix string
+}
+
+// prefixTag binds nested structs and entry types to a key prefix of a source.
+type prefixTag struct {
+	name string
+	src  Source
+	// absolute keeps the leading slash of the outermost prefix, since the keys of the source are usually absolute
+	// paths, e.g. the ones of etcd.
+	absolute bool
+}
+
+var prefixTags = []prefixTag{
+	{name: consulPrefixTag, src: SourceConsul},
+	{name: etcdPrefixTag, src: SourceEtcd, absolute: true},
+}
+
+// lookupPrefixTag returns the prefix tag of the field and its value, if any.
+func lookupPrefixTag(f reflect.StructField) (prefixTag, string, bool, error) {
+	var found []prefixTag
+	var value string
+	for _, pt := range prefixTags {
+		if v, ok := f.Tag.Lookup(pt.name); ok {
+			found = append(found, pt)
+			value = v
+		}
+	}
+	switch len(found) {
+	case 0:
+		return prefixTag{}, "", false, nil
+	case 1:
+		return found[0], value, true, nil
+	default:
+		return prefixTag{}, "", false, fmt.Errorf("%s tag conflicts with the %s tag, field %s", found[0].name,
+			found[1].name, f.Name)
+	}
+}
+
+func (b binding) nest(f reflect.StructField) (binding, error) {
+	pt, value, ok, err := lookupPrefixTag(f)
+	if err != nil || !ok {
+		return b, err
+	}
+	if b.src != "" && b.src != pt.src {
+		return b, fmt.Errorf("%s tag of field %s conflicts with the %s prefix it is nested in", pt.name, f.Name, b.src)
+	}
+	trimmed := strings.Trim(value, "/")
+	if trimmed == "" {
+		return b, fmt.Errorf("%s tag of field %s is empty", pt.name, f.Name)
+	}
+	nested := binding{src: pt.src, root: b.root, prefix: b.prefix + trimmed + "/"}
+	if b.prefix == "" && pt.absolute && strings.HasPrefix(value, "/") {
+		nested.prefix = "/" + nested.prefix
+	}
+	if nested.root == "" {
+		nested.root = nested.prefix
+	}
+	return nested, nil
+}
+
+func (p *parser) getFields(prefix string, b binding, tp reflect.Type, val reflect.Value, chNotify chan<- ChangeNotification) ([]*Field, error) {
 	var ff []*Field
 
 	for i := 0; i < tp.NumField(); i++ {
 		f := tp.Field(i)
 
-		typ, err := p.getStructFieldType(f, val.Field(i))
+		typ, err := p.getStructFieldType(f, val.Field(i), b)
 		if err != nil {
 			return nil, err
 		}
 
 		switch typ {
 		case typeField:
-			fld, err := p.createField(prefix, f, val.Field(i), chNotify)
+			fld, err := p.createField(prefix, b, f, val.Field(i), chNotify)
 			if err != nil {
 				return nil, err
 			}
 			ff = append(ff, fld)
 		case typeStruct:
-			nested, err := p.getFields(prefix+f.Name, f.Type, val.Field(i), chNotify)
+			nb, err := b.nest(f)
+			if err != nil {
+				return nil, err
+			}
+			nested, err := p.getFields(prefix+f.Name, nb, f.Type, val.Field(i), chNotify)
 			if err != nil {
 				return nil, err
 			}
@@ -63,27 +137,24 @@ func (p *parser) getFields(prefix string, tp reflect.Type, val reflect.Value, ch
 	return ff, nil
 }
 
-func (p *parser) createField(prefix string, f reflect.StructField, val reflect.Value, chNotify chan<- ChangeNotification) (*Field, error) {
-	fld, err := newField(prefix, f, val, chNotify)
+func (p *parser) createField(prefix string, b binding, f reflect.StructField, val reflect.Value, chNotify chan<- ChangeNotification) (*Field, error) {
+	fld, err := newField(prefix, b, f, val, p.sources, chNotify)
 	if err != nil {
 		return nil, err
 	}
 
-	// Duplicate key detection is intentionally limited to Consul and Redis.
-	// For env, flag, and file tags, the Go compiler enforces struct field name
+	// Duplicate key detection is intentionally limited to the remote sources, which are all the registered sources
+	// except the local ones. For env, flag, and file tags, the Go compiler enforces struct field name
 	// uniqueness, which makes duplicate tag values harmless in practice. For
-	// Consul and Redis, multiple fields could share the same remote key string,
+	// Consul, etcd, Redis, Vault and the custom sources, multiple fields could share the same remote key string,
 	// causing silent overwrites at runtime — so we reject duplicates eagerly here.
-	value, ok := fld.Sources()[SourceConsul]
-	if ok {
-		if p.isKeyValueDuplicate(SourceConsul, value) {
-			return nil, fmt.Errorf("duplicate value %v for source %s", fld, SourceConsul)
+	for _, src := range p.sources {
+		if local[src] {
+			continue
 		}
-	}
-	value, ok = fld.Sources()[SourceRedis]
-	if ok {
-		if p.isKeyValueDuplicate(SourceRedis, value) {
-			return nil, fmt.Errorf("duplicate value %v for source %s", fld, SourceRedis)
+		value, ok := fld.Sources()[src]
+		if ok && p.isKeyValueDuplicate(src, value) {
+			return nil, fmt.Errorf("duplicate value %v for source %s", fld, src)
 		}
 	}
 	return fld, nil
@@ -100,7 +171,7 @@ func (p *parser) isKeyValueDuplicate(src Source, value string) bool {
 	return false
 }
 
-func (p *parser) getStructFieldType(f reflect.StructField, val reflect.Value) (structFieldType, error) {
+func (p *parser) getStructFieldType(f reflect.StructField, val reflect.Value, b binding) (structFieldType, error) {
 	t := f.Type
 	if t.Kind() != reflect.Struct {
 		return typeInvalid, fmt.Errorf("only struct type supported for %s", f.Name)
@@ -108,8 +179,26 @@ func (p *parser) getStructFieldType(f reflect.StructField, val reflect.Value) (s
 
 	cfgType := reflect.TypeOf((*CfgType)(nil)).Elem()
 
-	for _, tag := range sourceTags {
-		if _, ok := f.Tag.Lookup(string(tag)); ok {
+	// fields of entry types are bound to a prefix with a prefix tag, e.g. consul_prefix, which is validated by the
+	// field
+	if _, _, ok, _ := lookupPrefixTag(f); ok && val.Addr().Type().Implements(cfgType) {
+		return typeField, nil
+	}
+
+	// the fields of a bound struct need no tag, since their key is derived from their name
+	if b.prefix != "" && val.Addr().Type().Implements(cfgType) {
+		return typeField, nil
+	}
+
+	tags := make([]string, 0, len(p.sources)+len(keyTags))
+	for _, tag := range p.sources {
+		tags = append(tags, string(tag))
+	}
+	for tag := range keyTags {
+		tags = append(tags, tag)
+	}
+	for _, tag := range tags {
+		if _, ok := f.Tag.Lookup(tag); ok {
 			if !val.Addr().Type().Implements(cfgType) {
 				return typeInvalid, fmt.Errorf("field %s must implement CfgType interface", f.Name)
 			}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"time"
+)
+
+// Attempt describes a source which was tried while seeding a field, but did not provide a value.
+type Attempt struct {
+	Source Source
+	Key    string
+	// Reason the source did not provide a value, e.g. the key was not found or the source failed.
+	Reason string
+}
+
+// Provenance describes where the value of a field came from.
+type Provenance struct {
+	Name string
+	Type string
+	// Value is the string representation of the field, which means that secrets are redacted.
+	Value string
+	// Source which last set the field, empty if the value was set without one or the field was never set.
+	Source  Source
+	Key     string
+	Version uint64
+	// Time the field was last set, zero if the field was never set.
+	Time  time.Time
+	Phase Phase
+	// Stale is true if the value might be outdated, e.g. because it was read from a Consul follower.
+	Stale bool
+	// Deleted is true if the key which last changed the field was deleted, in which case the value is the one of the
+	// deletion policy of the field.
+	Deleted bool
+	// Attempts lists the sources which were tried while seeding, but were missing or failed.
+	Attempts []Attempt
+	// Sources declared with the tags of the field. The seed tag is redacted if the type of the field does not render
+	// its value as is, e.g. sync.Secret.
+	Sources map[Source]string
+}
+
+// RecordAttempt records that a source was tried while seeding the field, but did not provide a value.
+func (f *Field) RecordAttempt(src Source, key, reason string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts = append(f.attempts, Attempt{Source: src, Key: key, Reason: reason})
+}
+
+// ResetAttempts clears the recorded attempts, so that the attempts of a seed pass do not add up with the previous ones.
+func (f *Field) ResetAttempts() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts = nil
+}
+
+func (f *Field) provenance() Provenance {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return Provenance{
+		Name:     f.name,
+		Type:     f.tp,
+		Value:    f.structField.String(),
+		Source:   f.origin.Source,
+		Key:      f.origin.Key,
+		Version:  f.origin.Version,
+		Time:     f.updated,
+		Phase:    f.origin.Phase,
+		Stale:    f.origin.Stale,
+		Deleted:  f.origin.Deleted,
+		Attempts: append([]Attempt(nil), f.attempts...),
+		Sources:  f.taggedSources(),
+	}
+}
+
+// redacted replaces the seed tag of fields whose type hides its value.
+const redacted = "***"
+
+// taggedSources returns the sources declared with the tags of the field, with the seed value redacted if needed.
+func (f *Field) taggedSources() map[Source]string {
+	ss := maps.Clone(f.sources)
+	if seed, ok := ss[SourceSeed]; ok && hidesValue(f.rtype, seed) {
+		ss[SourceSeed] = redacted
+	}
+	return ss
+}
+
+// hidesValue reports whether a value of the type is not rendered as is by its String method, e.g. sync.Secret.
+// Values which cannot be parsed are treated as hidden, since they cannot be checked.
+func hidesValue(rtype reflect.Type, value string) bool {
+	scratch, ok := reflect.New(rtype).Interface().(CfgType)
+	if !ok {
+		return true
+	}
+	err := scratch.SetString(value)
+	if err != nil {
+		return true
+	}
+	return scratch.String() != value
+}
+
+// Explain returns the provenance of a field.
+func (c *Config) Explain(fieldName string) (Provenance, error) {
+	f := c.field(fieldName)
+	if f == nil {
+		return Provenance{}, fmt.Errorf("field %s not found", fieldName)
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return f.provenance(), nil
+}
+
+// Provenance returns the provenance of all fields, taken consistently.
+func (c *Config) Provenance() []Provenance {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	pp := make([]Provenance, 0, len(c.Fields))
+	for _, f := range c.Fields {
+		pp = append(pp, f.provenance())
+	}
+	return pp
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/beatlabs/harvester/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Explain(t *testing.T) {
+	c := testSecretConfig{}
+	cfg, err := New(&c, nil)
+	require.NoError(t, err)
+
+	p, err := cfg.Explain("Age")
+	require.NoError(t, err)
+	assert.Equal(t, Provenance{
+		Name:    "Age",
+		Type:    "Int64",
+		Value:   "0",
+		Sources: map[Source]string{SourceConsul: "/config/age"},
+	}, p)
+
+	cfg.Fields[0].RecordAttempt(SourceEnv, "ENV_AGE", "not found")
+	require.NoError(t, cfg.Fields[0].Set("25", 3, WithOrigin(SourceConsul, "/config/age"), WithPhase(PhaseMonitor)))
+	require.NoError(t, cfg.Fields[1].Set("s3cr3t", 0, WithOrigin(SourceEnv, "ENV_PASSWORD"), WithPhase(PhaseSeed),
+		WithStale()))
+
+	p, err = cfg.Explain("Age")
+	require.NoError(t, err)
+	assert.Equal(t, "25", p.Value)
+	assert.Equal(t, SourceConsul, p.Source)
+	assert.Equal(t, "/config/age", p.Key)
+	assert.Equal(t, uint64(3), p.Version)
+	assert.Equal(t, PhaseMonitor, p.Phase)
+	assert.False(t, p.Stale)
+	assert.False(t, p.Time.IsZero())
+	assert.Equal(t, []Attempt{{Source: SourceEnv, Key: "ENV_AGE", Reason: "not found"}}, p.Attempts)
+
+	pp := cfg.Provenance()
+	require.Len(t, pp, 2)
+	assert.Equal(t, "Age", pp[0].Name)
+	assert.Equal(t, "Password", pp[1].Name)
+	assert.Equal(t, "***", pp[1].Value)
+	assert.Equal(t, SourceEnv, pp[1].Source)
+	assert.True(t, pp[1].Stale)
+	assert.Empty(t, pp[1].Attempts)
+
+	cfg.Fields[0].ResetAttempts()
+	p, err = cfg.Explain("Age")
+	require.NoError(t, err)
+	assert.Empty(t, p.Attempts)
+
+	_, err = cfg.Explain("Unknown")
+	require.EqualError(t, err, "field Unknown not found")
+}
+
+func TestConfig_Explain_SeedRedaction(t *testing.T) {
+	cfg, err := New(&struct {
+		Name     sync.String `seed:"John"`
+		Password sync.Secret `seed:"hunter2" env:"ENV_PASSWORD"`
+	}{}, nil)
+	require.NoError(t, err)
+
+	p, err := cfg.Explain("Name")
+	require.NoError(t, err)
+	assert.Equal(t, map[Source]string{SourceSeed: "John"}, p.Sources)
+
+	p, err = cfg.Explain("Password")
+	require.NoError(t, err)
+	assert.Equal(t, map[Source]string{SourceSeed: "***", SourceEnv: "ENV_PASSWORD"}, p.Sources)
+}
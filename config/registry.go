@@ -0,0 +1,132 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Default precedences of the built-in sources. Sources with a higher precedence are applied later
+// during seeding and therefore override the values of the sources with a lower precedence.
+const (
+	PrecedenceSeed   = 100
+	PrecedenceEnv    = 200
+	PrecedenceFile   = 300
+	PrecedenceConsul = 400
+	PrecedenceEtcd   = 450
+	PrecedenceRedis  = 500
+	PrecedenceVault  = 550
+	PrecedenceFlag   = 600
+)
+
+// builtIn sources, whose names can't be registered again.
+var builtIn = map[Source]bool{
+	SourceSeed: true, SourceEnv: true, SourceFile: true, SourceConsul: true, SourceEtcd: true, SourceRedis: true,
+	SourceVault: true, SourceFlag: true,
+}
+
+// local sources, whose keys are not shared across fields, e.g. the names of environment variables.
+var local = map[Source]bool{SourceSeed: true, SourceEnv: true, SourceFile: true, SourceFlag: true}
+
+// reservedTags are the struct tags of the fields which are not sources.
+var reservedTags = map[string]bool{
+	precedenceTag: true, harvesterTag: true, requiredTag: true, onDeleteTag: true, strictTag: true, schemaTag: true,
+	consulPrefixTag: true, etcdPrefixTag: true, RedisHashTag: true, RedisJSONTag: true, minTag: true, maxTag: true,
+	oneOfTag: true, regexTag: true, nonEmptyTag: true, validateTag: true,
+}
+
+type registry struct {
+	mu          sync.RWMutex
+	precedences map[Source]int
+}
+
+var sources = &registry{
+	precedences: map[Source]int{
+		SourceSeed:   PrecedenceSeed,
+		SourceEnv:    PrecedenceEnv,
+		SourceFile:   PrecedenceFile,
+		SourceConsul: PrecedenceConsul,
+		SourceEtcd:   PrecedenceEtcd,
+		SourceRedis:  PrecedenceRedis,
+		SourceVault:  PrecedenceVault,
+		SourceFlag:   PrecedenceFlag,
+	},
+}
+
+// RegisterSource registers a source, which makes its name available as a struct tag.
+// The precedence defines the order in which the source is applied during seeding.
+// Registering an existing source again with the same precedence is a no-op. The names of the built-in sources and of
+// the other struct tags of the fields, e.g. on_delete, are reserved.
+func RegisterSource(src Source, precedence int) error {
+	if src == "" {
+		return errors.New("source name is empty")
+	}
+	if builtIn[src] {
+		return fmt.Errorf("source %s is built in", src)
+	}
+	if reservedTags[string(src)] {
+		return fmt.Errorf("source name %s is a reserved tag", src)
+	}
+	sources.mu.Lock()
+	defer sources.mu.Unlock()
+	if p, ok := sources.precedences[src]; ok {
+		if p == precedence {
+			return nil
+		}
+		return fmt.Errorf("source %s is already registered with precedence %d", src, p)
+	}
+	sources.precedences[src] = precedence
+	return nil
+}
+
+// IsRegistered returns true if the source has been registered.
+func IsRegistered(src Source) bool {
+	sources.mu.RLock()
+	defer sources.mu.RUnlock()
+	_, ok := sources.precedences[src]
+	return ok
+}
+
+// RegisteredSources returns all registered sources ordered by their precedence.
+// Sources with the same precedence are ordered by name.
+func RegisteredSources() []Source {
+	sources.mu.RLock()
+	defer sources.mu.RUnlock()
+	ss := make([]Source, 0, len(sources.precedences))
+	for src := range sources.precedences {
+		ss = append(ss, src)
+	}
+	sort.Slice(ss, func(i, j int) bool {
+		pi, pj := sources.precedences[ss[i]], sources.precedences[ss[j]]
+		if pi == pj {
+			return ss[i] < ss[j]
+		}
+		return pi < pj
+	})
+	return ss
+}
+
+// SourceOrder returns the seeding order of the base sources, where the preferred sources are moved to the end
+// in the given order. Since later sources override earlier ones, the last preferred source has the highest
+// precedence. Base sources that are not preferred keep their relative order and are applied first.
+func SourceOrder(base []Source, preferred ...Source) ([]Source, error) {
+	seen := make(map[Source]bool, len(preferred))
+	for _, src := range preferred {
+		if !IsRegistered(src) {
+			return nil, fmt.Errorf("source %s is not registered", src)
+		}
+		if seen[src] {
+			return nil, fmt.Errorf("source %s is duplicated", src)
+		}
+		seen[src] = true
+	}
+
+	ss := make([]Source, 0, len(base)+len(preferred))
+	for _, src := range base {
+		if !seen[src] {
+			ss = append(ss, src)
+		}
+	}
+	return append(ss, preferred...), nil
+}
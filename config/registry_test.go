@@ -0,0 +1,113 @@
+package config
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/beatlabs/harvester/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterSource(t *testing.T) {
+	require.NoError(t, RegisterSource("registry-test-existing", 450))
+	tests := map[string]struct {
+		src        Source
+		precedence int
+		wantErr    bool
+	}{
+		"success":                 {src: "registry-test", precedence: 450},
+		"success same precedence": {src: "registry-test-existing", precedence: 450},
+		"empty name":              {src: "", precedence: 1, wantErr: true},
+		"different precedence":    {src: "registry-test-existing", precedence: 1, wantErr: true},
+		"built in":                {src: SourceEnv, precedence: PrecedenceEnv, wantErr: true},
+		"reserved tag":            {src: "on_delete", precedence: 450, wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := RegisterSource(tt.src, tt.precedence)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				assert.True(t, IsRegistered(tt.src))
+			}
+		})
+	}
+}
+
+func TestRegisteredSources(t *testing.T) {
+	require.NoError(t, RegisterSource("registry-test-kv", 450))
+	ss := RegisteredSources()
+	assert.Equal(t, []Source{SourceSeed, SourceEnv, SourceFile, SourceConsul}, ss[:4])
+	assert.Equal(t, SourceFlag, ss[len(ss)-1])
+	assert.Less(t, slices.Index(ss, SourceConsul), slices.Index(ss, "registry-test-kv"))
+	assert.Less(t, slices.Index(ss, "registry-test-kv"), slices.Index(ss, SourceRedis))
+}
+
+func TestNew_RegisteredSourceTag(t *testing.T) {
+	require.NoError(t, RegisterSource("registry-test-kv", 450))
+	cfg, err := New(&struct {
+		Name sync.String `seed:"John Doe" registry-test-kv:"name"`
+	}{}, nil)
+	require.NoError(t, err)
+	require.Len(t, cfg.Fields, 1)
+	assert.Equal(t, map[Source]string{SourceSeed: "John Doe", "registry-test-kv": "name"}, cfg.Fields[0].Sources())
+}
+
+func TestNew_RegisteredSourceDuplicate(t *testing.T) {
+	require.NoError(t, RegisterSource("registry-test-kv", 450))
+	cfg, err := New(&struct {
+		Name  sync.String `registry-test-kv:"name"`
+		Alias sync.String `registry-test-kv:"name"`
+	}{}, nil)
+	require.EqualError(t, err, "duplicate value  for source registry-test-kv")
+	assert.Nil(t, cfg)
+}
+
+func TestSourceOrder(t *testing.T) {
+	base := []Source{SourceSeed, SourceEnv, SourceFile, SourceConsul, SourceRedis, SourceFlag}
+	tests := map[string]struct {
+		preferred   []Source
+		want        []Source
+		expectedErr string
+	}{
+		"no preference":     {want: base},
+		"env beats consul":  {preferred: []Source{SourceConsul, SourceEnv}, want: []Source{SourceSeed, SourceFile, SourceRedis, SourceFlag, SourceConsul, SourceEnv}},
+		"consul beats flag": {preferred: []Source{SourceFlag, SourceConsul}, want: []Source{SourceSeed, SourceEnv, SourceFile, SourceRedis, SourceFlag, SourceConsul}},
+		"not registered":    {preferred: []Source{"unknown"}, expectedErr: "source unknown is not registered"},
+		"duplicated":        {preferred: []Source{SourceEnv, SourceEnv}, expectedErr: "source env is duplicated"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := SourceOrder(base, tt.preferred...)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestNew_PrecedenceTag(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cfg, err := New(&struct {
+			Name sync.String `seed:"John Doe" env:"ENV_NAME" consul:"name" precedence:"consul, env"`
+			Age  sync.Int64  `seed:"18"`
+		}{}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []Source{SourceConsul, SourceEnv}, cfg.Fields[0].Precedence())
+		assert.Nil(t, cfg.Fields[1].Precedence())
+	})
+
+	t.Run("invalid source", func(t *testing.T) {
+		cfg, err := New(&struct {
+			Name sync.String `seed:"John Doe" precedence:"unknown"`
+		}{}, nil)
+		require.EqualError(t, err, "invalid precedence of field Name: source unknown is not registered")
+		assert.Nil(t, cfg)
+	})
+}
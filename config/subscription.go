@@ -0,0 +1,211 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// DefaultQueueSize of a subscription.
+const DefaultQueueSize = 100
+
+// OverflowPolicy defines how a subscription handles a notification when its queue is full.
+type OverflowPolicy int
+
+const (
+	// PolicyDrop drops the new notification when the queue is full.
+	PolicyDrop OverflowPolicy = iota
+	// PolicyCoalesce merges a new notification into the pending notification of the same field and entry, keeping
+	// the previous value of the pending one and the current value of the new one. The merged notification moves to
+	// the end of the queue, since its current value is the latest one of the field. When the queue is full and there
+	// is no pending notification for the field and entry, the oldest pending notification is dropped.
+	PolicyCoalesce
+)
+
+// SubscribeOption for configuring a subscription.
+type SubscribeOption func(*Subscription) error
+
+// WithQueueSize sets the number of notifications which can be pending for a subscription.
+func WithQueueSize(size int) SubscribeOption {
+	return func(s *Subscription) error {
+		if size <= 0 {
+			return errors.New("queue size should be a positive number")
+		}
+		s.size = size
+		return nil
+	}
+}
+
+// WithOverflowPolicy sets the policy which is applied when the queue of a subscription is full.
+func WithOverflowPolicy(policy OverflowPolicy) SubscribeOption {
+	return func(s *Subscription) error {
+		s.policy = policy
+		return nil
+	}
+}
+
+// Subscription delivers change notifications to a function, in its own goroutine.
+// A slow function therefore never blocks the application of changes; notifications are queued instead
+// and the overflow policy is applied when the queue is full.
+type Subscription struct {
+	cfg     *Config
+	field   string
+	fn      func(ChangeNotification)
+	size    int
+	policy  OverflowPolicy
+	mu      sync.Mutex // protects the queue and the dropped counter
+	queue   []ChangeNotification
+	dropped uint64
+	signal  chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+}
+
+// Subscribe to the change notifications of all fields.
+func (c *Config) Subscribe(fn func(ChangeNotification), oo ...SubscribeOption) (*Subscription, error) {
+	return c.subscribe("", fn, oo...)
+}
+
+// OnChange subscribes to the change notifications of a field.
+func (c *Config) OnChange(fieldName string, fn func(ChangeNotification), oo ...SubscribeOption) (*Subscription, error) {
+	if c.field(fieldName) == nil {
+		return nil, fmt.Errorf("field %s not found", fieldName)
+	}
+	return c.subscribe(fieldName, fn, oo...)
+}
+
+func (c *Config) subscribe(fieldName string, fn func(ChangeNotification), oo ...SubscribeOption) (*Subscription, error) {
+	if fn == nil {
+		return nil, errors.New("subscriber function is nil")
+	}
+	s := &Subscription{
+		cfg:    c,
+		field:  fieldName,
+		fn:     fn,
+		size:   DefaultQueueSize,
+		policy: PolicyDrop,
+		signal: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	for _, o := range oo {
+		err := o(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c.subMu.Lock()
+	c.subs = append(c.subs, s)
+	c.subMu.Unlock()
+
+	s.wg.Go(s.deliver)
+	return s, nil
+}
+
+func (c *Config) field(name string) *Field {
+	for _, f := range c.Fields {
+		if f.name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (c *Config) publish(n ChangeNotification) {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	for _, s := range c.subs {
+		if s.field == "" || s.field == n.Name {
+			s.enqueue(n)
+		}
+	}
+}
+
+// Close the subscription. Pending notifications are discarded and Close waits for an ongoing delivery to return,
+// which means that it must not be called from within the subscriber function.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.cfg.subMu.Lock()
+		for i, sub := range s.cfg.subs {
+			if sub == s {
+				s.cfg.subs = append(s.cfg.subs[:i], s.cfg.subs[i+1:]...)
+				break
+			}
+		}
+		s.cfg.subMu.Unlock()
+		close(s.done)
+	})
+	s.wg.Wait()
+}
+
+// Dropped returns the number of notifications which were dropped due to the queue being full.
+func (s *Subscription) Dropped() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+func (s *Subscription) enqueue(n ChangeNotification) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.policy == PolicyCoalesce {
+		for i, pending := range s.queue {
+			if pending.Name == n.Name && pending.Entry == n.Entry {
+				n.Previous = pending.Previous
+				s.queue = slices.Delete(s.queue, i, i+1)
+				break
+			}
+		}
+	}
+
+	if len(s.queue) >= s.size {
+		s.dropped++
+		if s.policy != PolicyCoalesce {
+			return
+		}
+		s.queue = s.queue[1:]
+	}
+	s.queue = append(s.queue, n)
+
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Subscription) deliver() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.signal:
+		}
+
+		for {
+			n, ok := s.dequeue()
+			if !ok {
+				break
+			}
+			select {
+			case <-s.done:
+				return
+			default:
+			}
+			s.fn(n)
+		}
+	}
+}
+
+func (s *Subscription) dequeue() (ChangeNotification, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queue) == 0 {
+		return ChangeNotification{}, false
+	}
+	n := s.queue[0]
+	s.queue = s.queue[1:]
+	return n, true
+}
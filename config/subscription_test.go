@@ -0,0 +1,194 @@
+package config
+
+import (
+	"strconv"
+	stdsync "sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Subscribe(t *testing.T) {
+	c := testConfig{}
+	cfg, err := New(&c, nil)
+	require.NoError(t, err)
+
+	ch := make(chan ChangeNotification, 10)
+	sub, err := cfg.Subscribe(func(n ChangeNotification) { ch <- n })
+	require.NoError(t, err)
+	defer sub.Close()
+
+	require.NoError(t, cfg.Fields[0].Set("John Doe", 1))
+	require.NoError(t, cfg.Fields[1].Set("18", 1))
+
+	n := <-ch
+	assert.Equal(t, "Name", n.Name)
+	assert.Equal(t, "John Doe", n.Current)
+	n = <-ch
+	assert.Equal(t, "Age", n.Name)
+	assert.Equal(t, "18", n.Current)
+	assert.Equal(t, uint64(0), sub.Dropped())
+}
+
+func TestConfig_OnChange(t *testing.T) {
+	c := testConfig{}
+	cfg, err := New(&c, nil)
+	require.NoError(t, err)
+
+	ch := make(chan ChangeNotification, 10)
+	sub, err := cfg.OnChange("Age", func(n ChangeNotification) { ch <- n })
+	require.NoError(t, err)
+	defer sub.Close()
+
+	require.NoError(t, cfg.Fields[0].Set("John Doe", 1))
+	require.NoError(t, cfg.Fields[1].Set("18", 1))
+
+	n := <-ch
+	assert.Equal(t, "Age", n.Name)
+	assert.Empty(t, ch)
+}
+
+func TestConfig_Subscribe_Errors(t *testing.T) {
+	cfg, err := New(&testConfig{}, nil)
+	require.NoError(t, err)
+	fn := func(ChangeNotification) {}
+
+	tests := map[string]struct {
+		field string
+		fn    func(ChangeNotification)
+		oo    []SubscribeOption
+		err   string
+	}{
+		"unknown field":      {field: "Unknown", fn: fn, err: "field Unknown not found"},
+		"nil function":       {field: "Age", fn: nil, err: "subscriber function is nil"},
+		"invalid queue size": {field: "Age", fn: fn, oo: []SubscribeOption{WithQueueSize(0)}, err: "queue size should be a positive number"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			sub, err := cfg.OnChange(tt.field, tt.fn, tt.oo...)
+			require.EqualError(t, err, tt.err)
+			assert.Nil(t, sub)
+		})
+	}
+}
+
+func TestSubscription_Overflow(t *testing.T) {
+	tests := map[string]struct {
+		policy   OverflowPolicy
+		expected []string
+		dropped  uint64
+	}{
+		"drop": {
+			policy:   PolicyDrop,
+			expected: []string{"0->1", "1->2"},
+			dropped:  2,
+		},
+		"coalesce": {
+			policy:   PolicyCoalesce,
+			expected: []string{"0->1", "1->4"},
+			dropped:  0,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := New(&testConfig{}, nil)
+			require.NoError(t, err)
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			var mu stdsync.Mutex
+			var got []string
+			sub, err := cfg.OnChange("Age", func(n ChangeNotification) {
+				mu.Lock()
+				got = append(got, n.Previous+"->"+n.Current)
+				first := len(got) == 1
+				mu.Unlock()
+				if first {
+					close(started)
+					<-release
+				}
+			}, WithQueueSize(1), WithOverflowPolicy(tt.policy))
+			require.NoError(t, err)
+			defer sub.Close()
+
+			require.NoError(t, cfg.Fields[1].Set("1", 1))
+			<-started
+			for i := 2; i <= 4; i++ {
+				require.NoError(t, cfg.Fields[1].Set(strconv.Itoa(i), uint64(i)))
+			}
+			close(release)
+
+			require.Eventually(t, func() bool {
+				mu.Lock()
+				defer mu.Unlock()
+				return len(got) == len(tt.expected)
+			}, time.Second, time.Millisecond)
+			mu.Lock()
+			assert.Equal(t, tt.expected, got)
+			mu.Unlock()
+			assert.Equal(t, tt.dropped, sub.Dropped())
+		})
+	}
+}
+
+func TestSubscription_CoalesceEntries(t *testing.T) {
+	cfg, err := New(&testEntriesConfig{}, nil)
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var mu stdsync.Mutex
+	var got []string
+	sub, err := cfg.OnChange("Flags", func(n ChangeNotification) {
+		mu.Lock()
+		got = append(got, n.Entry+":"+n.Previous+"->"+n.Current)
+		first := len(got) == 1
+		mu.Unlock()
+		if first {
+			close(started)
+			<-release
+		}
+	}, WithQueueSize(2), WithOverflowPolicy(PolicyCoalesce))
+	require.NoError(t, err)
+	defer sub.Close()
+
+	f := cfg.Fields[0]
+	require.NoError(t, f.Set("true", 1, WithEntry("alpha")))
+	<-started
+	// the notifications of different entries are kept, while the ones of the same entry are merged
+	require.NoError(t, f.Set("true", 2, WithEntry("beta")))
+	require.NoError(t, f.Set("false", 3, WithEntry("alpha")))
+	require.NoError(t, f.Set("true", 4, WithEntry("alpha")))
+	close(release)
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(got) == 3
+	}, time.Second, time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{
+		"alpha:->alpha=true",
+		"beta:alpha=true->alpha=true,beta=true",
+		"alpha:alpha=true,beta=true->alpha=true,beta=true",
+	}, got)
+	assert.Equal(t, uint64(0), sub.Dropped())
+}
+
+func TestSubscription_Close(t *testing.T) {
+	cfg, err := New(&testConfig{}, nil)
+	require.NoError(t, err)
+
+	ch := make(chan ChangeNotification, 10)
+	sub, err := cfg.Subscribe(func(n ChangeNotification) { ch <- n })
+	require.NoError(t, err)
+	sub.Close()
+	sub.Close()
+
+	require.NoError(t, cfg.Fields[1].Set("18", 1))
+	assert.Empty(t, ch)
+	assert.Empty(t, cfg.subs)
+}
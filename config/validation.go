@@ -0,0 +1,183 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Validation tags.
+const (
+	minTag      = "min"
+	maxTag      = "max"
+	oneOfTag    = "oneof"
+	regexTag    = "regex"
+	nonEmptyTag = "nonempty"
+	validateTag = "validate"
+)
+
+// Validator validates a value before it is applied to a field.
+type Validator func(value string) error
+
+// ValidationError is returned when a value is rejected by one of the validation rules of a field.
+// The value itself is not part of the error, since the field could hold a secret.
+type ValidationError struct {
+	Field string
+	Rule  string
+	Err   error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("field %s failed %s validation: %v", e.Field, e.Rule, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+var validators = struct {
+	mu sync.RWMutex
+	m  map[string]Validator
+}{m: make(map[string]Validator)}
+
+// RegisterValidator registers a custom validator which can be referenced by name with the `validate` tag,
+// e.g. `validate:"port,even"`. Validators have to be registered before the configuration is created.
+func RegisterValidator(name string, v Validator) error {
+	if name == "" {
+		return errors.New("validator name is empty")
+	}
+	if v == nil {
+		return errors.New("validator is nil")
+	}
+	validators.mu.Lock()
+	defer validators.mu.Unlock()
+	validators.m[name] = v
+	return nil
+}
+
+func lookupValidator(name string) (Validator, bool) {
+	validators.mu.RLock()
+	defer validators.mu.RUnlock()
+	v, ok := validators.m[name]
+	return v, ok
+}
+
+type rule struct {
+	name     string
+	validate Validator
+}
+
+// newRules creates the validation rules declared with the tags of a field.
+func newRules(tag reflect.StructTag) ([]rule, error) {
+	var rr []rule
+
+	if value, ok := tag.Lookup(nonEmptyTag); ok {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag: %w", nonEmptyTag, err)
+		}
+		if enabled {
+			rr = append(rr, rule{name: nonEmptyTag, validate: validateNonEmpty})
+		}
+	}
+
+	if value, ok := tag.Lookup(minTag); ok {
+		v, err := newBoundValidator(value, func(c int) bool { return c >= 0 }, "at least")
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag: %w", minTag, err)
+		}
+		rr = append(rr, rule{name: minTag, validate: v})
+	}
+
+	if value, ok := tag.Lookup(maxTag); ok {
+		v, err := newBoundValidator(value, func(c int) bool { return c <= 0 }, "at most")
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag: %w", maxTag, err)
+		}
+		rr = append(rr, rule{name: maxTag, validate: v})
+	}
+
+	if value, ok := tag.Lookup(oneOfTag); ok {
+		rr = append(rr, rule{name: oneOfTag, validate: newOneOfValidator(strings.Fields(value))})
+	}
+
+	if value, ok := tag.Lookup(regexTag); ok {
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag: %w", regexTag, err)
+		}
+		rr = append(rr, rule{name: regexTag, validate: func(value string) error {
+			if !re.MatchString(value) {
+				return fmt.Errorf("value does not match %s", re)
+			}
+			return nil
+		}})
+	}
+
+	if value, ok := tag.Lookup(validateTag); ok {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			v, ok := lookupValidator(name)
+			if !ok {
+				return nil, fmt.Errorf("validator %s is not registered", name)
+			}
+			rr = append(rr, rule{name: name, validate: v})
+		}
+	}
+
+	return rr, nil
+}
+
+func validateNonEmpty(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("value is empty")
+	}
+	return nil
+}
+
+// newBoundValidator creates a validator which compares numbers, or durations when the bound is a duration.
+func newBoundValidator(bound string, ok func(c int) bool, desc string) (Validator, error) {
+	if b, err := strconv.ParseFloat(bound, 64); err == nil {
+		return func(value string) error {
+			v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return errors.New("value is not a number")
+			}
+			if !ok(cmp.Compare(v, b)) {
+				return fmt.Errorf("value should be %s %s", desc, bound)
+			}
+			return nil
+		}, nil
+	}
+
+	b, err := time.ParseDuration(bound)
+	if err != nil {
+		return nil, fmt.Errorf("bound %s is neither a number nor a duration", bound)
+	}
+	return func(value string) error {
+		v, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return errors.New("value is not a duration")
+		}
+		if !ok(cmp.Compare(v, b)) {
+			return fmt.Errorf("value should be %s %s", desc, bound)
+		}
+		return nil
+	}, nil
+}
+
+func newOneOfValidator(values []string) Validator {
+	return func(value string) error {
+		if slices.Contains(values, value) {
+			return nil
+		}
+		return fmt.Errorf("value should be one of %v", values)
+	}
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/beatlabs/harvester/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestField_Set_Validation(t *testing.T) {
+	require.NoError(t, RegisterValidator("even", func(value string) error {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if v%2 != 0 {
+			return errors.New("value is odd")
+		}
+		return nil
+	}))
+
+	c := testValidationConfig{}
+	cfg, err := New(&c, nil)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		field       *Field
+		value       string
+		expectedErr string
+	}{
+		"min success":            {field: cfg.Fields[0], value: "1"},
+		"min failure":            {field: cfg.Fields[0], value: "-5", expectedErr: "field WorkerCount failed min validation: value should be at least 1"},
+		"max success":            {field: cfg.Fields[0], value: "10"},
+		"max failure":            {field: cfg.Fields[0], value: "11", expectedErr: "field WorkerCount failed max validation: value should be at most 10"},
+		"not a number":           {field: cfg.Fields[0], value: "XXX", expectedErr: "field WorkerCount failed min validation: value is not a number"},
+		"duration min success":   {field: cfg.Fields[1], value: "1s"},
+		"duration min failure":   {field: cfg.Fields[1], value: "10ms", expectedErr: "field Timeout failed min validation: value should be at least 100ms"},
+		"not a duration":         {field: cfg.Fields[1], value: "XXX", expectedErr: "field Timeout failed min validation: value is not a duration"},
+		"oneof success":          {field: cfg.Fields[2], value: "INFO"},
+		"oneof failure":          {field: cfg.Fields[2], value: "TRACE", expectedErr: "field LogLevel failed oneof validation: value should be one of [DEBUG INFO ERROR]"},
+		"regex success":          {field: cfg.Fields[3], value: "db-1"},
+		"regex failure":          {field: cfg.Fields[3], value: "DB_1", expectedErr: "field Host failed regex validation: value does not match ^[a-z0-9-]+$"},
+		"nonempty success":       {field: cfg.Fields[4], value: "secret"},
+		"nonempty failure":       {field: cfg.Fields[4], value: " ", expectedErr: "field Token failed nonempty validation: value is empty"},
+		"custom success":         {field: cfg.Fields[5], value: "8080"},
+		"custom failure":         {field: cfg.Fields[5], value: "8081", expectedErr: "field Port failed even validation: value is odd"},
+		"nonempty false success": {field: cfg.Fields[6], value: ""},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			prev := tt.field.String()
+			err := tt.field.Set(tt.value, 0)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				var verr *ValidationError
+				require.ErrorAs(t, err, &verr)
+				assert.Equal(t, tt.field.Name(), verr.Field)
+				assert.Equal(t, prev, tt.field.String())
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNew_InvalidValidation(t *testing.T) {
+	tests := map[string]struct {
+		cfg         any
+		expectedErr string
+	}{
+		"invalid min": {cfg: &struct {
+			Age sync.Int64 `seed:"1" min:"XXX"`
+		}{}, expectedErr: "invalid validation of field Age: invalid min tag: bound XXX is neither a number nor a duration"},
+		"invalid max": {cfg: &struct {
+			Age sync.Int64 `seed:"1" max:"XXX"`
+		}{}, expectedErr: "invalid validation of field Age: invalid max tag: bound XXX is neither a number nor a duration"},
+		"invalid regex": {cfg: &struct {
+			Name sync.String `seed:"1" regex:"["`
+		}{}, expectedErr: "invalid validation of field Name: invalid regex tag: error parsing regexp: missing closing ]: `[`"},
+		"invalid nonempty": {cfg: &struct {
+			Name sync.String `seed:"1" nonempty:"XXX"`
+		}{}, expectedErr: "invalid validation of field Name: invalid nonempty tag: strconv.ParseBool: parsing \"XXX\": invalid syntax"},
+		"unknown validator": {cfg: &struct {
+			Name sync.String `seed:"1" validate:"unknown"`
+		}{}, expectedErr: "invalid validation of field Name: validator unknown is not registered"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := New(tt.cfg, nil)
+			require.EqualError(t, err, tt.expectedErr)
+			assert.Nil(t, got)
+		})
+	}
+}
+
+func TestRegisterValidator(t *testing.T) {
+	require.EqualError(t, RegisterValidator("", func(string) error { return nil }), "validator name is empty")
+	require.EqualError(t, RegisterValidator("nil", nil), "validator is nil")
+}
+
+func TestConfig_Apply_Validation(t *testing.T) {
+	c := testValidationConfig{}
+	cfg, err := New(&c, nil)
+	require.NoError(t, err)
+
+	err = cfg.Apply(
+		Update{Field: cfg.Fields[0], Value: "5", Version: 1},
+		Update{Field: cfg.Fields[2], Value: "TRACE", Version: 1},
+	)
+	require.EqualError(t, err, "field LogLevel failed oneof validation: value should be one of [DEBUG INFO ERROR]")
+	assert.Equal(t, int64(0), c.WorkerCount.Get())
+}
+
+func TestField_Set_EntryValidation(t *testing.T) {
+	c := testEntryValidationConfig{}
+	cfg, err := New(&c, nil)
+	require.NoError(t, err)
+	f := cfg.Fields[0]
+	require.NoError(t, f.Set("a", 1, WithEntry("host")))
+
+	// the rules are checked against the whole value with the entry applied
+	err = f.Set("B", 2, WithEntry("host"))
+	require.EqualError(t, err, "field Hosts failed regex validation: value does not match ^[a-z=,]*$")
+	err = f.Set("", 2, WithEntry("host"), WithDeleted())
+	require.EqualError(t, err, "field Hosts failed nonempty validation: value is empty")
+	assert.Equal(t, map[string]string{"host": "a"}, c.Hosts.Get())
+
+	err = cfg.Apply(
+		Update{Field: f, Value: "b", Version: 3, Entry: "other"},
+		Update{Field: f, Value: "C", Version: 3, Entry: "host"},
+	)
+	require.EqualError(t, err, "field Hosts failed regex validation: value does not match ^[a-z=,]*$")
+	assert.Equal(t, map[string]string{"host": "a"}, c.Hosts.Get())
+
+	require.NoError(t, f.Set("b", 4, WithEntry("other")))
+	assert.Equal(t, map[string]string{"host": "a", "other": "b"}, c.Hosts.Get())
+}
+
+func TestConfig_Apply_EntryBatch(t *testing.T) {
+	c := testEntryBatchConfig{}
+	cfg, err := New(&c, nil)
+	require.NoError(t, err)
+	single, docs := cfg.Fields[0], cfg.Fields[1]
+
+	// the rules see the result of the whole batch, not each entry on its own
+	err = cfg.Apply(
+		Update{Field: single, Value: "x", Version: 1, Entry: "a"},
+		Update{Field: single, Value: "y", Version: 1, Entry: "b"},
+	)
+	require.EqualError(t, err, "field Single failed regex validation: value does not match ^[^,]*$")
+	assert.Empty(t, c.Single.Get())
+
+	require.NoError(t, single.Set("x", 2, WithEntry("a")))
+	require.NoError(t, cfg.Apply(
+		Update{Field: single, Version: 3, Entry: "a", Deleted: true},
+		Update{Field: single, Value: "y", Version: 3, Entry: "b"},
+	))
+	assert.Equal(t, map[string]string{"b": "y"}, c.Single.Get())
+
+	// values with commas and equal signs are copied entry by entry
+	require.NoError(t, docs.Set(`{"a":1,"b":2}`, 1, WithEntry("x")))
+	require.NoError(t, docs.Set("a=b", 2, WithEntry("y")))
+	require.NoError(t, cfg.Apply(
+		Update{Field: docs, Value: `{"c":3}`, Version: 3, Entry: "x"},
+		Update{Field: docs, Value: "c,d", Version: 3, Entry: "z"},
+	))
+	assert.Equal(t, map[string]string{"x": `{"c":3}`, "y": "a=b", "z": "c,d"}, c.Docs.Get())
+}
+
+type testEntryBatchConfig struct {
+	Single sync.Map[string, string] `consul_prefix:"single/" regex:"^[^,]*$"`
+	Docs   sync.Map[string, string] `consul_prefix:"docs/" nonempty:"true"`
+}
+
+type testEntryValidationConfig struct {
+	Hosts sync.Map[string, string] `consul_prefix:"hosts/" nonempty:"true" regex:"^[a-z=,]*$"`
+}
+
+type testValidationConfig struct {
+	WorkerCount sync.Int64        `seed:"1" min:"1" max:"10"`
+	Timeout     sync.TimeDuration `seed:"1s" min:"100ms"`
+	LogLevel    sync.String       `seed:"INFO" oneof:"DEBUG INFO ERROR"`
+	Host        sync.String       `seed:"localhost" regex:"^[a-z0-9-]+$"`
+	Token       sync.Secret       `seed:"token" nonempty:"true"`
+	Port        sync.Int64        `seed:"80" validate:"even"`
+	Optional    sync.String       `seed:"" nonempty:"false"`
+}
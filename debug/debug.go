@@ -0,0 +1,191 @@
+// Package debug exposes the live configuration over HTTP.
+package debug
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/beatlabs/harvester/config"
+)
+
+// Formats supported by the handler.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Field describes a config field, as rendered by the handler.
+type Field struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Value    string            `json:"value"`
+	Source   string            `json:"source,omitempty"`
+	Key      string            `json:"key,omitempty"`
+	Version  uint64            `json:"version"`
+	Phase    string            `json:"phase,omitempty"`
+	Stale    bool              `json:"stale,omitempty"`
+	Deleted  bool              `json:"deleted,omitempty"`
+	Updated  *time.Time        `json:"updated,omitempty"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Attempts []Attempt         `json:"attempts,omitempty"`
+}
+
+// Attempt describes a source which was tried while seeding a field, but did not provide a value.
+type Attempt struct {
+	Source string `json:"source"`
+	Key    string `json:"key"`
+	Reason string `json:"reason"`
+}
+
+// Handler renders the current config fields, similar to expvar.
+// The output is JSON by default. Plain text is rendered when the format query parameter is set to text,
+// or when the request accepts text/plain but not application/json.
+// Values are rendered with the String method of the field, which means that secrets are redacted.
+type Handler struct {
+	fields func() []config.Provenance
+}
+
+// New creates a handler, which takes the fields from the provided function, e.g. the Fields method of the harvester
+// or the Provenance method of the config.
+func New(fields func() []config.Provenance) (*Handler, error) {
+	if fields == nil {
+		return nil, errors.New("fields function is nil")
+	}
+	return &Handler{fields: fields}, nil
+}
+
+// ServeHTTP renders the fields.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ff := convert(h.fields())
+
+	var err error
+	switch format(r) {
+	case FormatJSON:
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(ff)
+	case FormatText:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		err = writeText(w, ff)
+	default:
+		http.Error(w, "unsupported format", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		slog.Error("failed to write debug response", "err", err)
+	}
+}
+
+func format(r *http.Request) string {
+	if f := r.URL.Query().Get("format"); f != "" {
+		return f
+	}
+	text := false
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil {
+			continue
+		}
+		switch mt {
+		case "application/json":
+			return FormatJSON
+		case "text/plain":
+			text = true
+		}
+	}
+	if text {
+		return FormatText
+	}
+	return FormatJSON
+}
+
+func convert(pp []config.Provenance) []Field {
+	ff := make([]Field, 0, len(pp))
+	for _, p := range pp {
+		f := Field{
+			Name:    p.Name,
+			Type:    p.Type,
+			Value:   p.Value,
+			Source:  string(p.Source),
+			Key:     p.Key,
+			Version: p.Version,
+			Phase:   string(p.Phase),
+			Stale:   p.Stale,
+			Deleted: p.Deleted,
+		}
+		if !p.Time.IsZero() {
+			updated := p.Time
+			f.Updated = &updated
+		}
+		if len(p.Sources) > 0 {
+			f.Tags = make(map[string]string, len(p.Sources))
+			for src, key := range p.Sources {
+				f.Tags[string(src)] = key
+			}
+		}
+		for _, a := range p.Attempts {
+			f.Attempts = append(f.Attempts, Attempt{Source: string(a.Source), Key: a.Key, Reason: a.Reason})
+		}
+		ff = append(ff, f)
+	}
+	return ff
+}
+
+func writeText(w io.Writer, ff []Field) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, err := fmt.Fprintln(tw, "NAME\tTYPE\tVALUE\tSOURCE\tKEY\tVERSION\tPHASE\tUPDATED\tTAGS")
+	if err != nil {
+		return err
+	}
+	for _, f := range ff {
+		updated := "-"
+		if f.Updated != nil {
+			updated = f.Updated.Format(time.RFC3339)
+		}
+		_, err = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n", f.Name, f.Type, f.Value, orDash(f.Source),
+			orDash(f.Key), f.Version, orDash(f.Phase), updated, tags(f.Tags))
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func tags(tt map[string]string) string {
+	if len(tt) == 0 {
+		return "-"
+	}
+	names := make([]string, 0, len(tt))
+	for name := range tt {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	ss := make([]string, 0, len(names))
+	for _, name := range names {
+		ss = append(ss, fmt.Sprintf("%s:%q", name, tt[name]))
+	}
+	return strings.Join(ss, " ")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,127 @@
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	h, err := New(nil)
+	require.EqualError(t, err, "fields function is nil")
+	assert.Nil(t, h)
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	cfg := newTestConfig(t)
+	h, err := New(cfg.Provenance)
+	require.NoError(t, err)
+
+	t.Run("json", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/harvester", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+		var ff []Field
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &ff))
+		require.Len(t, ff, 2)
+		assert.Equal(t, "Age", ff[0].Name)
+		assert.Equal(t, "25", ff[0].Value)
+		assert.Equal(t, "consul", ff[0].Source)
+		assert.Equal(t, "/config/age", ff[0].Key)
+		assert.Equal(t, uint64(3), ff[0].Version)
+		assert.Equal(t, "monitor", ff[0].Phase)
+		assert.True(t, ff[0].Stale)
+		assert.NotNil(t, ff[0].Updated)
+		assert.Equal(t, map[string]string{"consul": "/config/age", "seed": "18"}, ff[0].Tags)
+		assert.Equal(t, "***", ff[1].Value)
+		assert.Empty(t, ff[1].Source)
+		assert.False(t, ff[1].Stale)
+		assert.NotContains(t, rec.Body.String(), "s3cr3t")
+	})
+
+	t.Run("text", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/harvester?format=text", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+		lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+		require.Len(t, lines, 3)
+		assert.Equal(t, []string{"NAME", "TYPE", "VALUE", "SOURCE", "KEY", "VERSION", "PHASE", "UPDATED", "TAGS"},
+			strings.Fields(lines[0]))
+		assert.Equal(t, []string{"Age", "Int64", "25", "consul", "/config/age", "3", "monitor"},
+			strings.Fields(lines[1])[:7])
+		assert.True(t, strings.HasSuffix(lines[1], `consul:"/config/age" seed:"18"`))
+		assert.Equal(t, []string{"Password", "Secret", "***", "-", "-", "0", "-"}, strings.Fields(lines[2])[:7])
+		assert.True(t, strings.HasSuffix(lines[2], `env:"ENV_PASSWORD"`))
+	})
+
+	t.Run("text by accept header", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/debug/harvester", nil)
+		req.Header.Set("Accept", "text/plain")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/harvester?format=xml", nil))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("method not allowed", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/debug/harvester", nil))
+
+		assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+		assert.Equal(t, "GET, HEAD", rec.Header().Get("Allow"))
+	})
+}
+
+func newTestConfig(t *testing.T) *config.Config {
+	t.Helper()
+	cfg, err := config.New(&testConfig{}, nil)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Fields[0].Set("25", 3, config.WithOrigin(config.SourceConsul, "/config/age"),
+		config.WithPhase(config.PhaseMonitor), config.WithStale()))
+	require.NoError(t, cfg.Fields[1].Set("s3cr3t", 0))
+	return cfg
+}
+
+type testConfig struct {
+	Age      sync.Int64  `seed:"18" consul:"/config/age"`
+	Password sync.Secret `env:"ENV_PASSWORD"`
+}
+
+func TestHandler_ServeHTTP_SeededSecret(t *testing.T) {
+	cfg, err := config.New(&struct {
+		Password sync.Secret `seed:"hunter2"`
+	}{}, nil)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Fields[0].Set("hunter2", 0, config.WithOrigin(config.SourceSeed, "")))
+	h, err := New(cfg.Provenance)
+	require.NoError(t, err)
+
+	for _, format := range []string{FormatJSON, FormatText} {
+		t.Run(format, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/harvester?format="+format, nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NotContains(t, rec.Body.String(), "hunter2")
+			assert.Contains(t, rec.Body.String(), "***")
+		})
+	}
+}
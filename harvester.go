@@ -2,6 +2,9 @@ package harvester
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 
 	"github.com/beatlabs/harvester/config"
 	"github.com/beatlabs/harvester/monitor"
@@ -20,27 +23,165 @@ type Monitor interface {
 
 // Harvester interface.
 type Harvester interface {
+	// Harvest seeds the configuration and starts monitoring for changes, until the context is cancelled or
+	// Close is called. A harvester can be started only once.
 	Harvest(context.Context) error
 }
 
+// Runner is implemented by the harvester returned by New, which controls its lifecycle and reports its errors and
+// health, e.g. r, ok := h.(harvester.Runner).
+type Runner interface {
+	// Run harvests and blocks until monitoring has stopped and all watchers have exited.
+	Run(context.Context) error
+	// Close stops monitoring and waits for all watchers to exit.
+	Close() error
+	// Done returns a channel which is closed once monitoring has stopped and all watchers have exited.
+	Done() <-chan struct{}
+	// Errors returns a channel which receives the errors reported by the watchers while monitoring, e.g. failed
+	// Consul watch plans or Redis polls, and the rejected changes. The channel is buffered and errors are dropped
+	// when it is full.
+	Errors() <-chan error
+	// Health returns the health of the watchers, which is starting until every watcher has reported once and failed
+	// once monitoring has stopped.
+	Health() monitor.Health
+}
+
+// Observer is implemented by the harvester returned by New, which reads the fields consistently, notifies their
+// changes and explains their values, e.g. o, ok := h.(harvester.Observer).
+type Observer interface {
+	// Snapshot returns the current values of all fields, taken consistently.
+	Snapshot() config.Snapshot
+	// View calls the function while no changes can be applied, which allows reading multiple fields consistently.
+	View(func())
+	// Subscribe to the change notifications of all fields.
+	Subscribe(fn func(config.ChangeNotification), oo ...config.SubscribeOption) (*config.Subscription, error)
+	// OnChange subscribes to the change notifications of a field.
+	OnChange(fieldName string, fn func(config.ChangeNotification), oo ...config.SubscribeOption) (*config.Subscription, error)
+	// Explain returns where the value of a field came from.
+	Explain(fieldName string) (config.Provenance, error)
+	// Fields returns where the values of all fields came from.
+	Fields() []config.Provenance
+}
+
+// runningMonitor is a monitor which reports when it has stopped, its errors and its health, e.g. monitor.Monitor
+// and monitor.NoopMonitor.
+type runningMonitor interface {
+	Monitor
+	Done() <-chan struct{}
+	Errors() <-chan error
+	Health() monitor.Health
+}
+
 type harvester struct {
 	cfg     *config.Config
 	seeder  Seeder
-	monitor Monitor
+	monitor runningMonitor
+	mu      sync.Mutex // protects starting and cancel
+	cancel  context.CancelFunc
 }
 
 // Harvest take the configuration object, initializes it and monitors for changes.
 func (h *harvester) Harvest(ctx context.Context) error {
+	// Holding the lock while starting makes Close wait for the monitor to be started, in order to stop it.
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.cancel != nil {
+		return errors.New("harvester already started")
+	}
+
 	err := h.seeder.Seed(h.cfg)
 	if err != nil {
 		return err
 	}
 
-	return h.monitor.Monitor(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	h.cancel = cancel
+
+	err = h.monitor.Monitor(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	return nil
+}
+
+// Run harvests and blocks until monitoring has stopped and all watchers have exited, which happens when the context
+// is cancelled or Close is called.
+func (h *harvester) Run(ctx context.Context) error {
+	err := h.Harvest(ctx)
+	if err != nil {
+		return err
+	}
+	<-h.monitor.Done()
+	return nil
+}
+
+// Close stops monitoring and waits for all watchers to exit.
+func (h *harvester) Close() error {
+	h.mu.Lock()
+	cancel := h.cancel
+	h.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+	<-h.monitor.Done()
+	return nil
+}
+
+// Done returns a channel which is closed once monitoring has stopped and all watchers have exited.
+func (h *harvester) Done() <-chan struct{} {
+	return h.monitor.Done()
+}
+
+// Errors returns a channel which receives the errors reported by the watchers while monitoring and the rejected
+// changes.
+func (h *harvester) Errors() <-chan error {
+	return h.monitor.Errors()
+}
+
+// Health returns the health of the watchers, which is starting until every watcher has reported once and failed
+// once monitoring has stopped.
+func (h *harvester) Health() monitor.Health {
+	return h.monitor.Health()
+}
+
+// Snapshot returns the current values of all fields, taken consistently.
+func (h *harvester) Snapshot() config.Snapshot {
+	return h.cfg.Snapshot()
+}
+
+// View calls the function while no changes can be applied, which allows reading multiple fields consistently.
+func (h *harvester) View(fn func()) {
+	h.cfg.View(fn)
+}
+
+// Explain returns where the value of a field came from.
+func (h *harvester) Explain(fieldName string) (config.Provenance, error) {
+	return h.cfg.Explain(fieldName)
+}
+
+// Fields returns where the values of all fields came from.
+func (h *harvester) Fields() []config.Provenance {
+	return h.cfg.Provenance()
+}
+
+// Subscribe to the change notifications of all fields.
+func (h *harvester) Subscribe(fn func(config.ChangeNotification), oo ...config.SubscribeOption) (*config.Subscription, error) {
+	return h.cfg.Subscribe(fn, oo...)
+}
+
+// OnChange subscribes to the change notifications of a field.
+func (h *harvester) OnChange(fieldName string, fn func(config.ChangeNotification),
+	oo ...config.SubscribeOption,
+) (*config.Subscription, error) {
+	return h.cfg.OnChange(fieldName, fn, oo...)
 }
 
 // New constructor with functional options support.
-// Notification channel is optional and can be nil.
+// Notification channel is optional and can be nil. Sending to the channel blocks until the notification is received,
+// so a consumer that does not keep up delays the application of changes. Subscribe and OnChange do not have this
+// limitation and can be used alongside or instead of the channel.
 func New(cfg any, ch chan<- config.ChangeNotification, oo ...OptionFunc) (Harvester, error) {
 	hCfg, err := config.New(cfg, ch)
 	if err != nil {
@@ -51,6 +192,22 @@ func New(cfg any, ch chan<- config.ChangeNotification, oo ...OptionFunc) (Harves
 		cfg: hCfg,
 	}
 
+	for _, src := range registeredSources() {
+		if src.Getter != nil {
+			prm, err := seed.NewParam(src.Name, src.Getter)
+			if err != nil {
+				return nil, err
+			}
+			opt.seedParams = append(opt.seedParams, *prm)
+		}
+		if src.WatcherFactory != nil {
+			err = WithWatcherFactory(src.WatcherFactory)(opt)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create the watcher of source %s: %w", src.Name, err)
+			}
+		}
+	}
+
 	for _, option := range oo {
 		err = option(opt)
 		if err != nil {
@@ -58,11 +215,14 @@ func New(cfg any, ch chan<- config.ChangeNotification, oo ...OptionFunc) (Harves
 		}
 	}
 
-	sd := seed.New(opt.seedParams...)
-	var mon Monitor = monitor.NewNoop()
+	sd, err := seed.NewWithOptions(opt.seedParams, opt.seedOptions...)
+	if err != nil {
+		return nil, err
+	}
+	var mon runningMonitor = monitor.NewNoop()
 
 	if len(opt.monitorParams) > 0 {
-		mon, err = monitor.New(opt.cfg, opt.monitorParams...)
+		mon, err = monitor.NewWithOptions(opt.cfg, opt.monitorParams, opt.monitorOptions...)
 		if err != nil {
 			return nil, err
 		}
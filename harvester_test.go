@@ -1,11 +1,18 @@
 package harvester
 
 import (
+	"net/http"
+	"os"
 	"testing"
 	"time"
 
 	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/metrics"
+	"github.com/beatlabs/harvester/monitor"
+	"github.com/beatlabs/harvester/monitor/consul"
+	seedconsul "github.com/beatlabs/harvester/seed/consul"
 	"github.com/beatlabs/harvester/sync"
+	consulapi "github.com/hashicorp/consul/api"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -123,6 +130,121 @@ func TestWithNotification(t *testing.T) {
 	}
 }
 
+func TestWithSourcePrecedence(t *testing.T) {
+	t.Setenv("ENV_AGE", "42")
+
+	t.Run("success", func(t *testing.T) {
+		cfg := &testConfigPrecedence{}
+		h, err := New(cfg, nil, WithSourcePrecedence(config.SourceEnv, config.SourceSeed))
+		require.NoError(t, err)
+		require.NoError(t, h.Harvest(t.Context()))
+		assert.Equal(t, int64(18), cfg.Age.Get())
+	})
+
+	t.Run("invalid source", func(t *testing.T) {
+		h, err := New(&testConfigPrecedence{}, nil, WithSourcePrecedence("unknown"))
+		require.EqualError(t, err, "source unknown is not registered")
+		assert.Nil(t, h)
+	})
+}
+
+func TestWithPrecedenceWhileMonitoring(t *testing.T) {
+	require.NoError(t, RegisterSource(Source{Name: testSource, Precedence: 450}))
+	t.Setenv("ENV_NAME", "John Doe")
+	oo := []OptionFunc{
+		WithSourcePrecedence(testSource, config.SourceEnv),
+		WithSeedGetter(testSource, &stubGetter{value: "Mary Doe"}),
+		WithWatcher(&stubWatcher{value: "Jane Doe"}),
+	}
+
+	t.Run("default", func(t *testing.T) {
+		cfg := &testConfigMonitorPrecedence{}
+		h, err := New(cfg, nil, oo...)
+		require.NoError(t, err)
+		require.NoError(t, h.Harvest(t.Context()))
+		assert.Eventually(t, func() bool { return cfg.Name.Get() == "Jane Doe" }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("while monitoring", func(t *testing.T) {
+		cfg := &testConfigMonitorPrecedence{}
+		h, err := New(cfg, nil, append(oo, WithPrecedenceWhileMonitoring())...)
+		require.NoError(t, err)
+		require.NoError(t, h.Harvest(t.Context()))
+		assert.Never(t, func() bool { return cfg.Name.Get() != "John Doe" }, 100*time.Millisecond, 5*time.Millisecond)
+	})
+}
+
+func TestWithFileMonitor(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("name", []byte("John Doe"), 0o600))
+
+	t.Run("success", func(t *testing.T) {
+		cfg := &testConfigFile{}
+		ch := make(chan config.ChangeNotification, 10)
+		h, err := New(cfg, ch, WithFileMonitor(5*time.Millisecond))
+		require.NoError(t, err)
+		require.NoError(t, h.Harvest(t.Context()))
+		assert.Equal(t, "John Doe", cfg.Name.Get())
+
+		require.NoError(t, os.WriteFile("name", []byte("Jane Doe"), 0o600))
+		require.Eventually(t, func() bool {
+			return cfg.Name.Get() == "Jane Doe"
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("invalid poll interval", func(t *testing.T) {
+		h, err := New(&testConfigFile{}, nil, WithFileMonitor(0))
+		require.EqualError(t, err, "file monitor poll interval should be a positive number")
+		assert.Nil(t, h)
+	})
+
+	t.Run("no file fields", func(t *testing.T) {
+		h, err := New(&testConfigNoConsul{}, nil, WithFileMonitor(time.Second))
+		require.EqualError(t, err, "files are empty")
+		assert.Nil(t, h)
+	})
+}
+
+func TestWithConsulClient(t *testing.T) {
+	h, err := New(&testConfig{}, nil, WithConsulClientSeed(nil, ""))
+	require.EqualError(t, err, "client is nil")
+	assert.Nil(t, h)
+
+	h, err = New(&testConfig{}, nil, WithConsulClientMonitor(nil, ""))
+	require.EqualError(t, err, "client is nil")
+	assert.Nil(t, h)
+
+	h, err = New(&testConfig{}, nil, WithConsulConfig(nil, ""))
+	require.EqualError(t, err, "consul config is nil")
+	assert.Nil(t, h)
+
+	cfg := consulapi.DefaultConfig()
+	cfg.Address = addr
+	cfg.Namespace = "team"
+	h, err = New(&testConfig{}, nil, WithConsulConfig(cfg, "folder"))
+	require.NoError(t, err)
+	assert.NotNil(t, h)
+
+	timeoutCfg := consulapi.DefaultConfig()
+	timeoutCfg.HttpClient = &http.Client{Timeout: time.Second}
+	h, err = New(&testConfig{}, nil, WithConsulConfig(timeoutCfg, ""))
+	require.EqualError(t, err, "http client timeout is not supported, since it cuts the blocking queries short")
+	assert.Nil(t, h)
+
+	client, err := consulapi.NewClient(cfg)
+	require.NoError(t, err)
+	h, err = New(&testConfig{}, nil, WithConsulClientSeed(client, "", seedconsul.WithAllowStale(),
+		seedconsul.WithRequireConsistent()))
+	require.EqualError(t, err, "allow stale conflicts with require consistent")
+	assert.Nil(t, h)
+
+	h, err = New(&testConfig{}, nil,
+		WithConsulClientSeed(client, "", seedconsul.WithRequireConsistent(), seedconsul.WithStaleRetry()),
+		WithConsulClientMonitor(client, "", consul.WithAllowStale()))
+	require.NoError(t, err)
+	assert.NotNil(t, h)
+}
+
 func TestCreate_NoConsulOrRedis(t *testing.T) {
 	cfg := &testConfigNoConsul{}
 	got, err := New(cfg, nil)
@@ -140,6 +262,166 @@ func TestCreate_NoConsulOrRedis(t *testing.T) {
 	assert.Equal(t, int64(24), cfg.Position.Place.RoomNumber.Get())
 }
 
+func TestHarvester_Snapshot(t *testing.T) {
+	cfg := &testConfigNoConsul{}
+	h, err := New(cfg, nil)
+	require.NoError(t, err)
+	o := observer(t, h)
+	require.NoError(t, h.Harvest(t.Context()))
+
+	snapshot := o.Snapshot()
+	assert.Equal(t, uint64(8), snapshot.Generation)
+	assert.Equal(t, "John Doe", snapshot.Values["Name"])
+	assert.Equal(t, "24", snapshot.Values["PositionPlaceRoomNumber"])
+
+	var name string
+	var age int64
+	o.View(func() {
+		name = cfg.Name.Get()
+		age = cfg.Age.Get()
+	})
+	assert.Equal(t, "John Doe", name)
+	assert.Equal(t, int64(18), age)
+}
+
+func TestWithTransactionalMonitor(t *testing.T) {
+	require.NoError(t, RegisterSource(Source{Name: testSource, Precedence: 450}))
+
+	cfg := &testConfigRegisteredSource{}
+	ch := make(chan config.ChangeNotification, 1)
+	h, err := New(cfg, ch, WithSeedGetter(testSource, &stubGetter{value: "Mary Doe"}),
+		WithWatcher(&stubWatcher{value: "Jane Doe"}), WithTransactionalMonitor())
+	require.NoError(t, err)
+	o := observer(t, h)
+	require.NoError(t, h.Harvest(t.Context()))
+	assert.Equal(t, "Mary Doe", (<-ch).Current)
+	assert.Equal(t, "Jane Doe", (<-ch).Current)
+	assert.Equal(t, uint64(2), o.Snapshot().Generation)
+}
+
+func TestHarvester_Subscribe(t *testing.T) {
+	cfg := &testConfigNoConsul{}
+	h, err := New(cfg, nil)
+	require.NoError(t, err)
+	o := observer(t, h)
+
+	all := make(chan config.ChangeNotification, 10)
+	sub, err := o.Subscribe(func(n config.ChangeNotification) { all <- n })
+	require.NoError(t, err)
+	defer sub.Close()
+
+	name := make(chan config.ChangeNotification, 10)
+	sub, err = o.OnChange("Name", func(n config.ChangeNotification) { name <- n })
+	require.NoError(t, err)
+	defer sub.Close()
+
+	require.NoError(t, h.Harvest(t.Context()))
+	assert.Equal(t, "John Doe", (<-name).Current)
+	require.Eventually(t, func() bool { return len(all) == 8 }, time.Second, time.Millisecond)
+
+	_, err = o.OnChange("Unknown", func(config.ChangeNotification) {})
+	require.EqualError(t, err, "field Unknown not found")
+}
+
+func TestHarvester_Explain(t *testing.T) {
+	t.Setenv("ENV_AGE", "42")
+
+	cfg := &testConfigPrecedence{}
+	h, err := New(cfg, nil)
+	require.NoError(t, err)
+	o := observer(t, h)
+	require.NoError(t, h.Harvest(t.Context()))
+
+	p, err := o.Explain("Age")
+	require.NoError(t, err)
+	assert.Equal(t, "42", p.Value)
+	assert.Equal(t, config.SourceEnv, p.Source)
+	assert.Equal(t, "ENV_AGE", p.Key)
+
+	ff := o.Fields()
+	require.Len(t, ff, 1)
+	assert.Equal(t, p, ff[0])
+
+	_, err = o.Explain("Unknown")
+	require.EqualError(t, err, "field Unknown not found")
+}
+
+func TestHarvester_Run(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("name", []byte("John Doe"), 0o600))
+
+	cfg := &testConfigFile{}
+	h, err := New(cfg, nil, WithFileMonitor(time.Millisecond))
+	require.NoError(t, err)
+	r := runner(t, h)
+
+	ran := make(chan error)
+	go func() {
+		ran <- r.Run(t.Context())
+	}()
+	require.Eventually(t, func() bool {
+		return cfg.Name.Get() == "John Doe"
+	}, time.Second, time.Millisecond)
+
+	require.Eventually(t, func() bool {
+		return r.Health().Status == monitor.StatusOK && len(r.Health().Watchers) == 1
+	}, time.Second, time.Millisecond)
+
+	require.NoError(t, r.Close())
+	<-r.Done()
+	require.NoError(t, <-ran)
+	assert.NotNil(t, r.Errors())
+	assert.Equal(t, monitor.StatusFailed, r.Health().Status)
+}
+
+func TestWithHealthThresholds(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("name", []byte("John Doe"), 0o600))
+
+	h, err := New(&testConfigFile{}, nil, WithFileMonitor(time.Millisecond),
+		WithHealthThresholds(monitor.HealthThresholds{DegradedAge: time.Nanosecond}))
+	require.NoError(t, err)
+	r := runner(t, h)
+	assert.Equal(t, monitor.StatusStarting, r.Health().Status)
+	require.NoError(t, h.Harvest(t.Context()))
+	require.Eventually(t, func() bool {
+		return r.Health().Status == monitor.StatusDegraded
+	}, time.Second, time.Millisecond)
+	require.NoError(t, r.Close())
+}
+
+func TestHarvester_Harvest_AlreadyStarted(t *testing.T) {
+	t.Chdir(t.TempDir())
+	require.NoError(t, os.WriteFile("name", []byte("John Doe"), 0o600))
+
+	h, err := New(&testConfigFile{}, nil, WithFileMonitor(time.Millisecond))
+	require.NoError(t, err)
+	r := runner(t, h)
+	require.NoError(t, h.Harvest(t.Context()))
+	require.EqualError(t, h.Harvest(t.Context()), "harvester already started")
+	require.EqualError(t, r.Run(t.Context()), "harvester already started")
+	require.NoError(t, r.Close())
+	require.EqualError(t, h.Harvest(t.Context()), "harvester already started")
+}
+
+func TestHarvester_Close_NotStarted(t *testing.T) {
+	h, err := New(&testConfigSeedError{}, nil)
+	require.NoError(t, err)
+	r := runner(t, h)
+	require.Error(t, r.Run(t.Context()))
+	require.NoError(t, r.Close())
+}
+
+func TestWithMetrics(t *testing.T) {
+	h, err := New(&testConfigNoConsul{}, nil, WithMetrics(nil))
+	require.EqualError(t, err, "metrics recorder is nil")
+	assert.Nil(t, h)
+
+	h, err = New(&testConfigNoConsul{}, nil, WithMetrics(metrics.Noop{}))
+	require.NoError(t, err)
+	require.NoError(t, h.Harvest(t.Context()))
+}
+
 func TestCreate_SeedError(t *testing.T) {
 	cfg := &testConfigSeedError{}
 	got, err := New(cfg, nil)
@@ -173,6 +455,39 @@ type testConfigNoConsul struct {
 	}
 }
 
+type testConfigPrecedence struct {
+	Age sync.Int64 `seed:"18" env:"ENV_AGE"`
+}
+
+var (
+	_ Runner   = &harvester{}
+	_ Observer = &harvester{}
+)
+
+// runner returns the harvester as a Runner.
+func runner(t *testing.T, h Harvester) Runner {
+	t.Helper()
+	r, ok := h.(Runner)
+	require.True(t, ok)
+	return r
+}
+
+// observer returns the harvester as an Observer.
+func observer(t *testing.T, h Harvester) Observer {
+	t.Helper()
+	o, ok := h.(Observer)
+	require.True(t, ok)
+	return o
+}
+
+type testConfigMonitorPrecedence struct {
+	Name sync.String `env:"ENV_NAME" harvester-test-kv:"name"`
+}
+
+type testConfigFile struct {
+	Name sync.String `file:"name"`
+}
+
 type testConfigSeedError struct {
 	Name    sync.String       `seed:"John Doe"`
 	Age     sync.Int64        `seed:"XXX"`
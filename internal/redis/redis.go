@@ -0,0 +1,88 @@
+// Package redis parses the keys of Redis values for the seed and monitor packages.
+package redis
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Type of a Redis value.
+type Type string
+
+const (
+	// TypeString is the value of a string key.
+	TypeString Type = "string"
+	// TypeHash is the value of a field of a hash.
+	TypeHash Type = "hash"
+	// TypeJSON is the value of a path of a RedisJSON document.
+	TypeJSON Type = "json"
+)
+
+// Key of a value in Redis, which is a string key, e.g. age, the field of a hash, e.g. svc-config#age, or the path of a
+// RedisJSON document, e.g. svc-config#$.age.
+type Key struct {
+	Type Type
+	// Name of the Redis key.
+	Name string
+	// Field of a hash or path of a document.
+	Field string
+}
+
+// ParseKey parses a key of the type. The keys of hashes and documents have the form key#field, while string keys are
+// used as they are.
+func ParseKey(tp Type, key string) (Key, error) {
+	if tp == TypeString {
+		return Key{Type: TypeString, Name: key}, nil
+	}
+	name, field, ok := strings.Cut(key, "#")
+	if !ok || name == "" || field == "" {
+		return Key{}, fmt.Errorf("redis %s key %q should have the form key#field", tp, key)
+	}
+	return Key{Type: tp, Name: name, Field: field}, nil
+}
+
+// Types of the keys, which are string keys unless they are hash or document keys.
+type Types map[string]Type
+
+// Add the keys with the type.
+func (tt Types) Add(tp Type, keys ...string) {
+	for _, key := range keys {
+		tt[key] = tp
+	}
+}
+
+// Parse the key with its type.
+func (tt Types) Parse(key string) (Key, error) {
+	tp, ok := tt[key]
+	if !ok {
+		tp = TypeString
+	}
+	return ParseKey(tp, key)
+}
+
+// JSONValue returns the value of the reply of JSON.GET for the path. JSONPath paths, which start with $, reply with
+// an array of their matches, of which the first is used, while legacy paths reply with the value itself. Strings are
+// returned unquoted and other values as JSON. It returns false if the path matched nothing.
+func JSONValue(reply, path string) (string, bool, error) {
+	raw := json.RawMessage(reply)
+	if strings.HasPrefix(path, "$") {
+		var matches []json.RawMessage
+		err := json.Unmarshal(raw, &matches)
+		if err != nil {
+			return "", false, fmt.Errorf("redis json reply of path %s is not an array: %w", path, err)
+		}
+		if len(matches) == 0 {
+			return "", false, nil
+		}
+		raw = matches[0]
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s, true, nil
+	}
+	if !json.Valid(raw) {
+		return "", false, fmt.Errorf("redis json reply of path %s is not valid", path)
+	}
+	return string(raw), true, nil
+}
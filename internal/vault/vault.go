@@ -0,0 +1,116 @@
+// Package vault reads secrets from Vault for the seed and monitor packages.
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+)
+
+// Key of a value in Vault, which is the path of a secret and the field of its data, e.g. secret/data/app#password.
+type Key struct {
+	Path  string
+	Field string
+}
+
+// ParseKey parses a key of the form path#field.
+func ParseKey(key string) (Key, error) {
+	path, field, ok := strings.Cut(key, "#")
+	path = strings.Trim(path, "/")
+	if !ok || path == "" || field == "" {
+		return Key{}, fmt.Errorf("vault key %q should have the form path#field", key)
+	}
+	return Key{Path: path, Field: field}, nil
+}
+
+// ErrNotFound is returned when a secret does not exist.
+var ErrNotFound = errors.New("secret not found")
+
+// Secret read from Vault.
+type Secret struct {
+	Data map[string]any
+	// Version of a KV v2 secret, 0 for other secrets.
+	Version       uint64
+	LeaseID       string
+	LeaseDuration time.Duration
+	Renewable     bool
+	// ReadAt is the time the secret was requested, which is the start of its lease.
+	ReadAt time.Time
+}
+
+// Read the secret of the path. KV v2 secrets are detected by the data and metadata of their response, which means
+// that their path has to contain the data segment, e.g. secret/data/app. It returns ErrNotFound if the secret does
+// not exist or the KV v2 secret has been deleted.
+func Read(ctx context.Context, client *api.Client, path string) (*Secret, error) {
+	readAt := time.Now()
+	s, err := client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, ErrNotFound
+	}
+
+	secret := &Secret{
+		Data:          s.Data,
+		LeaseID:       s.LeaseID,
+		LeaseDuration: time.Duration(s.LeaseDuration) * time.Second,
+		Renewable:     s.Renewable,
+		ReadAt:        readAt,
+	}
+	metadata, ok := s.Data["metadata"].(map[string]any)
+	if !ok {
+		return secret, nil
+	}
+	if _, ok := s.Data["data"]; !ok {
+		return secret, nil
+	}
+	data, ok := s.Data["data"].(map[string]any)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	secret.Data = data
+	secret.Version, err = parseVersion(metadata["version"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid version of secret %s: %w", path, err)
+	}
+	return secret, nil
+}
+
+// Value of a field of the secret, which is false if the field does not exist. Values which are not strings
+// are returned JSON encoded.
+func (s *Secret) Value(field string) (string, bool, error) {
+	v, ok := s.Data[field]
+	if !ok || v == nil {
+		return "", false, nil
+	}
+	switch t := v.(type) {
+	case string:
+		return t, true, nil
+	case json.Number:
+		return t.String(), true, nil
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to encode field %s: %w", field, err)
+		}
+		return string(b), true, nil
+	}
+}
+
+func parseVersion(v any) (uint64, error) {
+	switch t := v.(type) {
+	case json.Number:
+		return strconv.ParseUint(t.String(), 10, 64)
+	case float64:
+		return uint64(t), nil
+	default:
+		return 0, fmt.Errorf("unexpected type %T", v)
+	}
+}
@@ -0,0 +1,56 @@
+// Package metrics defines the metrics recorded while seeding and monitoring.
+package metrics
+
+import (
+	"time"
+
+	"github.com/beatlabs/harvester/config"
+)
+
+// Recorder records the metrics of harvester. Implementations have to be safe for concurrent use.
+type Recorder interface {
+	// SeedDuration records the time spent seeding all fields from a source.
+	SeedDuration(src config.Source, d time.Duration)
+	// SeedError records a failure of a source to get a value while seeding.
+	SeedError(src config.Source)
+	// ChangeApplied records a change of a field which was applied while monitoring.
+	ChangeApplied(field string, src config.Source)
+	// ChangeRejected records a change of a field which was rejected while monitoring, e.g. because it is invalid.
+	ChangeRejected(field string, src config.Source)
+	// ParseFailure records a value which could not be parsed into the type of a field.
+	ParseFailure(field string, src config.Source)
+	// PollLatency records the time a watcher spent polling a source.
+	PollLatency(src config.Source, d time.Duration)
+	// Backoff records the interval until the next poll of a watcher, which is larger than the poll interval
+	// while the watcher is backing off due to errors.
+	Backoff(src config.Source, interval time.Duration)
+	// PlanRestart records the restart of a watch plan after an error.
+	PlanRestart(src config.Source, plan string)
+}
+
+// Noop is a recorder which does nothing.
+type Noop struct{}
+
+// SeedDuration does nothing.
+func (Noop) SeedDuration(config.Source, time.Duration) {}
+
+// SeedError does nothing.
+func (Noop) SeedError(config.Source) {}
+
+// ChangeApplied does nothing.
+func (Noop) ChangeApplied(string, config.Source) {}
+
+// ChangeRejected does nothing.
+func (Noop) ChangeRejected(string, config.Source) {}
+
+// ParseFailure does nothing.
+func (Noop) ParseFailure(string, config.Source) {}
+
+// PollLatency does nothing.
+func (Noop) PollLatency(config.Source, time.Duration) {}
+
+// Backoff does nothing.
+func (Noop) Backoff(config.Source, time.Duration) {}
+
+// PlanRestart does nothing.
+func (Noop) PlanRestart(config.Source, string) {}
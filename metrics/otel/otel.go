@@ -0,0 +1,122 @@
+// Package otel records the metrics of harvester with the OpenTelemetry metric API.
+package otel
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/beatlabs/harvester/config"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const meterName = "github.com/beatlabs/harvester"
+
+// Recorder records the metrics of harvester with OpenTelemetry instruments.
+type Recorder struct {
+	seedDuration    metric.Float64Histogram
+	seedErrors      metric.Int64Counter
+	changesApplied  metric.Int64Counter
+	changesRejected metric.Int64Counter
+	parseFailures   metric.Int64Counter
+	pollLatency     metric.Float64Histogram
+	backoff         metric.Float64Gauge
+	planRestarts    metric.Int64Counter
+}
+
+// New creates a recorder, which creates its instruments with a meter of the provider.
+func New(mp metric.MeterProvider) (*Recorder, error) {
+	if mp == nil {
+		return nil, errors.New("meter provider is nil")
+	}
+	m := mp.Meter(meterName)
+
+	var r Recorder
+	var err error
+	r.seedDuration, err = m.Float64Histogram("harvester.seed.duration", metric.WithUnit("s"),
+		metric.WithDescription("Time spent seeding all fields from a source."))
+	if err != nil {
+		return nil, err
+	}
+	r.seedErrors, err = m.Int64Counter("harvester.seed.errors",
+		metric.WithDescription("Failures of a source to get a value while seeding."))
+	if err != nil {
+		return nil, err
+	}
+	r.changesApplied, err = m.Int64Counter("harvester.changes.applied",
+		metric.WithDescription("Changes of a field applied while monitoring."))
+	if err != nil {
+		return nil, err
+	}
+	r.changesRejected, err = m.Int64Counter("harvester.changes.rejected",
+		metric.WithDescription("Changes of a field rejected while monitoring."))
+	if err != nil {
+		return nil, err
+	}
+	r.parseFailures, err = m.Int64Counter("harvester.parse.failures",
+		metric.WithDescription("Values which could not be parsed into the type of a field."))
+	if err != nil {
+		return nil, err
+	}
+	r.pollLatency, err = m.Float64Histogram("harvester.poll.latency", metric.WithUnit("s"),
+		metric.WithDescription("Time a watcher spent polling a source."))
+	if err != nil {
+		return nil, err
+	}
+	r.backoff, err = m.Float64Gauge("harvester.poll.backoff", metric.WithUnit("s"),
+		metric.WithDescription("Interval until the next poll of a watcher."))
+	if err != nil {
+		return nil, err
+	}
+	r.planRestarts, err = m.Int64Counter("harvester.plan.restarts",
+		metric.WithDescription("Restarts of a watch plan after an error."))
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// SeedDuration records the time spent seeding all fields from a source.
+func (r *Recorder) SeedDuration(src config.Source, d time.Duration) {
+	r.seedDuration.Record(context.Background(), d.Seconds(), metric.WithAttributes(source(src)))
+}
+
+// SeedError records a failure of a source to get a value while seeding.
+func (r *Recorder) SeedError(src config.Source) {
+	r.seedErrors.Add(context.Background(), 1, metric.WithAttributes(source(src)))
+}
+
+// ChangeApplied records a change of a field which was applied while monitoring.
+func (r *Recorder) ChangeApplied(field string, src config.Source) {
+	r.changesApplied.Add(context.Background(), 1, metric.WithAttributes(attribute.String("field", field), source(src)))
+}
+
+// ChangeRejected records a change of a field which was rejected while monitoring.
+func (r *Recorder) ChangeRejected(field string, src config.Source) {
+	r.changesRejected.Add(context.Background(), 1, metric.WithAttributes(attribute.String("field", field), source(src)))
+}
+
+// ParseFailure records a value which could not be parsed into the type of a field.
+func (r *Recorder) ParseFailure(field string, src config.Source) {
+	r.parseFailures.Add(context.Background(), 1, metric.WithAttributes(attribute.String("field", field), source(src)))
+}
+
+// PollLatency records the time a watcher spent polling a source.
+func (r *Recorder) PollLatency(src config.Source, d time.Duration) {
+	r.pollLatency.Record(context.Background(), d.Seconds(), metric.WithAttributes(source(src)))
+}
+
+// Backoff records the interval until the next poll of a watcher.
+func (r *Recorder) Backoff(src config.Source, interval time.Duration) {
+	r.backoff.Record(context.Background(), interval.Seconds(), metric.WithAttributes(source(src)))
+}
+
+// PlanRestart records the restart of a watch plan after an error.
+func (r *Recorder) PlanRestart(src config.Source, plan string) {
+	r.planRestarts.Add(context.Background(), 1, metric.WithAttributes(source(src), attribute.String("plan", plan)))
+}
+
+func source(src config.Source) attribute.KeyValue {
+	return attribute.String("source", string(src))
+}
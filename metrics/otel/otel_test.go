@@ -0,0 +1,76 @@
+package otel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var _ metrics.Recorder = &Recorder{}
+
+func TestNew(t *testing.T) {
+	r, err := New(nil)
+	require.EqualError(t, err, "meter provider is nil")
+	assert.Nil(t, r)
+}
+
+func TestRecorder(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	r, err := New(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	require.NoError(t, err)
+
+	r.SeedDuration(config.SourceConsul, 2*time.Second)
+	r.SeedError(config.SourceConsul)
+	r.ChangeApplied("Age", config.SourceConsul)
+	r.ChangeApplied("Age", config.SourceConsul)
+	r.ChangeRejected("Age", config.SourceRedis)
+	r.ParseFailure("Age", config.SourceRedis)
+	r.PollLatency(config.SourceRedis, time.Millisecond)
+	r.Backoff(config.SourceRedis, 4*time.Second)
+	r.PlanRestart(config.SourceConsul, "key /config/age")
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(t.Context(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+	assert.Equal(t, meterName, rm.ScopeMetrics[0].Scope.Name)
+
+	got := make(map[string]metricdata.Aggregation)
+	for _, m := range rm.ScopeMetrics[0].Metrics {
+		got[m.Name] = m.Data
+	}
+	require.Len(t, got, 8)
+
+	sum := func(name string) int64 {
+		data, ok := got[name].(metricdata.Sum[int64])
+		require.True(t, ok, name)
+		var total int64
+		for _, dp := range data.DataPoints {
+			total += dp.Value
+		}
+		return total
+	}
+	assert.Equal(t, int64(1), sum("harvester.seed.errors"))
+	assert.Equal(t, int64(2), sum("harvester.changes.applied"))
+	assert.Equal(t, int64(1), sum("harvester.changes.rejected"))
+	assert.Equal(t, int64(1), sum("harvester.parse.failures"))
+	assert.Equal(t, int64(1), sum("harvester.plan.restarts"))
+
+	seed, ok := got["harvester.seed.duration"].(metricdata.Histogram[float64])
+	require.True(t, ok)
+	require.Len(t, seed.DataPoints, 1)
+	assert.InDelta(t, 2.0, seed.DataPoints[0].Sum, 0.001)
+	src, ok := seed.DataPoints[0].Attributes.Value("source")
+	require.True(t, ok)
+	assert.Equal(t, "consul", src.AsString())
+
+	backoff, ok := got["harvester.poll.backoff"].(metricdata.Gauge[float64])
+	require.True(t, ok)
+	require.Len(t, backoff.DataPoints, 1)
+	assert.InDelta(t, 4.0, backoff.DataPoints[0].Value, 0.001)
+}
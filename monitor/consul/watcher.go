@@ -4,16 +4,29 @@ package consul
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"math/rand/v2"
 	"path"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/beatlabs/harvester/change"
 	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/metrics"
+	"github.com/beatlabs/harvester/monitor"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/api/watch"
 )
 
+const (
+	retryInterval = time.Second
+	maxBackoff    = 30 * time.Second
+)
+
 // Item definition.
 type Item struct {
 	tp     string
@@ -36,13 +49,79 @@ func NewPrefixItem(key string) Item {
 	return Item{tp: "keyprefix", key: key}
 }
 
+// NewPrefixItemWithPrefix creates a prefix key watch item for a given key prefix and folder prefix. The keys of
+// its changes are relative to the folder prefix.
+func NewPrefixItemWithPrefix(key, prefix string) Item {
+	return Item{tp: "keyprefix", key: key, prefix: prefix}
+}
+
 // Watcher of ConsulLogger changes.
 type Watcher struct {
-	cl    *api.Client
-	dc    string
-	token string
-	pp    []*watch.Plan
-	ii    []Item
+	cl            *api.Client
+	dc            string
+	token         string
+	consistency   consistency
+	ii            []Item
+	retryInterval time.Duration
+	errFn         func(error)
+	metrics       metrics.Recorder
+	mu            sync.Mutex // protects tt and ss
+	tt            []*monitor.HealthTracker
+	ss            []*PlanState
+	wg            sync.WaitGroup
+	cancel        context.CancelFunc
+	done          chan struct{}
+	once          sync.Once
+}
+
+// PlanStatus of a watch plan.
+type PlanStatus string
+
+const (
+	// PlanRunning means that the plan runs its blocking queries.
+	PlanRunning PlanStatus = "running"
+	// PlanBackoff means that the plan failed and waits to be restarted.
+	PlanBackoff PlanStatus = "backoff"
+	// PlanStopped means that the plan was stopped, since the context passed to Watch was cancelled.
+	PlanStopped PlanStatus = "stopped"
+)
+
+// PlanState describes the state of the watch plan of an item.
+type PlanState struct {
+	Type   string     `json:"type"`
+	Key    string     `json:"key"`
+	Status PlanStatus `json:"status"`
+	// Restarts of the plan after failures.
+	Restarts  int    `json:"restarts"`
+	LastError string `json:"last_error,omitempty"`
+	// RetryAt is the time of the restart while the plan backs off.
+	RetryAt time.Time `json:"retry_at,omitzero"`
+}
+
+// consistency mode of the blocking queries.
+type consistency struct {
+	allowStale        bool
+	requireConsistent bool
+}
+
+// Option for configuring the watcher.
+type Option func(*Watcher) error
+
+// WithAllowStale allows any Consul server to serve the blocking queries, which keeps them working without a leader,
+// at the cost of possibly stale values.
+func WithAllowStale() Option {
+	return func(w *Watcher) error {
+		w.consistency.allowStale = true
+		return nil
+	}
+}
+
+// WithRequireConsistent makes the leader verify its leadership before serving the blocking queries.
+func WithRequireConsistent() Option {
+	return func(w *Watcher) error {
+		w.consistency.requireConsistent = true
+		return nil
+	}
 }
 
 // New creates a new watcher.
@@ -63,10 +142,85 @@ func New(addr, dc, token string, timeout time.Duration, ii ...Item) (*Watcher, e
 	if err != nil {
 		return nil, err
 	}
-	return &Watcher{cl: cl, dc: dc, token: token, ii: ii}, nil
+	return &Watcher{
+		cl:            cl,
+		dc:            dc,
+		token:         token,
+		ii:            ii,
+		retryInterval: retryInterval,
+		metrics:       metrics.Noop{},
+		done:          make(chan struct{}),
+	}, nil
 }
 
-// Watch key and prefixes for changes.
+// NewWithConfig creates a new watcher with a client created with the config, e.g. api.DefaultConfig, which reads the
+// standard CONSUL_* environment variables. The datacenter, token, namespace, partition and TLS settings of the config
+// are used for all plans. A timeout of the HTTP client of the config is rejected, since it would cut the blocking
+// queries short; their wait time is set with the WaitTime of the config.
+func NewWithConfig(cfg *api.Config, ii ...Item) (*Watcher, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+	err := ValidateConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(ii) == 0 {
+		return nil, errors.New("items are empty")
+	}
+	cl, err := api.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithClient(cl, ii...)
+}
+
+// NewWithClient creates a new watcher, which allows sharing a configured client, e.g. with the Consul seeder.
+// The HTTP client of the client should not have a timeout, since it would cut the blocking queries short.
+func NewWithClient(client *api.Client, ii ...Item) (*Watcher, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if len(ii) == 0 {
+		return nil, errors.New("items are empty")
+	}
+	return &Watcher{
+		cl:            client,
+		ii:            ii,
+		retryInterval: retryInterval,
+		metrics:       metrics.Noop{},
+		done:          make(chan struct{}),
+	}, nil
+}
+
+// ValidateConfig checks that the config can be used for blocking queries, i.e. that its HTTP client has no timeout.
+func ValidateConfig(cfg *api.Config) error {
+	if cfg.HttpClient != nil && cfg.HttpClient.Timeout > 0 {
+		return errors.New("http client timeout is not supported, since it cuts the blocking queries short")
+	}
+	return nil
+}
+
+// NewWithOptions creates a new watcher, which uses the client, e.g. in order to share it with the Consul seeder,
+// and the options, e.g. the consistency mode of its blocking queries.
+func NewWithOptions(client *api.Client, ii []Item, oo ...Option) (*Watcher, error) {
+	w, err := NewWithClient(client, ii...)
+	if err != nil {
+		return nil, err
+	}
+	for _, o := range oo {
+		err := o(w)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if w.consistency.allowStale && w.consistency.requireConsistent {
+		return nil, errors.New("allow stale conflicts with require consistent")
+	}
+	return w, nil
+}
+
+// Watch key and prefixes for changes. Each plan is supervised, so that a failed plan is restarted.
 func (w *Watcher) Watch(ctx context.Context, ch chan<- []*change.Change) error {
 	if ctx == nil {
 		return errors.New("context is nil")
@@ -75,64 +229,244 @@ func (w *Watcher) Watch(ctx context.Context, ch chan<- []*change.Change) error {
 		return errors.New("change channel is nil")
 	}
 	for _, i := range w.ii {
-		var pl *watch.Plan
-		var err error
-		switch i.tp {
-		case "key":
-			pl, err = w.createKeyPlanWithPrefix(i.key, i.prefix, ch)
-		case "keyprefix":
-			pl, err = w.createKeyPrefixPlan(i.key, ch)
+		if i.tp != "key" && i.tp != "keyprefix" {
+			return fmt.Errorf("item type %q is not supported", i.tp)
 		}
-		if err != nil {
-			return err
-		}
-		w.pp = append(w.pp, pl)
-		go func(tp, key string) {
-			err := pl.RunWithClientAndHclog(w.cl, logger)
-			if err != nil {
-				slog.Error("plan failed", "plan", tp, "type", key, "err", err)
-			} else {
-				slog.Debug("plan running", "plan", tp, "type", key)
-			}
-		}(i.tp, i.key)
+	}
+	ctx, w.cancel = context.WithCancel(ctx)
+	for _, i := range w.ii {
+		t := monitor.NewHealthTracker(i.tp + " " + i.key)
+		s := &PlanState{Type: i.tp, Key: i.key, Status: PlanRunning}
+		w.mu.Lock()
+		w.tt = append(w.tt, t)
+		w.ss = append(w.ss, s)
+		w.mu.Unlock()
+		w.wg.Go(func() {
+			w.supervise(ctx, i, t, s, ch)
+		})
 	}
 	go func() {
 		<-ctx.Done()
-		for _, pl := range w.pp {
-			pl.Stop()
-		}
+		w.stop()
 		slog.Debug("all watch plans have been stopped")
 	}()
 
 	return nil
 }
 
-func (w *Watcher) createKeyPlanWithPrefix(key, prefix string, ch chan<- []*change.Change) (*watch.Plan, error) {
-	pl, err := w.getPlan("key", path.Join(prefix, key))
+// OnError sets the function which is called with the errors of the watch plans.
+func (w *Watcher) OnError(fn func(error)) {
+	w.errFn = fn
+}
+
+// SetMetrics sets the recorder of the plan restart metrics.
+func (w *Watcher) SetMetrics(r metrics.Recorder) {
+	w.metrics = r
+}
+
+// Done returns a channel which is closed once all watch plans have exited, after the context passed to Watch
+// has been cancelled.
+func (w *Watcher) Done() <-chan struct{} {
+	return w.done
+}
+
+// stop the watch plans and wait for them to exit.
+func (w *Watcher) stop() {
+	w.once.Do(func() {
+		w.cancel()
+		w.wg.Wait()
+		close(w.done)
+	})
+}
+
+// Health of the watch plans, where the plan with the most consecutive errors and the oldest success prevails.
+func (w *Watcher) Health() monitor.WatcherHealth {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return monitor.MergeHealth("consul", w.tt...)
+}
+
+// Plans returns the state of each watch plan, e.g. in order to report which keys are not watched while their plans
+// back off.
+func (w *Watcher) Plans() []PlanState {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ss := make([]PlanState, 0, len(w.ss))
+	for _, s := range w.ss {
+		ss = append(ss, *s)
+	}
+	return ss
+}
+
+func (w *Watcher) setState(s *PlanState, fn func(s *PlanState)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(s)
+}
+
+// supervise runs a plan for the item until the context is cancelled. A failed plan is replaced by a new one after
+// a jittered exponential backoff. The new plan reads the item before blocking, so that the changes made while the
+// item was not watched are not missed.
+func (w *Watcher) supervise(ctx context.Context, i Item, t *monitor.HealthTracker, s *PlanState,
+	ch chan<- []*change.Change,
+) {
+	stopped := func(s *PlanState) {
+		s.Status = PlanStopped
+		s.RetryAt = time.Time{}
+	}
+	// the keys seen by the plans of the item, which outlive a restart, so that the keys deleted meanwhile are
+	// detected
+	keys := make(map[string]bool)
+	for {
+		pl, err := w.plan(ctx, i, keys, ch)
+		if err == nil {
+			err = w.run(ctx, pl, t)
+		}
+		if ctx.Err() != nil {
+			w.setState(s, stopped)
+			return
+		}
+
+		err = fmt.Errorf("consul %s plan %s failed: %w", i.tp, i.key, err)
+		slog.Error("plan failed", "plan", i.tp, "key", i.key, "err", err)
+		t.Failure(err)
+		w.report(err)
+		interval := w.backoffInterval(t.Health().ConsecutiveErrors)
+		w.setState(s, func(s *PlanState) {
+			s.Status = PlanBackoff
+			s.LastError = err.Error()
+			s.RetryAt = time.Now().Add(interval)
+		})
+		if !sleepContext(ctx, interval) {
+			w.setState(s, stopped)
+			return
+		}
+		w.metrics.PlanRestart(config.SourceConsul, i.tp+" "+i.key)
+		w.setState(s, func(s *PlanState) {
+			s.Status = PlanRunning
+			s.Restarts++
+			s.RetryAt = time.Time{}
+		})
+	}
+}
+
+// run the plan until the context is cancelled or the plan fails. The plan retries failed queries internally, with
+// a backoff which is neither jittered nor reported, so it is stopped instead, in order to be restarted by the
+// supervisor. A panic of the plan is returned as an error.
+func (w *Watcher) run(ctx context.Context, pl *watch.Plan, t *monitor.HealthTracker) error {
+	var mu sync.Mutex
+	var failure error
+	watcher := pl.Watcher
+	pl.Watcher = func(p *watch.Plan) (watch.BlockingParamVal, interface{}, error) {
+		val, result, err := watcher(p)
+		if ctx.Err() != nil {
+			return val, result, err
+		}
+		if err != nil {
+			mu.Lock()
+			failure = err
+			mu.Unlock()
+			p.Stop()
+		} else {
+			t.Success()
+		}
+		return val, result, err
+	}
+	stop := context.AfterFunc(ctx, pl.Stop)
+	defer stop()
+
+	err := recoverPanic(func() error {
+		return pl.RunWithClientAndHclog(w.cl, logger)
+	})
+	if err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if failure == nil && ctx.Err() == nil {
+		return errors.New("plan exited")
+	}
+	return failure
+}
+
+// recoverPanic calls the function and returns its panic as an error.
+func recoverPanic(fn func() error) error {
+	var err error
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("plan panicked: %v", r)
+			}
+		}()
+		err = fn()
+	}()
+	return err
+}
+
+func (w *Watcher) plan(ctx context.Context, i Item, keys map[string]bool, ch chan<- []*change.Change,
+) (*watch.Plan, error) {
+	switch i.tp {
+	case "key":
+		return w.createKeyPlanWithPrefix(ctx, i.key, i.prefix, keys, ch)
+	case "keyprefix":
+		return w.createKeyPrefixPlanWithPrefix(ctx, i.key, i.prefix, keys, ch)
+	default:
+		return nil, fmt.Errorf("item type %q is not supported", i.tp)
+	}
+}
+
+func (w *Watcher) report(err error) {
+	if w.errFn != nil {
+		w.errFn(err)
+	}
+}
+
+// createKeyPlanWithPrefix creates a plan, which sends the changes of the key and its deletion, once it was seen,
+// with the index of the query as its version.
+func (w *Watcher) createKeyPlanWithPrefix(ctx context.Context, key, prefix string, keys map[string]bool,
+	ch chan<- []*change.Change,
+) (*watch.Plan, error) {
+	pl, err := w.getPlan(ctx, "key", path.Join(prefix, key))
 	if err != nil {
 		return nil, err
 	}
-	pl.Handler = func(_ uint64, data interface{}) {
+	pl.Handler = func(idx uint64, data interface{}) {
 		if data == nil {
+			if keys[key] {
+				delete(keys, key)
+				send(ctx, ch, []*change.Change{change.NewDeleted(config.SourceConsul, key, idx)})
+			}
 			return
 		}
 		pair, ok := data.(*api.KVPair)
 		if !ok {
 			slog.Error("data is not a kv pair", "data", data)
 		} else {
-			ch <- []*change.Change{change.New(config.SourceConsul, key, string(pair.Value), pair.ModifyIndex)}
+			keys[key] = true
+			send(ctx, ch, []*change.Change{change.New(config.SourceConsul, key, string(pair.Value), pair.ModifyIndex)})
 		}
 	}
 	slog.Debug("plan created", "key", key)
 	return pl, nil
 }
 
-func (w *Watcher) createKeyPrefixPlan(keyPrefix string, ch chan<- []*change.Change) (*watch.Plan, error) {
-	pl, err := w.getPlan("keyprefix", keyPrefix)
+// createKeyPrefixPlanWithPrefix creates a plan, which sends the changes of all keys of the prefix and the deletions
+// of the keys which were listed previously, with the index of the listing as their version.
+func (w *Watcher) createKeyPrefixPlanWithPrefix(ctx context.Context, keyPrefix, prefix string, keys map[string]bool,
+	ch chan<- []*change.Change,
+) (*watch.Plan, error) {
+	full := keyPrefix
+	if prefix != "" {
+		full = path.Join(prefix, keyPrefix)
+		if strings.HasSuffix(keyPrefix, "/") {
+			full += "/"
+		}
+	}
+	pl, err := w.getPlan(ctx, "keyprefix", full)
 	if err != nil {
 		return nil, err
 	}
-	pl.Handler = func(_ uint64, data interface{}) {
+	pl.Handler = func(idx uint64, data interface{}) {
 		if data == nil {
 			return
 		}
@@ -141,17 +475,32 @@ func (w *Watcher) createKeyPrefixPlan(keyPrefix string, ch chan<- []*change.Chan
 			slog.Error("data is not a kv pairs", "data", data)
 		} else {
 			cc := make([]*change.Change, len(pp))
+			listed := make(map[string]bool, len(pp))
 			for i := 0; i < len(pp); i++ {
-				cc[i] = change.New(config.SourceConsul, pp[i].Key, string(pp[i].Value), pp[i].ModifyIndex)
+				key := keyPrefix + strings.TrimPrefix(pp[i].Key, full)
+				listed[key] = true
+				cc[i] = change.New(config.SourceConsul, key, string(pp[i].Value), pp[i].ModifyIndex)
 			}
-			ch <- cc
+			deleted := make([]string, 0)
+			for key := range keys {
+				if !listed[key] {
+					deleted = append(deleted, key)
+				}
+			}
+			slices.Sort(deleted)
+			for _, key := range deleted {
+				cc = append(cc, change.NewDeleted(config.SourceConsul, key, idx))
+			}
+			clear(keys)
+			maps.Copy(keys, listed)
+			send(ctx, ch, cc)
 		}
 	}
 	slog.Debug("plan created", "keyPrefix", keyPrefix)
 	return pl, nil
 }
 
-func (w *Watcher) getPlan(tp, key string) (*watch.Plan, error) {
+func (w *Watcher) getPlan(ctx context.Context, tp, key string) (*watch.Plan, error) {
 	params := map[string]interface{}{}
 	params["datacenter"] = w.dc
 	params["token"] = w.token
@@ -161,5 +510,82 @@ func (w *Watcher) getPlan(tp, key string) (*watch.Plan, error) {
 		params["prefix"] = key
 	}
 	params["type"] = tp
-	return watch.Parse(params)
+	pl, err := watch.Parse(params)
+	if err != nil {
+		return nil, err
+	}
+	if w.consistency != (consistency{}) {
+		pl.Watcher = w.watcherFunc(ctx, tp, key)
+	}
+	return pl, nil
+}
+
+// watcherFunc replaces the watcher function of a plan, which supports only stale reads, in order to apply the
+// consistency mode to its blocking queries. The queries are cancelled with the context.
+func (w *Watcher) watcherFunc(ctx context.Context, tp, key string) watch.WatcherFunc {
+	var index uint64
+	return func(_ *watch.Plan) (watch.BlockingParamVal, interface{}, error) {
+		q := &api.QueryOptions{
+			Datacenter:        w.dc,
+			Token:             w.token,
+			AllowStale:        w.consistency.allowStale,
+			RequireConsistent: w.consistency.requireConsistent,
+			WaitIndex:         index,
+		}
+		q = q.WithContext(ctx)
+		var result interface{}
+		var meta *api.QueryMeta
+		var err error
+		if tp == "key" {
+			var pair *api.KVPair
+			pair, meta, err = w.cl.KV().Get(key, q)
+			if pair != nil {
+				result = pair
+			}
+		} else {
+			var pairs api.KVPairs
+			pairs, meta, err = w.cl.KV().List(key, q)
+			result = pairs
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		// like the plan, reset the index if it went backwards
+		index = meta.LastIndex
+		if index < q.WaitIndex {
+			index = 0
+		}
+		return watch.WaitIndexVal(meta.LastIndex), result, nil
+	}
+}
+
+// send the changes, unless the context is cancelled, since nobody receives them afterwards.
+func send(ctx context.Context, ch chan<- []*change.Change, cc []*change.Change) {
+	select {
+	case <-ctx.Done():
+	case ch <- cc:
+	}
+}
+
+// backoffInterval doubles the retry interval with each consecutive failure, up to the max backoff, and jitters it
+// by up to a half, so that the plans of many instances do not restart in lockstep.
+func (w *Watcher) backoffInterval(failures int) time.Duration {
+	interval := w.retryInterval
+	for i := 1; i < failures && interval < maxBackoff; i++ {
+		interval = min(2*interval, maxBackoff)
+	}
+	//nolint:gosec // the jitter does not need a secure random number generator
+	return interval/2 + rand.N(interval/2+1)
+}
+
+func sleepContext(ctx context.Context, interval time.Duration) bool {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
 }
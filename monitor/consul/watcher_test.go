@@ -2,10 +2,19 @@ package consul
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/beatlabs/harvester/change"
+	"github.com/beatlabs/harvester/config"
+	"github.com/hashicorp/consul/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -66,6 +75,199 @@ func TestWatcher_Watch(t *testing.T) {
 	}
 }
 
+func TestNewWithClient(t *testing.T) {
+	client, err := api.NewClient(api.DefaultConfig())
+	require.NoError(t, err)
+	tests := map[string]struct {
+		client      *api.Client
+		ii          []Item
+		expectedErr string
+	}{
+		"success":         {client: client, ii: []Item{NewKeyItem("key1")}},
+		"client is nil":   {ii: []Item{NewKeyItem("key1")}, expectedErr: "client is nil"},
+		"items are empty": {client: client, expectedErr: "items are empty"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := NewWithClient(tt.client, tt.ii...)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestNewWithConfig(t *testing.T) {
+	tests := map[string]struct {
+		cfg         *api.Config
+		ii          []Item
+		expectedErr string
+	}{
+		"success":         {cfg: api.DefaultConfig(), ii: []Item{NewKeyItem("key1")}},
+		"config is nil":   {ii: []Item{NewKeyItem("key1")}, expectedErr: "config is nil"},
+		"items are empty": {cfg: api.DefaultConfig(), expectedErr: "items are empty"},
+		"http client timeout": {
+			cfg:         &api.Config{HttpClient: &http.Client{Timeout: time.Second}},
+			ii:          []Item{NewKeyItem("key1")},
+			expectedErr: "http client timeout is not supported, since it cuts the blocking queries short",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := NewWithConfig(tt.cfg, tt.ii...)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestNewWithOptions(t *testing.T) {
+	client, err := api.NewClient(api.DefaultConfig())
+	require.NoError(t, err)
+	tests := map[string]struct {
+		oo          []Option
+		expectedErr string
+	}{
+		"stale":                {oo: []Option{WithAllowStale()}},
+		"consistent":           {oo: []Option{WithRequireConsistent()}},
+		"stale and consistent": {oo: []Option{WithAllowStale(), WithRequireConsistent()}, expectedErr: "allow stale conflicts with require consistent"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := NewWithOptions(client, []Item{NewKeyItem("key1")}, tt.oo...)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+
+	got, err := NewWithOptions(nil, []Item{NewKeyItem("key1")})
+	require.EqualError(t, err, "client is nil")
+	assert.Nil(t, got)
+}
+
+func TestWatcher_Watch_Consistency(t *testing.T) {
+	queries := make(chan url.Values, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.Query()
+		if r.URL.Query().Get("index") != "" {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("X-Consul-Index", "10")
+		if r.URL.Query().Has("recurse") {
+			assert.NoError(t, json.NewEncoder(w).Encode(api.KVPairs{{Key: "prefix1/key2", Value: []byte("2"), ModifyIndex: 5}}))
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(api.KVPairs{{Key: "key1", Value: []byte("1"), ModifyIndex: 4}}))
+	}))
+	defer srv.Close()
+	cfg := api.DefaultConfig()
+	cfg.Address = srv.URL
+	client, err := api.NewClient(cfg)
+	require.NoError(t, err)
+
+	w, err := NewWithOptions(client, []Item{NewKeyItem("key1"), NewPrefixItem("prefix1/")}, WithRequireConsistent())
+	require.NoError(t, err)
+	ch := make(chan []*change.Change)
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, ch))
+
+	var cc []*change.Change
+	for range 2 {
+		select {
+		case c := <-ch:
+			cc = append(cc, c...)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "expected changes")
+		}
+	}
+	assert.ElementsMatch(t, []*change.Change{
+		change.New(config.SourceConsul, "key1", "1", 4),
+		change.New(config.SourceConsul, "prefix1/key2", "2", 5),
+	}, cc)
+
+	// the blocking queries continue from the index of the previous ones
+	indexes := 0
+	for range 4 {
+		q := <-queries
+		assert.True(t, q.Has("consistent"))
+		assert.False(t, q.Has("stale"))
+		if q.Get("index") == "10" {
+			indexes++
+		}
+	}
+	assert.Equal(t, 2, indexes)
+
+	// the blocking queries are cancelled with the context
+	cancel()
+	select {
+	case <-w.Done():
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "plans did not exit")
+	}
+}
+
+func TestWatcher_Watch_UnsupportedItem(t *testing.T) {
+	w, err := New("xxx", "", "", 0, Item{tp: "service"})
+	require.NoError(t, err)
+	err = w.Watch(t.Context(), make(chan []*change.Change))
+	require.EqualError(t, err, `item type "service" is not supported`)
+}
+
+func TestWatcher_Watch_Lifecycle(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	w, err := New(strings.TrimPrefix(srv.URL, "http://"), "", "", 0, NewKeyItem("key1"), NewPrefixItem("prefix1"))
+	require.NoError(t, err)
+	errs := make(chan error, 10)
+	w.OnError(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, make(chan []*change.Change)))
+
+	select {
+	case err := <-errs:
+		assert.Contains(t, err.Error(), "plan")
+		assert.Contains(t, err.Error(), "failed")
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no error reported")
+	}
+	h := w.Health()
+	assert.Equal(t, "consul", h.Name)
+	assert.GreaterOrEqual(t, h.ConsecutiveErrors, 1)
+	assert.True(t, h.LastSuccess.IsZero())
+	assert.NotEmpty(t, h.LastError)
+
+	cancel()
+	select {
+	case <-w.Done():
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "plans did not exit")
+	}
+}
+
 func TestItems(t *testing.T) {
 	t.Run("NewKeyItem", func(t *testing.T) {
 		item := NewKeyItem("key1")
@@ -79,4 +281,247 @@ func TestItems(t *testing.T) {
 		item := NewPrefixItem("prefix1")
 		assert.Equal(t, Item{tp: "keyprefix", key: "prefix1"}, item)
 	})
+	t.Run("NewPrefixItemWithPrefix", func(t *testing.T) {
+		item := NewPrefixItemWithPrefix("prefix1", "folder")
+		assert.Equal(t, Item{tp: "keyprefix", key: "prefix1", prefix: "folder"}, item)
+	})
+}
+
+func TestWatcher_Watch_PrefixWithFolder(t *testing.T) {
+	paths := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+		if r.URL.Query().Get("index") != "" {
+			// block like a blocking query without changes
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("X-Consul-Index", "10")
+		assert.NoError(t, json.NewEncoder(w).Encode(api.KVPairs{
+			{Key: "team/svc/db/Host", Value: []byte("db.local"), ModifyIndex: 3},
+			{Key: "team/svc/db/replica/Port", Value: []byte("5433"), ModifyIndex: 4},
+		}))
+	}))
+	defer srv.Close()
+
+	w, err := New(strings.TrimPrefix(srv.URL, "http://"), "", "", 0, NewPrefixItemWithPrefix("svc/db/", "team"))
+	require.NoError(t, err)
+	ch := make(chan []*change.Change)
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, ch))
+
+	select {
+	case cc := <-ch:
+		assert.Equal(t, []*change.Change{
+			change.New(config.SourceConsul, "svc/db/Host", "db.local", 3),
+			change.New(config.SourceConsul, "svc/db/replica/Port", "5433", 4),
+		}, cc)
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "expected changes")
+	}
+	assert.Equal(t, "/v1/kv/team/svc/db/", <-paths)
+
+	cancel()
+	<-w.Done()
+}
+
+func TestWatcher_Watch_Restart(t *testing.T) {
+	var reads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("index") != "" {
+			if reads.Load() == 1 {
+				http.Error(w, "no cluster leader", http.StatusInternalServerError)
+				return
+			}
+			<-r.Context().Done()
+			return
+		}
+		// the restarted plan reads the key again and gets the change made while it was not watched
+		n := reads.Add(1)
+		w.Header().Set("X-Consul-Index", "10")
+		pair := &api.KVPair{Key: "key1", Value: []byte("1"), ModifyIndex: 4}
+		if n > 1 {
+			pair = &api.KVPair{Key: "key1", Value: []byte("2"), ModifyIndex: 6}
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(api.KVPairs{pair}))
+	}))
+	defer srv.Close()
+
+	w, err := New(strings.TrimPrefix(srv.URL, "http://"), "", "", 0, NewKeyItem("key1"))
+	require.NoError(t, err)
+	w.retryInterval = time.Millisecond
+	ch := make(chan []*change.Change)
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, ch))
+
+	for _, want := range []*change.Change{
+		change.New(config.SourceConsul, "key1", "1", 4),
+		change.New(config.SourceConsul, "key1", "2", 6),
+	} {
+		select {
+		case cc := <-ch:
+			assert.Equal(t, []*change.Change{want}, cc)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "expected changes")
+		}
+	}
+	pp := w.Plans()
+	require.Len(t, pp, 1)
+	assert.Equal(t, "key", pp[0].Type)
+	assert.Equal(t, "key1", pp[0].Key)
+	assert.Equal(t, PlanRunning, pp[0].Status)
+	assert.Equal(t, 1, pp[0].Restarts)
+	assert.Contains(t, pp[0].LastError, "consul key plan key1 failed")
+	assert.True(t, pp[0].RetryAt.IsZero())
+	assert.Zero(t, w.Health().ConsecutiveErrors)
+
+	cancel()
+	select {
+	case <-w.Done():
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "plans did not exit")
+	}
+	assert.Equal(t, PlanStopped, w.Plans()[0].Status)
+}
+
+func TestWatcher_Watch_Backoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "unavailable", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	w, err := New(strings.TrimPrefix(srv.URL, "http://"), "", "", 0, NewPrefixItem("prefix1"))
+	require.NoError(t, err)
+	w.retryInterval = time.Hour
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, make(chan []*change.Change)))
+
+	require.Eventually(t, func() bool {
+		return w.Plans()[0].Status == PlanBackoff
+	}, 5*time.Second, 10*time.Millisecond)
+	pp := w.Plans()
+	assert.Zero(t, pp[0].Restarts)
+	assert.WithinRange(t, pp[0].RetryAt, time.Now().Add(29*time.Minute), time.Now().Add(time.Hour))
+
+	cancel()
+	select {
+	case <-w.Done():
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "plans did not exit")
+	}
+	assert.Equal(t, PlanStopped, w.Plans()[0].Status)
+	assert.True(t, w.Plans()[0].RetryAt.IsZero())
+}
+
+func TestRecoverPanic(t *testing.T) {
+	require.NoError(t, recoverPanic(func() error { return nil }))
+	require.EqualError(t, recoverPanic(func() error { return errors.New("failed") }), "failed")
+	require.EqualError(t, recoverPanic(func() error { panic("boom") }), "plan panicked: boom")
+}
+
+func TestWatcher_BackoffInterval(t *testing.T) {
+	w := &Watcher{retryInterval: time.Second}
+	tests := map[string]struct {
+		failures int
+		min, max time.Duration
+	}{
+		"first failure":  {failures: 1, min: 500 * time.Millisecond, max: time.Second},
+		"second failure": {failures: 2, min: time.Second, max: 2 * time.Second},
+		"third failure":  {failures: 3, min: 2 * time.Second, max: 4 * time.Second},
+		"max backoff":    {failures: 10, min: maxBackoff / 2, max: maxBackoff},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			for range 100 {
+				got := w.backoffInterval(tt.failures)
+				assert.GreaterOrEqual(t, got, tt.min)
+				assert.LessOrEqual(t, got, tt.max)
+			}
+		})
+	}
+}
+
+func TestWatcher_Watch_PrefixDeletions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pairs := api.KVPairs{
+			{Key: "flags/alpha", Value: []byte("true"), ModifyIndex: 4},
+			{Key: "flags/beta", Value: []byte("false"), ModifyIndex: 5},
+		}
+		switch r.URL.Query().Get("index") {
+		case "":
+			w.Header().Set("X-Consul-Index", "10")
+		case "10":
+			w.Header().Set("X-Consul-Index", "12")
+			pairs = pairs[:1]
+		default:
+			<-r.Context().Done()
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(pairs))
+	}))
+	defer srv.Close()
+
+	w, err := New(strings.TrimPrefix(srv.URL, "http://"), "", "", 0, NewPrefixItem("flags/"))
+	require.NoError(t, err)
+	ch := make(chan []*change.Change)
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, ch))
+
+	for _, want := range [][]*change.Change{
+		{
+			change.New(config.SourceConsul, "flags/alpha", "true", 4),
+			change.New(config.SourceConsul, "flags/beta", "false", 5),
+		},
+		{
+			change.New(config.SourceConsul, "flags/alpha", "true", 4),
+			change.NewDeleted(config.SourceConsul, "flags/beta", 12),
+		},
+	} {
+		select {
+		case cc := <-ch:
+			assert.Equal(t, want, cc)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "expected changes")
+		}
+	}
+
+	cancel()
+	<-w.Done()
+}
+
+func TestWatcher_Watch_KeyDeletion(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("index") {
+		case "":
+			w.Header().Set("X-Consul-Index", "10")
+			assert.NoError(t, json.NewEncoder(w).Encode(api.KVPairs{{Key: "key1", Value: []byte("1"), ModifyIndex: 4}}))
+		case "10":
+			w.Header().Set("X-Consul-Index", "12")
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	w, err := New(strings.TrimPrefix(srv.URL, "http://"), "", "", 0, NewKeyItem("key1"))
+	require.NoError(t, err)
+	ch := make(chan []*change.Change)
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, ch))
+
+	for _, want := range []*change.Change{
+		change.New(config.SourceConsul, "key1", "1", 4),
+		change.NewDeleted(config.SourceConsul, "key1", 12),
+	} {
+		select {
+		case cc := <-ch:
+			assert.Equal(t, []*change.Change{want}, cc)
+		case <-time.After(5 * time.Second):
+			require.FailNow(t, "expected changes")
+		}
+	}
+
+	cancel()
+	<-w.Done()
 }
@@ -0,0 +1,37 @@
+package etcd
+
+import (
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/monitor"
+)
+
+// Factory returns the factory of a watcher, which is usually given a *clientv3.Client and is passed to
+// harvester.WithWatcherFactory. It watches the keys of the fields tagged with etcd and the prefixes of the structs
+// and entry types tagged with etcd_prefix.
+func Factory(client Client) func(cfg *config.Config) (monitor.Watcher, error) {
+	return func(cfg *config.Config) (monitor.Watcher, error) {
+		return New(client, items(cfg)...)
+	}
+}
+
+// items watches each prefix once, since the fields of a bound struct share the prefix of the outermost one.
+func items(cfg *config.Config) []Item {
+	ii := make([]Item, 0)
+	prefixes := make(map[string]bool)
+	for _, field := range cfg.Fields {
+		key, ok := field.Sources()[config.SourceEtcd]
+		if !ok {
+			continue
+		}
+		prefix := field.Prefix(config.SourceEtcd)
+		if prefix == "" {
+			ii = append(ii, NewKeyItem(key))
+			continue
+		}
+		if !prefixes[prefix] {
+			prefixes[prefix] = true
+			ii = append(ii, NewPrefixItem(prefix))
+		}
+	}
+	return ii
+}
@@ -0,0 +1,82 @@
+package etcd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/monitor"
+	"github.com/beatlabs/harvester/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+type testConfig struct {
+	Age sync.Int64 `seed:"18" etcd:"/config/age"`
+}
+
+type testDB struct {
+	Host sync.String `seed:"localhost" etcd:"host"`
+	Port sync.Int64  `seed:"5432"`
+}
+
+type testPrefixConfig struct {
+	DB    testDB                   `etcd_prefix:"/config/db"`
+	Flags sync.Map[string, string] `etcd_prefix:"/config/flags"`
+}
+
+func TestFactory(t *testing.T) {
+	cfg, err := config.New(&testConfig{}, nil)
+	require.NoError(t, err)
+	w, err := Factory(nil)(cfg)
+	require.EqualError(t, err, "client is nil")
+	assert.Nil(t, w)
+
+	tests := map[string]struct {
+		cfg      any
+		expected []Item
+	}{
+		"key":    {cfg: &testConfig{}, expected: []Item{NewKeyItem("/config/age")}},
+		"prefix": {cfg: &testPrefixConfig{}, expected: []Item{NewPrefixItem("/config/db/"), NewPrefixItem("/config/flags/")}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := config.New(tt.cfg, nil)
+			require.NoError(t, err)
+			w, err := Factory(newFakeClient())(cfg)
+			require.NoError(t, err)
+			ew, ok := w.(*Watcher)
+			require.True(t, ok)
+			assert.Equal(t, tt.expected, ew.ii)
+		})
+	}
+}
+
+func TestFactory_Prefix(t *testing.T) {
+	fc := newFakeClient()
+	fc.put("/config/db/host", "db.local", 5)
+	fc.put("/config/flags/search", "on", 6)
+	c := &testPrefixConfig{}
+	cfg, err := config.New(c, nil)
+	require.NoError(t, err)
+	w, err := Factory(fc)(cfg)
+	require.NoError(t, err)
+	mon, err := monitor.New(cfg, w)
+	require.NoError(t, err)
+	require.NoError(t, mon.Monitor(t.Context()))
+
+	// the struct and the map are watched with a prefix each
+	fc.watchRevision(t)
+	fc.watchRevision(t)
+	fc.events <- clientv3.WatchResponse{Events: []*clientv3.Event{
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("/config/db/Port"), Value: []byte("6432"), ModRevision: 9}},
+	}}
+	fc.events <- clientv3.WatchResponse{Events: []*clientv3.Event{
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("/config/flags/search"), Value: []byte("off"), ModRevision: 9}},
+	}}
+	assert.Eventually(t, func() bool {
+		return c.DB.Host.Get() == "db.local" && c.DB.Port.Get() == 6432 && c.Flags.Get()["search"] == "off"
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,248 @@
+// Package etcd handles the monitor capabilities of harvester using etcd.
+package etcd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/harvester/change"
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/metrics"
+	"github.com/beatlabs/harvester/monitor"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	retryInterval = time.Second
+	maxBackoff    = 30 * time.Second
+)
+
+// Client of etcd, which is implemented by *clientv3.Client.
+type Client interface {
+	clientv3.KV
+	clientv3.Watcher
+}
+
+// Item definition.
+type Item struct {
+	tp  string
+	key string
+}
+
+// NewKeyItem creates a new key watch item for the watcher.
+func NewKeyItem(key string) Item {
+	return Item{tp: "key", key: key}
+}
+
+// NewPrefixItem creates a prefix key watch item for the watcher.
+func NewPrefixItem(prefix string) Item {
+	return Item{tp: "keyprefix", key: prefix}
+}
+
+// Watcher of etcd changes.
+type Watcher struct {
+	client        Client
+	ii            []Item
+	tt            []*monitor.HealthTracker
+	retryInterval time.Duration
+	errFn         func(error)
+	metrics       metrics.Recorder
+	wg            sync.WaitGroup
+	done          chan struct{}
+}
+
+// New creates a new watcher.
+func New(client Client, ii ...Item) (*Watcher, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if len(ii) == 0 {
+		return nil, errors.New("items are empty")
+	}
+	tt := make([]*monitor.HealthTracker, 0, len(ii))
+	for _, i := range ii {
+		if i.tp != "key" && i.tp != "keyprefix" {
+			return nil, fmt.Errorf("item type %q is not supported", i.tp)
+		}
+		tt = append(tt, monitor.NewHealthTracker(i.tp+" "+i.key))
+	}
+	return &Watcher{
+		client:        client,
+		ii:            ii,
+		tt:            tt,
+		retryInterval: retryInterval,
+		metrics:       metrics.Noop{},
+		done:          make(chan struct{}),
+	}, nil
+}
+
+// Watch key and prefixes for changes. Each item is read first, which sends its current values, and then watched
+// from the next revision with the watch stream of etcd. The mod revision of a key is used as the version of its
+// changes. When the stream fails, e.g. because the revision has been compacted, the item is read and watched again.
+func (w *Watcher) Watch(ctx context.Context, ch chan<- []*change.Change) error {
+	if ctx == nil {
+		return errors.New("context is nil")
+	}
+	if ch == nil {
+		return errors.New("change channel is nil")
+	}
+	for idx, i := range w.ii {
+		w.tt[idx].Start()
+		w.wg.Go(func() {
+			w.run(ctx, i, w.tt[idx], ch)
+		})
+	}
+	go func() {
+		<-ctx.Done()
+		w.wg.Wait()
+		close(w.done)
+		slog.Debug("all watches have been stopped")
+	}()
+	return nil
+}
+
+// OnError sets the function which is called with the errors of the watches.
+func (w *Watcher) OnError(fn func(error)) {
+	w.errFn = fn
+}
+
+// SetMetrics sets the recorder of the watch restart metrics.
+func (w *Watcher) SetMetrics(r metrics.Recorder) {
+	w.metrics = r
+}
+
+// Done returns a channel which is closed once all watches have exited, after the context passed to Watch
+// has been cancelled.
+func (w *Watcher) Done() <-chan struct{} {
+	return w.done
+}
+
+// Health of the watches, where the watch with the most consecutive errors and the oldest success prevails.
+func (w *Watcher) Health() monitor.WatcherHealth {
+	return monitor.MergeHealth("etcd", w.tt...)
+}
+
+func (w *Watcher) report(err error) {
+	if w.errFn != nil {
+		w.errFn(err)
+	}
+}
+
+// run reads and watches the item until the context is cancelled, backing off after each failure.
+func (w *Watcher) run(ctx context.Context, i Item, t *monitor.HealthTracker, ch chan<- []*change.Change) {
+	failures := 0
+	for {
+		rev, err := w.get(ctx, i, ch)
+		if err == nil {
+			t.Success()
+			failures = 0
+			err = w.watch(ctx, i, rev+1, t, ch)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		failures++
+		err = fmt.Errorf("etcd %s watch %s failed: %w", i.tp, i.key, err)
+		slog.Error("watch failed", "watch", i.tp, "key", i.key, "err", err)
+		t.Failure(err)
+		w.report(err)
+		w.metrics.PlanRestart(config.SourceEtcd, i.tp+" "+i.key)
+		if !sleepContext(ctx, w.backoffInterval(failures)) {
+			return
+		}
+	}
+}
+
+// get the current values of the item and send them as a batch. It returns the revision of the read.
+func (w *Watcher) get(ctx context.Context, i Item, ch chan<- []*change.Change) (int64, error) {
+	resp, err := w.client.Get(ctx, i.key, options(i)...)
+	if err != nil {
+		return 0, err
+	}
+	cc := make([]*change.Change, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		cc = append(cc, newChange(kv))
+	}
+	if len(cc) > 0 {
+		send(ctx, ch, cc)
+	}
+	return resp.Header.GetRevision(), nil
+}
+
+// watch the item from the revision and send the changes of each watch response as a batch.
+// It returns when the watch stream fails or is closed.
+func (w *Watcher) watch(ctx context.Context, i Item, rev int64, t *monitor.HealthTracker, ch chan<- []*change.Change,
+) error {
+	ctx, cancel := context.WithCancel(clientv3.WithRequireLeader(ctx))
+	defer cancel()
+	oo := append(options(i), clientv3.WithRev(rev), clientv3.WithProgressNotify())
+	for resp := range w.client.Watch(ctx, i.key, oo...) {
+		err := resp.Err()
+		if err != nil {
+			return err
+		}
+		t.Success()
+		cc := make([]*change.Change, 0, len(resp.Events))
+		for _, ev := range resp.Events {
+			if ev.Type == mvccpb.DELETE {
+				//nolint:gosec // revisions are positive
+				cc = append(cc, change.NewDeleted(config.SourceEtcd, string(ev.Kv.Key), uint64(ev.Kv.ModRevision)))
+				continue
+			}
+			cc = append(cc, newChange(ev.Kv))
+		}
+		if len(cc) > 0 {
+			send(ctx, ch, cc)
+		}
+	}
+	return errors.New("watch stream closed")
+}
+
+func options(i Item) []clientv3.OpOption {
+	if i.tp == "keyprefix" {
+		return []clientv3.OpOption{clientv3.WithPrefix()}
+	}
+	return nil
+}
+
+func newChange(kv *mvccpb.KeyValue) *change.Change {
+	//nolint:gosec // revisions are positive
+	return change.New(config.SourceEtcd, string(kv.Key), string(kv.Value), uint64(kv.ModRevision))
+}
+
+// send the changes, unless the context is cancelled, since nobody receives them afterwards.
+func send(ctx context.Context, ch chan<- []*change.Change, cc []*change.Change) {
+	select {
+	case <-ctx.Done():
+	case ch <- cc:
+	}
+}
+
+func (w *Watcher) backoffInterval(failures int) time.Duration {
+	interval := w.retryInterval
+	for i := 1; i < failures; i++ {
+		if interval >= maxBackoff/2 {
+			return maxBackoff
+		}
+		interval *= 2
+	}
+	return interval
+}
+
+func sleepContext(ctx context.Context, interval time.Duration) bool {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
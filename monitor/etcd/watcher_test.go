@@ -0,0 +1,290 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/harvester/change"
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/metrics"
+	"github.com/beatlabs/harvester/monitor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		client      Client
+		ii          []Item
+		expectedErr string
+	}{
+		"success":          {client: newFakeClient(), ii: []Item{NewKeyItem("/config/age"), NewPrefixItem("/config/")}},
+		"client is nil":    {client: nil, ii: []Item{NewKeyItem("/config/age")}, expectedErr: "client is nil"},
+		"items are empty":  {client: newFakeClient(), ii: nil, expectedErr: "items are empty"},
+		"item unsupported": {client: newFakeClient(), ii: []Item{{tp: "service"}}, expectedErr: `item type "service" is not supported`},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := New(tt.client, tt.ii...)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	w, err := New(newFakeClient(), NewKeyItem("/config/age"))
+	require.NoError(t, err)
+	type args struct {
+		ctx context.Context
+		ch  chan<- []*change.Change
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"missing context": {args: args{ch: make(chan []*change.Change)}, expectedErr: "context is nil"},
+		"missing chan":    {args: args{ctx: t.Context()}, expectedErr: "change channel is nil"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.EqualError(t, w.Watch(tt.args.ctx, tt.args.ch), tt.expectedErr)
+		})
+	}
+}
+
+func TestWatcher_Watch_Key(t *testing.T) {
+	fc := newFakeClient()
+	fc.put("/config/age", "18", 5)
+	fc.put("/config/name", "John", 6)
+	w, err := New(fc, NewKeyItem("/config/age"))
+	require.NoError(t, err)
+	ch := make(chan []*change.Change)
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, ch))
+
+	assertChanges(t, ch, []*change.Change{change.New(config.SourceEtcd, "/config/age", "18", 5)})
+	assert.Equal(t, int64(7), fc.watchRevision(t))
+
+	fc.events <- clientv3.WatchResponse{Events: []*clientv3.Event{
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("/config/age"), Value: []byte("19"), ModRevision: 8}},
+		{Type: mvccpb.DELETE, Kv: &mvccpb.KeyValue{Key: []byte("/config/age"), ModRevision: 9}},
+	}}
+	assertChanges(t, ch, []*change.Change{
+		change.New(config.SourceEtcd, "/config/age", "19", 8),
+		change.NewDeleted(config.SourceEtcd, "/config/age", 9),
+	})
+
+	cancel()
+	<-w.Done()
+}
+
+func TestWatcher_Watch_Prefix(t *testing.T) {
+	fc := newFakeClient()
+	fc.put("/config/age", "18", 5)
+	fc.put("/config/name", "John", 6)
+	fc.put("/other/name", "Jane", 7)
+	w, err := New(fc, NewPrefixItem("/config/"))
+	require.NoError(t, err)
+	ch := make(chan []*change.Change)
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, ch))
+
+	assertChanges(t, ch, []*change.Change{
+		change.New(config.SourceEtcd, "/config/age", "18", 5),
+		change.New(config.SourceEtcd, "/config/name", "John", 6),
+	})
+	assert.Equal(t, int64(8), fc.watchRevision(t))
+
+	fc.events <- clientv3.WatchResponse{Events: []*clientv3.Event{
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("/config/age"), Value: []byte("19"), ModRevision: 9}},
+		{Type: mvccpb.PUT, Kv: &mvccpb.KeyValue{Key: []byte("/config/name"), Value: []byte("Jane"), ModRevision: 9}},
+	}}
+	assertChanges(t, ch, []*change.Change{
+		change.New(config.SourceEtcd, "/config/age", "19", 9),
+		change.New(config.SourceEtcd, "/config/name", "Jane", 9),
+	})
+
+	cancel()
+	<-w.Done()
+}
+
+func TestWatcher_Watch_Restart(t *testing.T) {
+	fc := newFakeClient()
+	fc.put("/config/age", "18", 5)
+	w, err := New(fc, NewKeyItem("/config/age"))
+	require.NoError(t, err)
+	w.retryInterval = time.Millisecond
+	rec := &testRecorder{}
+	w.SetMetrics(rec)
+	errs := make(chan error, 10)
+	w.OnError(func(err error) { errs <- err })
+	ch := make(chan []*change.Change)
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, ch))
+
+	assertChanges(t, ch, []*change.Change{change.New(config.SourceEtcd, "/config/age", "18", 5)})
+	assert.Equal(t, int64(6), fc.watchRevision(t))
+
+	fc.put("/config/age", "20", 10)
+	fc.events <- clientv3.WatchResponse{CompactRevision: 8}
+
+	err = <-errs
+	require.ErrorContains(t, err, "etcd key watch /config/age failed")
+	require.ErrorIs(t, err, rpctypes.ErrCompacted)
+	assert.Equal(t, 1, w.Health().ConsecutiveErrors)
+	assertChanges(t, ch, []*change.Change{change.New(config.SourceEtcd, "/config/age", "20", 10)})
+	assert.Equal(t, int64(11), fc.watchRevision(t))
+	assert.Equal(t, 0, w.Health().ConsecutiveErrors)
+	assert.Equal(t, []string{"key /config/age"}, rec.restarts())
+
+	cancel()
+	<-w.Done()
+}
+
+func TestWatcher_Watch_GetError(t *testing.T) {
+	fc := newFakeClient()
+	fc.getErr = errors.New("unavailable")
+	w, err := New(fc, NewKeyItem("/config/age"))
+	require.NoError(t, err)
+	w.retryInterval = time.Hour
+	errs := make(chan error, 10)
+	w.OnError(func(err error) { errs <- err })
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, make(chan []*change.Change)))
+
+	require.EqualError(t, <-errs, "etcd key watch /config/age failed: unavailable")
+	h := w.Health()
+	assert.Equal(t, "etcd", h.Name)
+	assert.Equal(t, 1, h.ConsecutiveErrors)
+
+	cancel()
+	<-w.Done()
+}
+
+func assertChanges(t *testing.T, ch <-chan []*change.Change, expected []*change.Change) {
+	t.Helper()
+	select {
+	case cc := <-ch:
+		assert.Equal(t, expected, cc)
+	case <-time.After(time.Second):
+		require.FailNow(t, "expected changes")
+	}
+}
+
+// fakeClient serves the keys from memory. The responses sent to its events channel are forwarded to the active watch.
+type fakeClient struct {
+	clientv3.KV
+	clientv3.Watcher
+	mu      sync.Mutex
+	kvs     map[string]*mvccpb.KeyValue
+	rev     int64
+	getErr  error
+	events  chan clientv3.WatchResponse
+	watches chan int64
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		kvs:     make(map[string]*mvccpb.KeyValue),
+		events:  make(chan clientv3.WatchResponse),
+		watches: make(chan int64, 10),
+	}
+}
+
+func (fc *fakeClient) put(key, value string, rev int64) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	fc.kvs[key] = &mvccpb.KeyValue{Key: []byte(key), Value: []byte(value), ModRevision: rev}
+	fc.rev = max(fc.rev, rev)
+}
+
+func (fc *fakeClient) Get(_ context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	if fc.getErr != nil {
+		return nil, fc.getErr
+	}
+	prefix := clientv3.OpGet(key, opts...).RangeBytes() != nil
+	resp := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: fc.rev}}
+	for k, kv := range fc.kvs {
+		if k == key || (prefix && strings.HasPrefix(k, key)) {
+			resp.Kvs = append(resp.Kvs, kv)
+		}
+	}
+	sort.Slice(resp.Kvs, func(i, j int) bool { return string(resp.Kvs[i].Key) < string(resp.Kvs[j].Key) })
+	return resp, nil
+}
+
+func (fc *fakeClient) Watch(ctx context.Context, key string, opts ...clientv3.OpOption) clientv3.WatchChan {
+	fc.watches <- clientv3.OpGet(key, opts...).Rev()
+	out := make(chan clientv3.WatchResponse)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case resp := <-fc.events:
+				select {
+				case <-ctx.Done():
+					return
+				case out <- resp:
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// watchRevision returns the revision the next watch started from.
+func (fc *fakeClient) watchRevision(t *testing.T) int64 {
+	t.Helper()
+	select {
+	case rev := <-fc.watches:
+		return rev
+	case <-time.After(time.Second):
+		require.FailNow(t, "expected a watch")
+		return 0
+	}
+}
+
+type testRecorder struct {
+	metrics.Noop
+	mu   sync.Mutex
+	plan []string
+}
+
+func (r *testRecorder) PlanRestart(_ config.Source, plan string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.plan = append(r.plan, plan)
+}
+
+func (r *testRecorder) restarts() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.plan
+}
+
+var (
+	_ Client                 = &clientv3.Client{}
+	_ monitor.ErrorReporter  = &Watcher{}
+	_ monitor.Stopper        = &Watcher{}
+	_ monitor.HealthReporter = &Watcher{}
+	_ monitor.Instrumented   = &Watcher{}
+)
@@ -0,0 +1,200 @@
+// Package file handles the monitor capabilities of harvester using files.
+package file
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/beatlabs/harvester/change"
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/monitor"
+)
+
+// Watcher of file changes.
+// Files are polled and their symlinks are resolved on every poll, which allows detecting the atomic swap of
+// the `..data` symlink that Kubernetes uses when updating ConfigMap and Secret volumes. A file which was read before
+// and does not exist anymore is reported as deleted.
+type Watcher struct {
+	files        []string
+	states       []state
+	pollInterval time.Duration
+	sleep        func(context.Context, time.Duration) bool
+	errFn        func(error)
+	health       *monitor.HealthTracker
+	done         chan struct{}
+}
+
+type state struct {
+	path    string
+	modTime time.Time
+	size    int64
+	hash    string
+	version uint64
+}
+
+// New watcher.
+func New(pollInterval time.Duration, files []string) (*Watcher, error) {
+	if pollInterval <= 0 {
+		return nil, errors.New("poll interval should be a positive number")
+	}
+	if len(files) == 0 {
+		return nil, errors.New("files are empty")
+	}
+
+	return &Watcher{
+		files:        files,
+		states:       make([]state, len(files)),
+		pollInterval: pollInterval,
+		sleep:        sleepContext,
+		health:       monitor.NewHealthTracker("file"),
+		done:         make(chan struct{}),
+	}, nil
+}
+
+// Watch files for changes.
+func (w *Watcher) Watch(ctx context.Context, ch chan<- []*change.Change) error {
+	if ctx == nil {
+		return errors.New("context is nil")
+	}
+	if ch == nil {
+		return errors.New("change channel is nil")
+	}
+
+	w.health.Start()
+	go func() {
+		defer close(w.done)
+		w.monitor(ctx, ch)
+	}()
+	return nil
+}
+
+// OnError sets the function which is called when a file cannot be read.
+func (w *Watcher) OnError(fn func(error)) {
+	w.errFn = fn
+}
+
+// Health of the polls. A poll fails when a file exists, but cannot be read.
+func (w *Watcher) Health() monitor.WatcherHealth {
+	return w.health.Health()
+}
+
+// Done returns a channel which is closed once polling has stopped, after the context passed to Watch
+// has been cancelled.
+func (w *Watcher) Done() <-chan struct{} {
+	return w.done
+}
+
+func (w *Watcher) monitor(ctx context.Context, ch chan<- []*change.Change) {
+	for {
+		if !w.sleep(ctx, w.pollInterval) {
+			return
+		}
+
+		changes := w.getChanges()
+		if len(changes) == 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case ch <- changes:
+		}
+	}
+}
+
+func (w *Watcher) getChanges() []*change.Change {
+	changes := make([]*change.Change, 0)
+	var pollErr error
+
+	for i, file := range w.files {
+		// Resolving the symlinks detects the swap of the target even if the modification time of the
+		// new target is the same as the old one.
+		path, err := filepath.EvalSymlinks(file)
+		if err != nil {
+			slog.Debug("failed to resolve file", "file", file, "err", err)
+			changes = w.appendDeleted(changes, i, err)
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			slog.Debug("failed to stat file", "file", file, "err", err)
+			changes = w.appendDeleted(changes, i, err)
+			continue
+		}
+
+		st := w.states[i]
+		if st.path == path && st.modTime.Equal(info.ModTime()) && st.size == info.Size() {
+			continue
+		}
+
+		body, err := os.ReadFile(path)
+		if err != nil {
+			slog.Error("failed to read file", "file", file, "err", err)
+			pollErr = fmt.Errorf("failed to read file %s: %w", file, err)
+			w.health.Failure(pollErr)
+			if w.errFn != nil {
+				w.errFn(pollErr)
+			}
+			continue
+		}
+
+		st.path = path
+		st.modTime = info.ModTime()
+		st.size = info.Size()
+
+		hash := w.hash(body)
+		if hash == st.hash {
+			w.states[i] = st
+			continue
+		}
+
+		st.hash = hash
+		st.version++
+		w.states[i] = st
+
+		changes = append(changes, change.New(config.SourceFile, file, string(body), st.version))
+	}
+
+	if pollErr == nil {
+		w.health.Success()
+	}
+	return changes
+}
+
+// appendDeleted appends the deletion of a file which was read before and does not exist anymore. Its state is reset,
+// so that the file is read again once it is created again.
+func (w *Watcher) appendDeleted(changes []*change.Change, i int, err error) []*change.Change {
+	st := w.states[i]
+	if st.path == "" || !errors.Is(err, fs.ErrNotExist) {
+		return changes
+	}
+	w.states[i] = state{version: st.version + 1}
+	return append(changes, change.NewDeleted(config.SourceFile, w.files[i], st.version+1))
+}
+
+func (w *Watcher) hash(body []byte) string {
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+func sleepContext(ctx context.Context, interval time.Duration) bool {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
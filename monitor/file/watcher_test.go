@@ -0,0 +1,161 @@
+package file
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/harvester/change"
+	"github.com/beatlabs/harvester/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	type args struct {
+		pollInterval time.Duration
+		files        []string
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":               {args: args{pollInterval: 1 * time.Second, files: []string{"1"}}},
+		"poll interval invalid": {args: args{pollInterval: 0, files: []string{"1"}}, expectedErr: "poll interval should be a positive number"},
+		"files are missing":     {args: args{pollInterval: 1 * time.Second, files: nil}, expectedErr: "files are empty"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := New(tt.args.pollInterval, tt.args.files)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	w, err := New(time.Second, []string{"1"})
+	require.NoError(t, err)
+	type args struct {
+		ctx context.Context
+		ch  chan<- []*change.Change
+	}
+	tests := map[string]struct {
+		args    args
+		wantErr bool
+	}{
+		"missing context": {args: args{}, wantErr: true},
+		"missing chan":    {args: args{ctx: context.Background()}, wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err = w.Watch(tt.args.ctx, tt.args.ch)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWatcher_GetChanges(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "name")
+	missing := filepath.Join(dir, "missing")
+	w, err := New(time.Second, []string{file, missing})
+	require.NoError(t, err)
+
+	writeFile(t, file, "John Doe", time.Now().Add(-time.Minute))
+	assert.Equal(t, []*change.Change{change.New(config.SourceFile, file, "John Doe", 1)}, w.getChanges())
+
+	// unchanged file does not trigger a change
+	assert.Empty(t, w.getChanges())
+
+	// touched file with the same content does not trigger a change
+	writeFile(t, file, "John Doe", time.Now())
+	assert.Empty(t, w.getChanges())
+
+	writeFile(t, file, "Jane Doe", time.Now().Add(time.Minute))
+	assert.Equal(t, []*change.Change{change.New(config.SourceFile, file, "Jane Doe", 2)}, w.getChanges())
+
+	writeFile(t, missing, "Mary Doe", time.Now())
+	assert.Equal(t, []*change.Change{change.New(config.SourceFile, missing, "Mary Doe", 1)}, w.getChanges())
+
+	// a deleted file is reported once and read again when it is created again
+	require.NoError(t, os.Remove(file))
+	assert.Equal(t, []*change.Change{change.NewDeleted(config.SourceFile, file, 3)}, w.getChanges())
+	assert.Empty(t, w.getChanges())
+	writeFile(t, file, "Jane Doe", time.Now())
+	assert.Equal(t, []*change.Change{change.New(config.SourceFile, file, "Jane Doe", 4)}, w.getChanges())
+}
+
+func TestWatcher_GetChanges_SymlinkSwap(t *testing.T) {
+	// mimics the layout of a Kubernetes ConfigMap volume:
+	// name -> ..data/name, ..data -> ..2024_01_01, ..2024_01_01/name
+	dir := t.TempDir()
+	modTime := time.Now()
+	writeFile(t, filepath.Join(dir, "..2024_01_01", "name"), "John Doe", modTime)
+	require.NoError(t, os.Symlink("..2024_01_01", filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "name"), filepath.Join(dir, "name")))
+
+	file := filepath.Join(dir, "name")
+	w, err := New(time.Second, []string{file})
+	require.NoError(t, err)
+
+	assert.Equal(t, []*change.Change{change.New(config.SourceFile, file, "John Doe", 1)}, w.getChanges())
+
+	// the new target has the same modification time and size, only the symlink swap reveals the change
+	writeFile(t, filepath.Join(dir, "..2024_01_02", "name"), "Jane Doe", modTime)
+	require.NoError(t, os.Symlink("..2024_01_02", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "..2024_01_01")))
+
+	assert.Equal(t, []*change.Change{change.New(config.SourceFile, file, "Jane Doe", 2)}, w.getChanges())
+	assert.Empty(t, w.getChanges())
+}
+
+func TestWatcher_Monitor(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "name")
+	writeFile(t, file, "John Doe", time.Now())
+	w, err := New(5*time.Millisecond, []string{file})
+	require.NoError(t, err)
+
+	ch := make(chan []*change.Change, 1)
+	require.NoError(t, w.Watch(t.Context(), ch))
+
+	select {
+	case cc := <-ch:
+		assert.Equal(t, []*change.Change{change.New(config.SourceFile, file, "John Doe", 1)}, cc)
+	case <-time.After(time.Second):
+		assert.Fail(t, "change was not received")
+	}
+}
+
+func writeFile(t *testing.T, file, body string, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o700))
+	require.NoError(t, os.WriteFile(file, []byte(body), 0o600))
+	require.NoError(t, os.Chtimes(file, modTime, modTime))
+}
+
+func TestWatcher_Watch_Done(t *testing.T) {
+	w, err := New(time.Millisecond, []string{filepath.Join(t.TempDir(), "missing")})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, make(chan []*change.Change)))
+	require.Eventually(t, func() bool {
+		return !w.Health().LastSuccess.IsZero()
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, 0, w.Health().ConsecutiveErrors)
+	cancel()
+	<-w.Done()
+}
@@ -0,0 +1,202 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Status of the health of the monitor or of a watcher.
+type Status string
+
+const (
+	// StatusOK means that the configuration is live.
+	StatusOK Status = "ok"
+	// StatusStarting means that a watcher has not reported a success or a failure yet, or that monitoring has not
+	// started yet.
+	StatusStarting Status = "starting"
+	// StatusDegraded means that the configuration might be stale, e.g. because a watcher failed recently.
+	StatusDegraded Status = "degraded"
+	// StatusFailed means that the configuration is not live anymore.
+	StatusFailed Status = "failed"
+)
+
+// statusSeverity ranks starting above degraded, since the configuration is not known to be live before every watcher
+// has reported once.
+var statusSeverity = map[Status]int{StatusOK: 0, StatusDegraded: 1, StatusStarting: 2, StatusFailed: 3}
+
+// HealthThresholds define when a watcher is considered degraded or failed.
+// A zero threshold is disabled.
+type HealthThresholds struct {
+	// DegradedErrors is the number of consecutive errors after which a watcher is degraded.
+	DegradedErrors int
+	// FailedErrors is the number of consecutive errors after which a watcher is failed.
+	FailedErrors int
+	// DegradedAge is the time since the last success after which a watcher is degraded.
+	DegradedAge time.Duration
+	// FailedAge is the time since the last success after which a watcher is failed.
+	FailedAge time.Duration
+}
+
+// DefaultHealthThresholds consider a watcher degraded after an error and failed after 5 consecutive errors.
+// The age thresholds are disabled by default, since watchers which use blocking queries, like Consul, succeed
+// only when a value changes or the query times out.
+var DefaultHealthThresholds = HealthThresholds{DegradedErrors: 1, FailedErrors: 5}
+
+func (t HealthThresholds) status(h WatcherHealth) Status {
+	exceeds := func(errs int, age time.Duration) bool {
+		return (errs > 0 && h.ConsecutiveErrors >= errs) || (age > 0 && h.Staleness >= age)
+	}
+	switch {
+	case exceeds(t.FailedErrors, t.FailedAge):
+		return StatusFailed
+	case h.Starting:
+		return StatusStarting
+	case exceeds(t.DegradedErrors, t.DegradedAge):
+		return StatusDegraded
+	default:
+		return StatusOK
+	}
+}
+
+// WatcherHealth describes the health of a watcher.
+type WatcherHealth struct {
+	Name   string `json:"name"`
+	Status Status `json:"status"`
+	// LastSuccess is the time of the last successful poll or plan run, zero if none succeeded yet.
+	LastSuccess time.Time `json:"last_success"`
+	// ConsecutiveErrors since the last success.
+	ConsecutiveErrors int    `json:"consecutive_errors"`
+	LastError         string `json:"last_error,omitempty"`
+	// Staleness is the time since the last success, or since the watcher started if none succeeded yet.
+	Staleness time.Duration `json:"staleness"`
+	// Starting is true until the watcher has reported its first success or failure.
+	Starting bool `json:"starting,omitempty"`
+}
+
+// Health of the monitor, which is the worst status of its watchers.
+// The monitor is starting until it has started all watchers and failed once it has stopped.
+type Health struct {
+	Status   Status          `json:"status"`
+	Watchers []WatcherHealth `json:"watchers,omitempty"`
+}
+
+// HealthReporter is implemented by watchers which track their health.
+type HealthReporter interface {
+	Health() WatcherHealth
+}
+
+// HealthTracker tracks the health of a watcher. Watcher implementations can use it to implement HealthReporter.
+type HealthTracker struct {
+	mu                sync.Mutex
+	name              string
+	started           time.Time
+	lastSuccess       time.Time
+	consecutiveErrors int
+	lastErr           error
+	reported          bool
+}
+
+// NewHealthTracker creates a health tracker for the watcher with the given name.
+func NewHealthTracker(name string) *HealthTracker {
+	return &HealthTracker{name: name, started: time.Now()}
+}
+
+// Start resets the time from which the staleness is measured until the first success.
+func (t *HealthTracker) Start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.started = time.Now()
+}
+
+// Success records a successful poll or plan run.
+func (t *HealthTracker) Success() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastSuccess = time.Now()
+	t.consecutiveErrors = 0
+	t.lastErr = nil
+	t.reported = true
+}
+
+// Failure records a failed poll or plan run.
+func (t *HealthTracker) Failure(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.consecutiveErrors++
+	t.lastErr = err
+	t.reported = true
+}
+
+// Health returns the health of the watcher. The status is set by the monitor, based on its thresholds.
+func (t *HealthTracker) Health() WatcherHealth {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	since := t.started
+	if !t.lastSuccess.IsZero() {
+		since = t.lastSuccess
+	}
+	h := WatcherHealth{
+		Name:              t.name,
+		LastSuccess:       t.lastSuccess,
+		ConsecutiveErrors: t.consecutiveErrors,
+		Staleness:         time.Since(since),
+		Starting:          !t.reported,
+	}
+	if t.lastErr != nil {
+		h.LastError = t.lastErr.Error()
+	}
+	return h
+}
+
+// MergeHealth merges the health of the trackers of a watcher, e.g. one per watched key, where the tracker with
+// the most consecutive errors and the oldest success prevails. The watcher is starting while any tracker is.
+func MergeHealth(name string, tt ...*HealthTracker) WatcherHealth {
+	h := WatcherHealth{Name: name}
+	for i, t := range tt {
+		th := t.Health()
+		if i == 0 || th.LastSuccess.Before(h.LastSuccess) {
+			h.LastSuccess = th.LastSuccess
+		}
+		if th.Staleness > h.Staleness {
+			h.Staleness = th.Staleness
+		}
+		h.Starting = h.Starting || th.Starting
+		if th.ConsecutiveErrors > h.ConsecutiveErrors {
+			h.ConsecutiveErrors = th.ConsecutiveErrors
+			h.LastError = th.LastError
+		}
+	}
+	return h
+}
+
+// HealthHandler reports the health as JSON. It responds with 503 Service Unavailable when the health is failed
+// or starting and 200 OK otherwise, which makes it suitable as a Kubernetes readiness probe.
+type HealthHandler struct {
+	health func() Health
+}
+
+// NewHealthHandler creates a handler, which takes the health from the provided function, e.g. the Health method
+// of the harvester or the monitor.
+func NewHealthHandler(health func() Health) (*HealthHandler, error) {
+	if health == nil {
+		return nil, errors.New("health function is nil")
+	}
+	return &HealthHandler{health: health}, nil
+}
+
+// ServeHTTP reports the health.
+func (h *HealthHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	health := h.health()
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if health.Status == StatusFailed || health.Status == StatusStarting {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	err := json.NewEncoder(w).Encode(health)
+	if err != nil {
+		slog.Error("failed to write health response", "err", err)
+	}
+}
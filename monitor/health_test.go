@@ -0,0 +1,169 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/harvester/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthThresholds_Status(t *testing.T) {
+	thresholds := HealthThresholds{DegradedErrors: 1, FailedErrors: 3, DegradedAge: time.Minute, FailedAge: time.Hour}
+	tests := map[string]struct {
+		health   WatcherHealth
+		expected Status
+	}{
+		"ok":                 {health: WatcherHealth{}, expected: StatusOK},
+		"degraded by errors": {health: WatcherHealth{ConsecutiveErrors: 2}, expected: StatusDegraded},
+		"failed by errors":   {health: WatcherHealth{ConsecutiveErrors: 3}, expected: StatusFailed},
+		"degraded by age":    {health: WatcherHealth{Staleness: time.Minute}, expected: StatusDegraded},
+		"failed by age":      {health: WatcherHealth{Staleness: 2 * time.Hour}, expected: StatusFailed},
+		"starting":           {health: WatcherHealth{Starting: true, Staleness: time.Minute}, expected: StatusStarting},
+		"failed starting":    {health: WatcherHealth{Starting: true, Staleness: 2 * time.Hour}, expected: StatusFailed},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, thresholds.status(tt.health))
+		})
+	}
+
+	assert.Equal(t, StatusOK, HealthThresholds{}.status(WatcherHealth{ConsecutiveErrors: 100, Staleness: time.Hour}))
+}
+
+func TestHealthTracker(t *testing.T) {
+	tr := NewHealthTracker("test")
+	h := tr.Health()
+	assert.Equal(t, "test", h.Name)
+	assert.True(t, h.LastSuccess.IsZero())
+	assert.Positive(t, h.Staleness)
+	assert.True(t, h.Starting)
+
+	tr.Failure(errors.New("boom-1"))
+	tr.Failure(errors.New("boom-2"))
+	h = tr.Health()
+	assert.False(t, h.Starting)
+	assert.Equal(t, 2, h.ConsecutiveErrors)
+	assert.Equal(t, "boom-2", h.LastError)
+
+	tr.Success()
+	h = tr.Health()
+	assert.Equal(t, 0, h.ConsecutiveErrors)
+	assert.Empty(t, h.LastError)
+	assert.False(t, h.LastSuccess.IsZero())
+}
+
+func TestMergeHealth(t *testing.T) {
+	healthy := NewHealthTracker("key a")
+	healthy.Success()
+	failing := NewHealthTracker("key b")
+	failing.Success()
+	failing.Failure(errors.New("boom-1"))
+	failing.Failure(errors.New("boom-2"))
+
+	h := MergeHealth("test", healthy, failing)
+	assert.Equal(t, "test", h.Name)
+	assert.Equal(t, 2, h.ConsecutiveErrors)
+	assert.Equal(t, "boom-2", h.LastError)
+	assert.Equal(t, healthy.Health().LastSuccess, h.LastSuccess)
+	assert.False(t, h.Starting)
+	assert.True(t, MergeHealth("test", healthy, NewHealthTracker("key c")).Starting)
+
+	assert.Equal(t, WatcherHealth{Name: "empty"}, MergeHealth("empty"))
+}
+
+func TestMonitor_Health(t *testing.T) {
+	cfg, err := config.New(&testConfig{}, nil)
+	require.NoError(t, err)
+	healthy := &testHealthWatcher{tracker: NewHealthTracker("healthy")}
+	failing := &testHealthWatcher{tracker: NewHealthTracker("failing")}
+	mon, err := NewWithOptions(cfg, []Watcher{healthy, failing, &testBatchWatcher{}},
+		WithHealthThresholds(HealthThresholds{DegradedErrors: 1, FailedErrors: 2}))
+	require.NoError(t, err)
+	assert.Equal(t, StatusStarting, mon.Health().Status)
+	require.NoError(t, mon.Monitor(t.Context()))
+
+	healthy.tracker.Success()
+	h := mon.Health()
+	assert.Equal(t, StatusStarting, h.Status)
+	require.Len(t, h.Watchers, 2)
+	assert.Equal(t, StatusOK, h.Watchers[0].Status)
+	assert.Equal(t, StatusStarting, h.Watchers[1].Status)
+
+	failing.tracker.Failure(errors.New("boom"))
+	h = mon.Health()
+	assert.Equal(t, StatusDegraded, h.Status)
+	require.Len(t, h.Watchers, 2)
+	assert.Equal(t, StatusOK, h.Watchers[0].Status)
+	assert.Equal(t, StatusDegraded, h.Watchers[1].Status)
+
+	failing.tracker.Failure(errors.New("boom"))
+	assert.Equal(t, StatusFailed, mon.Health().Status)
+
+	failing.tracker.Success()
+	assert.Equal(t, StatusOK, mon.Health().Status)
+
+	assert.Equal(t, StatusOK, NewNoop().Health().Status)
+}
+
+func TestMonitor_Health_Stopped(t *testing.T) {
+	cfg, err := config.New(&testConfig{}, nil)
+	require.NoError(t, err)
+	mon, err := New(cfg, &testBatchWatcher{})
+	require.NoError(t, err)
+	assert.Equal(t, StatusStarting, mon.Health().Status)
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, mon.Monitor(ctx))
+	assert.Equal(t, StatusOK, mon.Health().Status)
+
+	cancel()
+	<-mon.Done()
+	assert.Equal(t, StatusFailed, mon.Health().Status)
+}
+
+func TestHealthHandler(t *testing.T) {
+	h, err := NewHealthHandler(nil)
+	require.EqualError(t, err, "health function is nil")
+	assert.Nil(t, h)
+
+	tests := map[string]struct {
+		status Status
+		code   int
+	}{
+		"ok":       {status: StatusOK, code: http.StatusOK},
+		"degraded": {status: StatusDegraded, code: http.StatusOK},
+		"starting": {status: StatusStarting, code: http.StatusServiceUnavailable},
+		"failed":   {status: StatusFailed, code: http.StatusServiceUnavailable},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			health := Health{Status: tt.status, Watchers: []WatcherHealth{{Name: "test", Status: tt.status}}}
+			h, err := NewHealthHandler(func() Health { return health })
+			require.NoError(t, err)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health/harvester", nil))
+
+			assert.Equal(t, tt.code, rec.Code)
+			assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+			var got Health
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, health, got)
+		})
+	}
+}
+
+type testHealthWatcher struct {
+	testBatchWatcher
+	tracker *HealthTracker
+}
+
+func (tw *testHealthWatcher) Health() WatcherHealth {
+	return tw.tracker.Health()
+}
@@ -6,9 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/beatlabs/harvester/change"
 	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/metrics"
 )
 
 // Watcher interface definition.
@@ -16,12 +20,41 @@ type Watcher interface {
 	Watch(ctx context.Context, ch chan<- []*change.Change) error
 }
 
+// ErrorReporter is implemented by watchers which report the errors that occur after Watch returns,
+// e.g. failed polls or watch plans.
+type ErrorReporter interface {
+	// OnError sets the function which is called with the errors. It is called before Watch.
+	OnError(fn func(error))
+}
+
+// Stopper is implemented by watchers which run goroutines after Watch returns.
+type Stopper interface {
+	// Done returns a channel which is closed once all goroutines started by Watch have exited,
+	// after the context passed to Watch has been cancelled.
+	Done() <-chan struct{}
+}
+
+// Instrumented is implemented by watchers which record metrics.
+type Instrumented interface {
+	// SetMetrics sets the recorder of the metrics. It is called before Watch.
+	SetMetrics(r metrics.Recorder)
+}
+
+// ErrorQueueSize is the number of errors which can be pending in the errors channel of the monitor.
+// Further errors are logged and dropped until the channel is drained.
+const ErrorQueueSize = 100
+
 // NoopMonitor is a no-op monitor that does nothing.
-type NoopMonitor struct{}
+type NoopMonitor struct {
+	done chan struct{}
+	errs chan error
+}
 
 // NewNoop creates a no-op monitor.
 func NewNoop() *NoopMonitor {
-	return &NoopMonitor{}
+	done := make(chan struct{})
+	close(done)
+	return &NoopMonitor{done: done, errs: make(chan error)}
 }
 
 // Monitor does nothing and returns nil.
@@ -29,64 +62,222 @@ func (m *NoopMonitor) Monitor(context.Context) error {
 	return nil
 }
 
+// Done returns a closed channel, since there is nothing to wait for.
+func (m *NoopMonitor) Done() <-chan struct{} {
+	return m.done
+}
+
+// Errors returns a channel which never receives an error.
+func (m *NoopMonitor) Errors() <-chan error {
+	return m.errs
+}
+
+// Health is always ok, since there is nothing to monitor.
+func (m *NoopMonitor) Health() Health {
+	return Health{Status: StatusOK}
+}
+
 type sourceMap map[config.Source]map[string]*config.Field
 
 // Monitor for configuration changes.
 type Monitor struct {
-	cfg *config.Config
-	mp  sourceMap
-	ww  []Watcher
+	cfg           *config.Config
+	mp            sourceMap
+	entries       sourceMap // fields with entries by source and prefix
+	ww            []Watcher
+	transactional bool
+	thresholds    HealthThresholds
+	metrics       metrics.Recorder
+	errs          chan error
+	started       atomic.Bool
+	running       atomic.Bool
+	done          chan struct{}
+	wg            sync.WaitGroup
+}
+
+// Option for configuring the monitor.
+type Option func(*Monitor)
+
+// Transactional makes the monitor apply each batch of changes reported by a watcher atomically.
+// The whole batch is validated first and if any of the values is invalid, the whole batch is rejected.
+func Transactional() Option {
+	return func(m *Monitor) {
+		m.transactional = true
+	}
+}
+
+// WithHealthThresholds sets the thresholds which define when a watcher is degraded or failed.
+func WithHealthThresholds(t HealthThresholds) Option {
+	return func(m *Monitor) {
+		m.thresholds = t
+	}
+}
+
+// WithMetrics sets the recorder of the monitoring metrics, which is also passed to the watchers
+// which implement Instrumented.
+func WithMetrics(r metrics.Recorder) Option {
+	return func(m *Monitor) {
+		m.metrics = r
+	}
 }
 
 // New constructor.
 func New(cfg *config.Config, ww ...Watcher) (*Monitor, error) {
+	return NewWithOptions(cfg, ww)
+}
+
+// NewWithOptions constructor.
+func NewWithOptions(cfg *config.Config, ww []Watcher, oo ...Option) (*Monitor, error) {
 	if cfg == nil {
 		return nil, errors.New("config is nil")
 	}
 	if len(ww) == 0 {
 		return nil, errors.New("watchers are empty")
 	}
-	mp, err := generateMap(cfg.Fields)
+	mp, entries, err := generateMap(cfg.Fields)
 	if err != nil {
 		return nil, err
 	}
-	return &Monitor{cfg: cfg, mp: mp, ww: ww}, nil
+	m := &Monitor{
+		cfg:        cfg,
+		mp:         mp,
+		entries:    entries,
+		ww:         ww,
+		thresholds: DefaultHealthThresholds,
+		metrics:    metrics.Noop{},
+		errs:       make(chan error, ErrorQueueSize),
+		done:       make(chan struct{}),
+	}
+	for _, o := range oo {
+		o(m)
+	}
+	return m, nil
 }
 
-func generateMap(ff []*config.Field) (sourceMap, error) {
+// generateMap maps the keys of the sources to the fields, and the prefixes of the sources to the fields which hold
+// their keys as entries.
+func generateMap(ff []*config.Field) (sourceMap, sourceMap, error) {
 	mp := make(sourceMap)
+	entries := make(sourceMap)
 	for _, f := range ff {
 		for source, val := range f.Sources() {
 			if source == config.SourceSeed {
 				continue
 			}
+			if f.HasEntries(source) {
+				if entries[source] == nil {
+					entries[source] = make(map[string]*config.Field)
+				}
+				entries[source][val] = f
+				continue
+			}
 			_, ok := mp[source]
 			if !ok {
 				mp[source] = map[string]*config.Field{val: f}
 			} else {
 				_, ok := mp[source][val]
 				if ok {
-					return nil, fmt.Errorf("%s key %s already exists in monitor map", source, val)
+					return nil, nil, fmt.Errorf("%s key %s already exists in monitor map", source, val)
 				}
 				mp[source][val] = f
 			}
 		}
 	}
-	return mp, nil
+	return mp, entries, nil
 }
 
 // Monitor configuration changes by starting watchers per source.
+// Monitoring stops when the context is cancelled, after which Done is closed once all watchers have stopped.
+// If a watcher fails to start, the watchers which have already been started are stopped before returning.
+// A monitor can be started only once.
 func (m *Monitor) Monitor(ctx context.Context) error {
+	if !m.started.CompareAndSwap(false, true) {
+		return errors.New("monitor already started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
 	ch := make(chan []*change.Change)
-	go m.monitor(ctx, ch)
+	m.wg.Go(func() {
+		m.monitor(ctx, ch)
+	})
 
+	var err error
 	for _, w := range m.ww {
-		err := w.Watch(ctx, ch)
+		if r, ok := w.(ErrorReporter); ok {
+			r.OnError(m.reportError)
+		}
+		if i, ok := w.(Instrumented); ok {
+			i.SetMetrics(m.metrics)
+		}
+		err = w.Watch(ctx, ch)
 		if err != nil {
-			return err
+			cancel()
+			break
+		}
+		if s, ok := w.(Stopper); ok {
+			m.wg.Go(func() {
+				<-s.Done()
+			})
 		}
 	}
-	return nil
+
+	go func() {
+		<-ctx.Done()
+		m.wg.Wait()
+		cancel()
+		close(m.done)
+	}()
+	if err == nil {
+		m.running.Store(true)
+	}
+	return err
+}
+
+// Done returns a channel which is closed once monitoring has stopped and all watchers have exited.
+func (m *Monitor) Done() <-chan struct{} {
+	return m.done
+}
+
+// Errors returns a channel which receives the errors reported by the watchers while monitoring and the rejections of
+// the changes, e.g. values which fail to parse or to validate.
+// The channel is buffered and errors are dropped when it is full, so a consumer is not required.
+func (m *Monitor) Errors() <-chan error {
+	return m.errs
+}
+
+// Health returns the health of the watchers which implement HealthReporter. The overall status is the worst status
+// of the watchers, where a watcher is starting until it has reported once. The monitor is starting until all watchers
+// have been started and failed once monitoring has stopped.
+func (m *Monitor) Health() Health {
+	h := Health{Status: StatusOK}
+	if !m.running.Load() {
+		h.Status = StatusStarting
+	}
+	for _, w := range m.ww {
+		r, ok := w.(HealthReporter)
+		if !ok {
+			continue
+		}
+		wh := r.Health()
+		wh.Status = m.thresholds.status(wh)
+		if statusSeverity[wh.Status] > statusSeverity[h.Status] {
+			h.Status = wh.Status
+		}
+		h.Watchers = append(h.Watchers, wh)
+	}
+	select {
+	case <-m.done:
+		h.Status = StatusFailed
+	default:
+	}
+	return h
+}
+
+func (m *Monitor) reportError(err error) {
+	select {
+	case m.errs <- err:
+	default:
+		slog.Warn("errors channel is full, error dropped", "err", err)
+	}
 }
 
 func (m *Monitor) monitor(ctx context.Context, ch <-chan []*change.Change) {
@@ -101,6 +292,7 @@ func (m *Monitor) monitor(ctx context.Context, ch <-chan []*change.Change) {
 }
 
 func (m *Monitor) applyChange(cc []*change.Change) {
+	uu := make([]config.Update, 0, len(cc))
 	for _, c := range cc {
 		mp, ok := m.mp[c.Source()]
 		if !ok {
@@ -109,15 +301,108 @@ func (m *Monitor) applyChange(cc []*change.Change) {
 		}
 		fld, ok := mp[c.Key()]
 		if !ok {
-			slog.Debug("key not found", "key", c.Key())
+			u, ok := m.entryUpdate(c)
+			if !ok {
+				slog.Debug("key not found", "key", c.Key())
+				continue
+			}
+			uu = append(uu, u)
 			continue
 		}
+		uu = append(uu, config.Update{
+			Field:   fld,
+			Value:   c.Value(),
+			Version: c.Version(),
+			Source:  c.Source(),
+			Key:     c.Key(),
+			Phase:   config.PhaseMonitor,
+			Deleted: c.Deleted(),
+		})
+	}
 
-		err := fld.Set(c.Value(), c.Version())
+	if m.transactional {
+		nn, err := m.cfg.ApplyChanges(uu...)
 		if err != nil {
-			slog.Error("failed to set value", "value", c.Value(), "type", fld.Type(), "name", fld.Name(),
-				"source", c.Source(), "err", err)
+			slog.Error("failed to apply changes, batch rejected", "changes", len(uu), "err", err)
+			m.recordRejected(uu, err)
+			m.reportError(fmt.Errorf("batch of %d changes rejected: %w", len(uu), err))
+			return
+		}
+		m.recordApplied(nn)
+		return
+	}
+
+	for _, u := range uu {
+		nn, err := m.cfg.ApplyChanges(u)
+		if err == nil {
+			m.recordApplied(nn)
+			continue
+		}
+		m.recordRejected([]config.Update{u}, err)
+		m.reportError(fmt.Errorf("change of key %s of source %s rejected: %w", u.Key, u.Source, err))
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			slog.Warn("change rejected, previous value kept", "source", u.Source, "key", u.Key, "name", u.Field.Name(), "rule", verr.Rule, "err", verr.Err)
+			continue
+		}
+		slog.Error("failed to set value", "source", u.Source, "key", u.Key, "type", u.Field.Type(), "name", u.Field.Name(),
+			"err", err)
+	}
+}
+
+// entryUpdate returns the update of the entry of the change, which belongs to the field with the longest prefix of
+// the key. It returns false if no field holds the key as an entry.
+func (m *Monitor) entryUpdate(c *change.Change) (config.Update, bool) {
+	var fld *config.Field
+	var entry string
+	for prefix, f := range m.entries[c.Source()] {
+		e, ok := strings.CutPrefix(c.Key(), prefix)
+		if !ok || e == "" || (fld != nil && len(e) >= len(entry)) {
 			continue
 		}
+		fld, entry = f, e
+	}
+	if fld == nil {
+		return config.Update{}, false
+	}
+	return config.Update{
+		Field:   fld,
+		Value:   c.Value(),
+		Version: c.Version(),
+		Source:  c.Source(),
+		Key:     c.Key(),
+		Phase:   config.PhaseMonitor,
+		Entry:   entry,
+		Deleted: c.Deleted(),
+	}, true
+}
+
+// recordApplied records the notifications of the applied updates, which leave out the skipped ones, e.g. the outdated
+// ones.
+func (m *Monitor) recordApplied(nn []config.ChangeNotification) {
+	for _, n := range nn {
+		m.metrics.ChangeApplied(n.Name, n.Source)
+	}
+}
+
+// recordRejected records the updates as rejected and the parse failures of the error, which can be joined.
+func (m *Monitor) recordRejected(uu []config.Update, err error) {
+	errs := []error{err}
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		errs = joined.Unwrap()
+	}
+	failed := make(map[string]bool, len(errs))
+	for _, err := range errs {
+		var perr *config.ParseError
+		if errors.As(err, &perr) {
+			failed[perr.Field] = true
+		}
+	}
+	for _, u := range uu {
+		if failed[u.Field.Name()] {
+			m.metrics.ParseFailure(u.Field.Name(), u.Source)
+		}
+		m.metrics.ChangeRejected(u.Field.Name(), u.Source)
 	}
 }
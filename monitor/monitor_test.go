@@ -3,11 +3,14 @@ package monitor
 import (
 	"context"
 	"errors"
+	stdsync "sync"
 	"testing"
 	"time"
 
 	"github.com/beatlabs/harvester/change"
 	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/metrics"
+	"github.com/beatlabs/harvester/seed"
 	"github.com/beatlabs/harvester/sync"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -77,8 +80,351 @@ func TestMonitor_Monitor(t *testing.T) {
 	assert.Equal(t, 7*time.Hour, c.NonWorkHours.Get())
 }
 
+func TestMonitor_Monitor_Transactional(t *testing.T) {
+	c := &testConfig{}
+	cfg, err := config.New(c, nil)
+	require.NoError(t, err)
+	w := &testBatchWatcher{}
+	mon, err := NewWithOptions(cfg, []Watcher{w}, Transactional())
+	require.NoError(t, err)
+	err = mon.Monitor(t.Context())
+	require.NoError(t, err)
+
+	// the change channel is unbuffered, so every send waits for the previous batch to be applied
+	ch := w.ch
+	ch <- []*change.Change{
+		change.New(config.SourceConsul, "/config/age", "25", 1),
+		change.New(config.SourceConsul, "/config/balance", "XXX", 1),
+	}
+	ch <- []*change.Change{
+		change.New(config.SourceConsul, "/config/balance", "111.11", 1),
+		change.New(config.SourceConsul, "/config/unknown", "25", 1),
+	}
+	ch <- []*change.Change{}
+
+	assert.Equal(t, int64(0), c.Age.Get())
+	assert.InDelta(t, 111.11, c.Balance.Get(), 0.01)
+	assert.Equal(t, uint64(1), cfg.Generation())
+}
+
+func TestMonitor_Monitor_ValidationRejected(t *testing.T) {
+	c := &testValidationConfig{}
+	cfg, err := config.New(c, nil)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Fields[0].Set("5", 0))
+	w := &testBatchWatcher{}
+	mon, err := New(cfg, w)
+	require.NoError(t, err)
+	err = mon.Monitor(t.Context())
+	require.NoError(t, err)
+
+	w.ch <- []*change.Change{change.New(config.SourceConsul, "/config/worker-count", "-5", 1)}
+	w.ch <- []*change.Change{}
+	assert.Equal(t, int64(5), c.WorkerCount.Get())
+
+	w.ch <- []*change.Change{change.New(config.SourceConsul, "/config/worker-count", "7", 2)}
+	w.ch <- []*change.Change{}
+	assert.Equal(t, int64(7), c.WorkerCount.Get())
+}
+
+func TestMonitor_Monitor_Notification(t *testing.T) {
+	c := &testConfig{}
+	chNotify := make(chan config.ChangeNotification, 1)
+	cfg, err := config.New(c, chNotify)
+	require.NoError(t, err)
+	w := &testBatchWatcher{}
+	mon, err := New(cfg, w)
+	require.NoError(t, err)
+	err = mon.Monitor(t.Context())
+	require.NoError(t, err)
+
+	w.ch <- []*change.Change{change.New(config.SourceConsul, "/config/age", "25", 7)}
+	n := <-chNotify
+	assert.Equal(t, "Age", n.Name)
+	assert.Equal(t, "25", n.Current)
+	assert.Equal(t, config.SourceConsul, n.Source)
+	assert.Equal(t, "/config/age", n.Key)
+	assert.Equal(t, uint64(7), n.Version)
+	assert.Equal(t, config.PhaseMonitor, n.Phase)
+}
+
+func TestMonitor_Monitor_Entries(t *testing.T) {
+	c := &testEntriesConfig{}
+	chNotify := make(chan config.ChangeNotification, 10)
+	cfg, err := config.New(c, chNotify)
+	require.NoError(t, err)
+	w := &testBatchWatcher{}
+	mon, err := New(cfg, w)
+	require.NoError(t, err)
+	err = mon.Monitor(t.Context())
+	require.NoError(t, err)
+
+	w.ch <- []*change.Change{
+		change.New(config.SourceConsul, "flags/", "", 1),
+		change.New(config.SourceConsul, "flags/alpha", "true", 2),
+		change.New(config.SourceConsul, "flags/limits/beta", "5", 3),
+		change.New(config.SourceConsul, "flags/limit", "true", 3),
+		change.New(config.SourceConsul, "flags/name", "Jane", 4),
+		change.New(config.SourceConsul, "other/alpha", "true", 5),
+	}
+	w.ch <- []*change.Change{}
+	assert.Equal(t, map[string]bool{"alpha": true, "limit": true}, c.Flags.Get())
+	assert.Equal(t, map[string]int64{"beta": 5}, c.Limits.Get())
+	assert.Equal(t, "Jane", c.Name.Get())
+
+	w.ch <- []*change.Change{
+		change.New(config.SourceConsul, "flags/alpha", "false", 5),
+		change.NewDeleted(config.SourceConsul, "flags/limit", 5),
+	}
+	w.ch <- []*change.Change{}
+	assert.Equal(t, map[string]bool{"alpha": false}, c.Flags.Get())
+	assert.Equal(t, "Jane", c.Name.Get())
+
+	for range 4 {
+		<-chNotify
+	}
+	n := <-chNotify
+	assert.Equal(t, "Flags", n.Name)
+	assert.Equal(t, "alpha", n.Entry)
+	assert.Equal(t, "alpha=false,limit=true", n.Current)
+	n = <-chNotify
+	assert.Equal(t, "limit", n.Entry)
+	assert.True(t, n.Deleted)
+	assert.Equal(t, "alpha=false", n.Current)
+}
+
+func TestMonitor_Monitor_Deleted(t *testing.T) {
+	c := &testDeleteConfig{}
+	chNotify := make(chan config.ChangeNotification, 10)
+	cfg, err := config.New(c, chNotify)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Fields[0].Set("5", 0, config.WithOrigin(config.SourceSeed, ""),
+		config.WithPhase(config.PhaseSeed)))
+	<-chNotify
+	w := &testBatchWatcher{}
+	mon, err := New(cfg, w)
+	require.NoError(t, err)
+	err = mon.Monitor(t.Context())
+	require.NoError(t, err)
+
+	w.ch <- []*change.Change{
+		change.New(config.SourceConsul, "/config/workers", "10", 2),
+		change.New(config.SourceRedis, "name", "Jane", 2),
+	}
+	w.ch <- []*change.Change{
+		change.NewDeleted(config.SourceConsul, "/config/workers", 3),
+		change.NewDeleted(config.SourceRedis, "name", 3),
+	}
+	w.ch <- []*change.Change{}
+	assert.Equal(t, int64(5), c.Workers.Get())
+	assert.Equal(t, "Jane", c.Name.Get())
+
+	for range 2 {
+		<-chNotify
+	}
+	n := <-chNotify
+	assert.Equal(t, "Workers", n.Name)
+	assert.True(t, n.Deleted)
+	assert.Equal(t, "10", n.Previous)
+	assert.Equal(t, "5", n.Current)
+	n = <-chNotify
+	assert.Equal(t, "Name", n.Name)
+	assert.True(t, n.Deleted)
+	assert.Equal(t, "Jane", n.Current)
+}
+
+func TestMonitor_Monitor_Precedence(t *testing.T) {
+	t.Setenv("ENV_WORKERS", "8")
+	tests := map[string]struct {
+		oo              []seed.Option
+		expectedWorkers int64
+		expectedNames   []string
+	}{
+		// every change of a monitored source is applied, whichever source seeded the field
+		"default": {expectedWorkers: 16, expectedNames: []string{"Workers", "Name"}},
+		// env beats consul, so the env value survives the change from consul
+		"while monitoring": {oo: []seed.Option{seed.WithPrecedenceWhileMonitoring()}, expectedWorkers: 8, expectedNames: []string{"Name"}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := &testPrecedenceConfig{}
+			chNotify := make(chan config.ChangeNotification, 10)
+			cfg, err := config.New(c, chNotify)
+			require.NoError(t, err)
+			p, err := seed.NewParam(config.SourceConsul, testGetter{"/config/workers": "4", "/config/name": "John"})
+			require.NoError(t, err)
+			sd, err := seed.NewWithOptions([]seed.Param{*p}, tt.oo...)
+			require.NoError(t, err)
+			require.NoError(t, sd.Seed(cfg))
+			assert.Equal(t, int64(8), c.Workers.Get())
+			for len(chNotify) > 0 {
+				<-chNotify
+			}
+
+			w := &testBatchWatcher{}
+			mon, err := New(cfg, w)
+			require.NoError(t, err)
+			err = mon.Monitor(t.Context())
+			require.NoError(t, err)
+
+			w.ch <- []*change.Change{
+				change.New(config.SourceConsul, "/config/workers", "16", 10),
+				change.New(config.SourceConsul, "/config/name", "Jane", 10),
+			}
+			names := make([]string, 0, len(tt.expectedNames))
+			for range tt.expectedNames {
+				names = append(names, (<-chNotify).Name)
+			}
+			assert.ElementsMatch(t, tt.expectedNames, names)
+			assert.Equal(t, "Jane", c.Name.Get())
+			assert.Equal(t, tt.expectedWorkers, c.Workers.Get())
+			assert.Empty(t, chNotify)
+		})
+	}
+}
+
+func TestMonitor_Lifecycle(t *testing.T) {
+	cfg, err := config.New(&testConfig{}, nil)
+	require.NoError(t, err)
+	w := &testLifecycleWatcher{done: make(chan struct{})}
+	mon, err := New(cfg, w)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, mon.Monitor(ctx))
+	require.EqualError(t, <-mon.Errors(), "TEST")
+
+	select {
+	case <-mon.Done():
+		require.Fail(t, "monitor stopped before the context was cancelled")
+	default:
+	}
+
+	cancel()
+	<-mon.Done()
+	select {
+	case <-w.done:
+	default:
+		require.Fail(t, "monitor stopped before the watcher exited")
+	}
+}
+
+func TestMonitor_Monitor_AlreadyStarted(t *testing.T) {
+	cfg, err := config.New(&testConfig{}, nil)
+	require.NoError(t, err)
+	mon, err := New(cfg, &testWatcher{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, mon.Monitor(ctx))
+	require.EqualError(t, mon.Monitor(ctx), "monitor already started")
+
+	cancel()
+	<-mon.Done()
+	require.EqualError(t, mon.Monitor(t.Context()), "monitor already started")
+}
+
+func TestMonitor_Monitor_ErrorStopsWatchers(t *testing.T) {
+	cfg, err := config.New(&testConfig{}, nil)
+	require.NoError(t, err)
+	w := &testLifecycleWatcher{done: make(chan struct{})}
+	mon, err := New(cfg, w, &testWatcher{err: true})
+	require.NoError(t, err)
+
+	require.EqualError(t, mon.Monitor(t.Context()), "TEST")
+	<-mon.Done()
+	<-w.done
+}
+
+func TestMonitor_Monitor_Metrics(t *testing.T) {
+	batch := []*change.Change{
+		change.New(config.SourceConsul, "/config/age", "25", 1),
+		change.New(config.SourceConsul, "/config/balance", "XXX", 1),
+	}
+	// the second batch is outdated, so its changes are skipped
+	outdated := [][]*change.Change{
+		{
+			change.New(config.SourceConsul, "/config/age", "25", 2),
+			change.New(config.SourceConsul, "/config/balance", "1.5", 2),
+		},
+		{
+			change.New(config.SourceConsul, "/config/age", "26", 1),
+			change.New(config.SourceConsul, "/config/balance", "2.5", 1),
+		},
+	}
+	tests := map[string]struct {
+		oo       []Option
+		batches  [][]*change.Change
+		applied  []string
+		rejected []string
+		parse    []string
+		err      string
+	}{
+		"single changes": {
+			batches:  [][]*change.Change{batch},
+			applied:  []string{"Age"},
+			rejected: []string{"Balance"},
+			parse:    []string{"Balance"},
+			err:      `change of key /config/balance of source consul rejected: strconv.ParseFloat: parsing "XXX": invalid syntax`,
+		},
+		"transactional": {
+			oo:       []Option{Transactional()},
+			batches:  [][]*change.Change{batch},
+			rejected: []string{"Age", "Balance"},
+			parse:    []string{"Balance"},
+			err:      `batch of 2 changes rejected: field Balance: strconv.ParseFloat: parsing "XXX": invalid syntax`,
+		},
+		"skipped changes": {
+			batches: outdated,
+			applied: []string{"Age", "Balance"},
+		},
+		"skipped changes transactional": {
+			oo:      []Option{Transactional()},
+			batches: outdated,
+			applied: []string{"Age", "Balance"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			cfg, err := config.New(&testConfig{}, nil)
+			require.NoError(t, err)
+			rec := &testRecorder{}
+			w := &testBatchWatcher{}
+			mon, err := NewWithOptions(cfg, []Watcher{w}, append(tt.oo, WithMetrics(rec))...)
+			require.NoError(t, err)
+			require.NoError(t, mon.Monitor(t.Context()))
+
+			for _, cc := range tt.batches {
+				w.ch <- cc
+			}
+			w.ch <- []*change.Change{}
+
+			rec.mu.Lock()
+			defer rec.mu.Unlock()
+			assert.Equal(t, tt.applied, rec.applied)
+			assert.Equal(t, tt.rejected, rec.rejected)
+			assert.Equal(t, tt.parse, rec.parse)
+
+			// the rejections are reported on the error channel
+			if tt.err == "" {
+				assert.Empty(t, mon.Errors())
+				return
+			}
+			require.Len(t, mon.Errors(), 1)
+			err = <-mon.Errors()
+			require.EqualError(t, err, tt.err)
+			var perr *config.ParseError
+			require.ErrorAs(t, err, &perr)
+			assert.Equal(t, "Balance", perr.Field)
+		})
+	}
+}
+
 func TestNoopMonitor_Monitor(t *testing.T) {
-	require.NoError(t, NewNoop().Monitor(context.Background()))
+	mon := NewNoop()
+	require.NoError(t, mon.Monitor(context.Background()))
+	<-mon.Done()
+	assert.NotNil(t, mon.Errors())
 }
 
 type testConfig struct {
@@ -90,6 +436,21 @@ type testConfig struct {
 	NonWorkHours sync.TimeDuration `seed:"5h" env:"ENV_NON_WORK_HOURS" redis:"/config/non_work_hours"`
 }
 
+type testDeleteConfig struct {
+	Workers sync.Int64  `seed:"5" consul:"/config/workers" on_delete:"revert"`
+	Name    sync.String `redis:"name"`
+}
+
+type testEntriesConfig struct {
+	Flags  sync.Map[string, bool]  `consul_prefix:"flags/"`
+	Limits sync.Map[string, int64] `consul_prefix:"flags/limits/"`
+	Name   sync.String             `consul:"flags/name"`
+}
+
+type testValidationConfig struct {
+	WorkerCount sync.Int64 `consul:"/config/worker-count" min:"1"`
+}
+
 type testWatcher struct {
 	err bool
 }
@@ -111,3 +472,75 @@ func (tw *testWatcher) Watch(_ context.Context, ch chan<- []*change.Change) erro
 	}
 	return nil
 }
+
+type testLifecycleWatcher struct {
+	errFn func(error)
+	done  chan struct{}
+}
+
+func (tw *testLifecycleWatcher) OnError(fn func(error)) {
+	tw.errFn = fn
+}
+
+func (tw *testLifecycleWatcher) Done() <-chan struct{} {
+	return tw.done
+}
+
+func (tw *testLifecycleWatcher) Watch(ctx context.Context, _ chan<- []*change.Change) error {
+	go func() {
+		defer close(tw.done)
+		tw.errFn(errors.New("TEST"))
+		<-ctx.Done()
+	}()
+	return nil
+}
+
+type testRecorder struct {
+	metrics.Noop
+	mu       stdsync.Mutex
+	applied  []string
+	rejected []string
+	parse    []string
+}
+
+func (r *testRecorder) ChangeApplied(field string, _ config.Source) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.applied = append(r.applied, field)
+}
+
+func (r *testRecorder) ChangeRejected(field string, _ config.Source) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rejected = append(r.rejected, field)
+}
+
+func (r *testRecorder) ParseFailure(field string, _ config.Source) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parse = append(r.parse, field)
+}
+
+type testPrecedenceConfig struct {
+	Workers sync.Int64  `seed:"1" env:"ENV_WORKERS" consul:"/config/workers" precedence:"consul,env"`
+	Name    sync.String `seed:"John" consul:"/config/name"`
+}
+
+type testGetter map[string]string
+
+func (g testGetter) Get(key string) (*string, uint64, error) {
+	v, ok := g[key]
+	if !ok {
+		return nil, 0, nil
+	}
+	return &v, 1, nil
+}
+
+type testBatchWatcher struct {
+	ch chan<- []*change.Change
+}
+
+func (tw *testBatchWatcher) Watch(_ context.Context, ch chan<- []*change.Change) error {
+	tw.ch = ch
+	return nil
+}
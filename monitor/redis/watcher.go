@@ -6,11 +6,18 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/beatlabs/harvester/change"
 	"github.com/beatlabs/harvester/config"
+	internalredis "github.com/beatlabs/harvester/internal/redis"
+	"github.com/beatlabs/harvester/metrics"
+	"github.com/beatlabs/harvester/monitor"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -18,16 +25,109 @@ import (
 type Watcher struct {
 	client       redis.UniversalClient
 	keys         []string
+	kk           []internalredis.Key
+	types        internalredis.Types
+	index        map[string][]int // indices of the watched keys of each Redis key
+	mu           sync.Mutex       // protects seq, fetched, versions and hashes
+	seq          uint64           // sequence of the last fetch
+	fetched      []uint64         // sequence of the fetch each watched key was last versioned from
 	versions     []uint64
 	hashes       []string
 	pollInterval time.Duration
+	resync       time.Duration
 	sleep        func(context.Context, time.Duration) bool
+	sub          *subscription
+	subscribe    func(ctx context.Context, channels ...string) (<-chan any, func() error)
+	errFn        func(error)
+	health       *monitor.HealthTracker
+	metrics      metrics.Recorder
+	wg           sync.WaitGroup
+	done         chan struct{}
 }
 
-const maxBackoff = 30 * time.Second
+// subscription of the event-driven mode, which maps the messages of its channels to the changed keys.
+type subscription struct {
+	channels []string
+	// keyspace is the channel prefix of the keyspace notifications, whose channels are derived from the keys.
+	keyspace string
+	key      func(msg *redis.Message) string
+}
+
+const (
+	maxBackoff = 30 * time.Second
+	// defaultResyncInterval of the event-driven mode, unless the poll interval is longer.
+	defaultResyncInterval = 5 * time.Minute
+)
+
+// Option for configuring the watcher.
+type Option func(*Watcher) error
+
+// WithKeyspaceNotifications makes the watcher event-driven, by subscribing to the keyspace notifications of the keys
+// in the database and fetching a key when it is notified. Redis has to be configured to publish the notifications,
+// e.g. with notify-keyspace-events set to "K$g" for string keys, "Khg" for hashes and "Kdg" for RedisJSON documents,
+// where g publishes the deletions of the keys, or "K$hdgx" for all of them, including expired keys.
+func WithKeyspaceNotifications(db int) Option {
+	return func(w *Watcher) error {
+		if db < 0 {
+			return errors.New("database should not be negative")
+		}
+		prefix := fmt.Sprintf("__keyspace@%d__:", db)
+		w.sub = &subscription{keyspace: prefix, key: func(msg *redis.Message) string {
+			return strings.TrimPrefix(msg.Channel, prefix)
+		}}
+		return nil
+	}
+}
 
-// New watcher.
-func New(client redis.UniversalClient, pollInterval time.Duration, keys []string) (*Watcher, error) {
+// WithChannel makes the watcher event-driven, by subscribing to a pub/sub channel on which the application publishes
+// the names of the changed Redis keys, e.g. the name of a hash, and fetching the key when it is published.
+func WithChannel(channel string) Option {
+	return func(w *Watcher) error {
+		if channel == "" {
+			return errors.New("channel is empty")
+		}
+		w.sub = &subscription{channels: []string{channel}, key: func(msg *redis.Message) string {
+			return msg.Payload
+		}}
+		return nil
+	}
+}
+
+// WithHashKeys sets the keys which are fields of hashes, of the form key#field, e.g. the keys of the redis_hash tag.
+func WithHashKeys(keys ...string) Option {
+	return func(w *Watcher) error {
+		w.types.Add(internalredis.TypeHash, keys...)
+		return nil
+	}
+}
+
+// WithJSONKeys sets the keys which are paths of RedisJSON documents, of the form key#path, e.g. the keys of the
+// redis_json tag.
+func WithJSONKeys(keys ...string) Option {
+	return func(w *Watcher) error {
+		w.types.Add(internalredis.TypeJSON, keys...)
+		return nil
+	}
+}
+
+// WithResyncInterval sets the interval of the polls of the event-driven mode, which resync the keys whose
+// notifications were missed. It defaults to 5 minutes, or the poll interval if it is longer.
+func WithResyncInterval(interval time.Duration) Option {
+	return func(w *Watcher) error {
+		if interval <= 0 {
+			return errors.New("resync interval should be a positive number")
+		}
+		w.resync = interval
+		return nil
+	}
+}
+
+// New watcher, which polls the keys every poll interval. A key is a string key, unless it is a hash field or a
+// RedisJSON path, see WithHashKeys and WithJSONKeys. In the event-driven mode, see
+// WithKeyspaceNotifications and WithChannel, only the notified keys are fetched and all keys are polled only as a slow
+// resync, see WithResyncInterval, and after every reconnect of the subscription, in order to resync the keys whose
+// notifications were missed.
+func New(client redis.UniversalClient, pollInterval time.Duration, keys []string, oo ...Option) (*Watcher, error) {
 	if client == nil {
 		return nil, errors.New("client is nil")
 	}
@@ -38,14 +138,54 @@ func New(client redis.UniversalClient, pollInterval time.Duration, keys []string
 		return nil, errors.New("keys are empty")
 	}
 
-	return &Watcher{
+	w := &Watcher{
 		client:       client,
 		keys:         keys,
+		types:        make(internalredis.Types),
+		fetched:      make([]uint64, len(keys)),
 		versions:     make([]uint64, len(keys)),
 		hashes:       make([]string, len(keys)),
 		pollInterval: pollInterval,
 		sleep:        sleepContext,
-	}, nil
+		health:       monitor.NewHealthTracker("redis"),
+		metrics:      metrics.Noop{},
+		done:         make(chan struct{}),
+	}
+	w.subscribe = w.redisSubscribe
+	for _, o := range oo {
+		err := o(w)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	w.kk = make([]internalredis.Key, 0, len(keys))
+	w.index = make(map[string][]int, len(keys))
+	for i, key := range keys {
+		k, err := w.types.Parse(key)
+		if err != nil {
+			return nil, err
+		}
+		w.kk = append(w.kk, k)
+		w.index[k.Name] = append(w.index[k.Name], i)
+	}
+	if w.sub != nil && w.sub.keyspace != "" {
+		for _, k := range w.kk {
+			if !slices.Contains(w.sub.channels, w.sub.keyspace+k.Name) {
+				w.sub.channels = append(w.sub.channels, w.sub.keyspace+k.Name)
+			}
+		}
+	}
+	if w.resync > 0 && w.sub == nil {
+		return nil, errors.New("resync interval requires keyspace notifications or a channel")
+	}
+	if w.sub != nil {
+		if w.resync == 0 {
+			w.resync = max(pollInterval, defaultResyncInterval)
+		}
+		w.pollInterval = w.resync
+	}
+	return w, nil
 }
 
 // Watch keys and changes.
@@ -57,20 +197,62 @@ func (w *Watcher) Watch(ctx context.Context, ch chan<- []*change.Change) error {
 		return errors.New("change channel is nil")
 	}
 
-	go w.monitor(ctx, ch)
+	w.health.Start()
+	w.wg.Go(func() {
+		w.monitor(ctx, ch)
+	})
+	if w.sub != nil {
+		w.wg.Go(func() {
+			w.listen(ctx, ch)
+		})
+	}
+	go func() {
+		w.wg.Wait()
+		close(w.done)
+	}()
 	return nil
 }
 
+// OnError sets the function which is called with the errors of the polls.
+func (w *Watcher) OnError(fn func(error)) {
+	w.errFn = fn
+}
+
+// Done returns a channel which is closed once polling and the subscription have stopped, after the context
+// passed to Watch has been cancelled.
+func (w *Watcher) Done() <-chan struct{} {
+	return w.done
+}
+
+// SetMetrics sets the recorder of the poll latency and backoff metrics.
+func (w *Watcher) SetMetrics(r metrics.Recorder) {
+	w.metrics = r
+}
+
+// Health of the polls.
+func (w *Watcher) Health() monitor.WatcherHealth {
+	return w.health.Health()
+}
+
+func (w *Watcher) report(err error) {
+	w.health.Failure(err)
+	if w.errFn != nil {
+		w.errFn(err)
+	}
+}
+
 func (w *Watcher) monitor(ctx context.Context, ch chan<- []*change.Change) {
 	interval := w.pollInterval
 	consecutiveErrors := 0
 
 	for {
+		w.metrics.Backoff(config.SourceRedis, interval)
 		if !w.sleep(ctx, interval) {
 			return
 		}
 
 		if w.getValues(ctx, ch) {
+			w.health.Success()
 			consecutiveErrors = 0
 			interval = w.pollInterval
 			continue
@@ -81,35 +263,217 @@ func (w *Watcher) monitor(ctx context.Context, ch chan<- []*change.Change) {
 	}
 }
 
-func (w *Watcher) getValues(ctx context.Context, ch chan<- []*change.Change) bool {
-	// Use MGET to fetch all values in a single round-trip
-	sliceCmd := w.client.MGet(ctx, w.keys...)
-	if sliceCmd == nil {
-		slog.Error("failed to get values", "err", "nil sliceCmd")
+// listen to the messages of the subscription and fetch the notified keys. All keys are fetched on the first
+// confirmation of a subscription, which is received again after a reconnect.
+func (w *Watcher) listen(ctx context.Context, ch chan<- []*change.Change) {
+	msgs, closeFn := w.subscribe(ctx, w.sub.channels...)
+	defer func() {
+		err := closeFn()
+		if err != nil {
+			slog.Debug("failed to close subscription", "err", err)
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			var names []string
+			switch m := msg.(type) {
+			case *redis.Subscription:
+				if m.Kind != "subscribe" || m.Count != 1 {
+					continue
+				}
+				slog.Debug("subscribed, resyncing all keys", "channel", m.Channel)
+			case *redis.Message:
+				name := w.sub.key(m)
+				if _, ok := w.index[name]; !ok {
+					slog.Debug("key is not watched", "key", name, "channel", m.Channel)
+					continue
+				}
+				names = []string{name}
+			default:
+				continue
+			}
+			if w.getValues(ctx, ch, names...) {
+				w.health.Success()
+			}
+		}
+	}
+}
+
+func (w *Watcher) redisSubscribe(ctx context.Context, channels ...string) (<-chan any, func() error) {
+	ps := w.client.Subscribe(ctx, channels...)
+	return ps.ChannelWithSubscriptions(), ps.Close
+}
+
+// getValues fetches the watched keys of the Redis keys, or all watched keys if none is given, and sends the changed
+// ones. The polls and the notifications fetch concurrently, so each fetch is numbered when it starts and the values of
+// a key are only versioned in the order they were fetched.
+func (w *Watcher) getValues(ctx context.Context, ch chan<- []*change.Change, names ...string) bool {
+	idx := w.indices(names)
+	w.mu.Lock()
+	w.seq++
+	seq := w.seq
+	w.mu.Unlock()
+	start := time.Now()
+	results, err := w.fetch(ctx, idx)
+	w.metrics.PollLatency(config.SourceRedis, time.Since(start))
+	if err != nil {
+		slog.Error("failed to get values", "err", err)
+		w.report(err)
 		return false
 	}
+
+	changes := w.changes(seq, idx, results)
+	if len(changes) == 0 {
+		return true
+	}
+
+	select {
+	case <-ctx.Done():
+	case ch <- changes:
+	}
+	return true
+}
+
+// indices of the watched keys of the Redis keys, or of all watched keys if none is given.
+func (w *Watcher) indices(names []string) []int {
+	if len(names) == 0 {
+		idx := make([]int, len(w.keys))
+		for i := range idx {
+			idx[i] = i
+		}
+		return idx
+	}
+	var idx []int
+	for _, name := range names {
+		idx = append(idx, w.index[name]...)
+	}
+	return idx
+}
+
+// fetch the values of the watched keys, where a missing value is nil. String keys are fetched with a single MGET,
+// each hash with a single HGETALL and each RedisJSON path with a JSON.GET.
+func (w *Watcher) fetch(ctx context.Context, idx []int) ([]any, error) {
+	results := make([]any, len(idx))
+	var strs []int
+	var hashes []string
+	fields := make(map[string][]int)
+	for j, i := range idx {
+		k := w.kk[i]
+		switch k.Type {
+		case internalredis.TypeString:
+			strs = append(strs, j)
+		case internalredis.TypeHash:
+			if _, ok := fields[k.Name]; !ok {
+				hashes = append(hashes, k.Name)
+			}
+			fields[k.Name] = append(fields[k.Name], j)
+		case internalredis.TypeJSON:
+			v, ok, err := w.getJSON(ctx, k)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				results[j] = v
+			}
+		}
+	}
+
+	if len(strs) > 0 {
+		err := w.mget(ctx, idx, strs, results)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range hashes {
+		m, err := w.client.HGetAll(ctx, name).Result()
+		if err != nil {
+			return nil, fmt.Errorf("redis failed to get hash %s: %w", name, err)
+		}
+		for _, j := range fields[name] {
+			if v, ok := m[w.kk[idx[j]].Field]; ok {
+				results[j] = v
+			}
+		}
+	}
+	return results, nil
+}
+
+// mget fetches the string keys, which are the positions of idx, in a single round-trip.
+func (w *Watcher) mget(ctx context.Context, idx, strs []int, results []any) error {
+	names := make([]string, 0, len(strs))
+	for _, j := range strs {
+		names = append(names, w.kk[idx[j]].Name)
+	}
+	sliceCmd := w.client.MGet(ctx, names...)
+	if sliceCmd == nil {
+		return errors.New("redis failed to get values: nil command")
+	}
 	if sliceCmd.Err() != nil {
-		slog.Error("failed to get values", "err", sliceCmd.Err())
-		return false
+		return fmt.Errorf("redis failed to get values: %w", sliceCmd.Err())
 	}
 
-	results := sliceCmd.Val()
-	if len(results) != len(w.keys) {
-		slog.Error("mget returned unexpected number of results", "expected", len(w.keys), "got", len(results))
-		return false
+	values := sliceCmd.Val()
+	if len(values) != len(names) {
+		return fmt.Errorf("redis mget returned %d results instead of %d", len(values), len(names))
+	}
+	for n, j := range strs {
+		results[j] = values[n]
+	}
+	return nil
+}
+
+func (w *Watcher) getJSON(ctx context.Context, k internalredis.Key) (string, bool, error) {
+	reply, err := w.client.JSONGet(ctx, k.Name, k.Field).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("redis failed to get json %s: %w", k.Name, err)
 	}
+	v, ok, err := internalredis.JSONValue(reply, k.Field)
+	if err != nil {
+		return "", false, fmt.Errorf("redis failed to get json %s: %w", k.Name, err)
+	}
+	return v, ok, nil
+}
 
-	changes := make([]*change.Change, 0, len(w.keys))
+// changes of the keys of a fetch, which bump the versions of the keys whose hash changed. The keys which have been
+// versioned from a later fetch are skipped, since their values are outdated.
+func (w *Watcher) changes(seq uint64, idx []int, results []any) []*change.Change {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-	for i, key := range w.keys {
-		// MGet returns nil for keys that don't exist
-		if results[i] == nil {
+	changes := make([]*change.Change, 0, len(idx))
+
+	for j, i := range idx {
+		key := w.keys[i]
+		if w.fetched[i] > seq {
+			continue
+		}
+		w.fetched[i] = seq
+		// missing keys, fields and paths are nil, which is a deletion if the value was seen before. The hash is
+		// reset, so that re-creating the same value is detected.
+		if results[j] == nil {
+			if w.hashes[i] != "" {
+				w.versions[i]++
+				w.hashes[i] = ""
+				changes = append(changes, change.NewDeleted(config.SourceRedis, key, w.versions[i]))
+			}
 			continue
 		}
 
-		value, ok := results[i].(string)
+		value, ok := results[j].(string)
 		if !ok {
-			slog.Error("failed to convert value to string", "key", key, "value", results[i])
+			slog.Error("failed to convert value to string", "key", key, "value", results[j])
+			w.report(fmt.Errorf("redis value of key %s is not a string", key))
 			continue
 		}
 
@@ -123,13 +487,7 @@ func (w *Watcher) getValues(ctx context.Context, ch chan<- []*change.Change) boo
 
 		changes = append(changes, change.New(config.SourceRedis, key, value, w.versions[i]))
 	}
-
-	if len(changes) == 0 {
-		return true
-	}
-
-	ch <- changes
-	return true
+	return changes
 }
 
 func (w *Watcher) hash(value string) string {
@@ -2,13 +2,17 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"maps"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/beatlabs/harvester/change"
 	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/metrics"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -19,19 +23,28 @@ func TestNew(t *testing.T) {
 		client       redis.UniversalClient
 		pollInterval time.Duration
 		keys         []string
+		oo           []Option
 	}
 	tests := map[string]struct {
 		args        args
 		expectedErr string
 	}{
-		"success":               {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: []string{"1"}}},
-		"client nil":            {args: args{client: nil, pollInterval: 1 * time.Second, keys: []string{"1"}}, expectedErr: "client is nil"},
-		"poll interval invalid": {args: args{client: &redis.Client{}, pollInterval: 0 * time.Second, keys: []string{"1"}}, expectedErr: "poll interval should be a positive number"},
-		"keys are missing":      {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: nil}, expectedErr: "keys are empty"},
+		"success":                 {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: []string{"1"}}},
+		"client nil":              {args: args{client: nil, pollInterval: 1 * time.Second, keys: []string{"1"}}, expectedErr: "client is nil"},
+		"poll interval invalid":   {args: args{client: &redis.Client{}, pollInterval: 0 * time.Second, keys: []string{"1"}}, expectedErr: "poll interval should be a positive number"},
+		"keys are missing":        {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: nil}, expectedErr: "keys are empty"},
+		"keyspace notifications":  {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: []string{"1"}, oo: []Option{WithKeyspaceNotifications(0)}}},
+		"channel":                 {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: []string{"1"}, oo: []Option{WithChannel("config")}}},
+		"database negative":       {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: []string{"1"}, oo: []Option{WithKeyspaceNotifications(-1)}}, expectedErr: "database should not be negative"},
+		"key invalid":             {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: []string{"config"}, oo: []Option{WithHashKeys("config")}}, expectedErr: `redis hash key "config" should have the form key#field`},
+		"channel empty":           {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: []string{"1"}, oo: []Option{WithChannel("")}}, expectedErr: "channel is empty"},
+		"resync interval":         {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: []string{"1"}, oo: []Option{WithResyncInterval(time.Hour), WithChannel("config")}}},
+		"resync interval invalid": {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: []string{"1"}, oo: []Option{WithResyncInterval(0)}}, expectedErr: "resync interval should be a positive number"},
+		"resync without events":   {args: args{client: &redis.Client{}, pollInterval: 1 * time.Second, keys: []string{"1"}, oo: []Option{WithResyncInterval(time.Hour)}}, expectedErr: "resync interval requires keyspace notifications or a channel"},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
-			got, err := New(tt.args.client, tt.args.pollInterval, tt.args.keys)
+			got, err := New(tt.args.client, tt.args.pollInterval, tt.args.keys, tt.args.oo...)
 			if tt.expectedErr != "" {
 				require.EqualError(t, err, tt.expectedErr)
 				assert.Nil(t, got)
@@ -43,6 +56,26 @@ func TestNew(t *testing.T) {
 	}
 }
 
+func TestNew_ResyncInterval(t *testing.T) {
+	tests := map[string]struct {
+		pollInterval time.Duration
+		oo           []Option
+		expected     time.Duration
+	}{
+		"polling":            {pollInterval: time.Second, expected: time.Second},
+		"events":             {pollInterval: time.Second, oo: []Option{WithChannel("config")}, expected: 5 * time.Minute},
+		"events long poll":   {pollInterval: time.Hour, oo: []Option{WithChannel("config")}, expected: time.Hour},
+		"events with resync": {pollInterval: time.Second, oo: []Option{WithChannel("config"), WithResyncInterval(time.Minute)}, expected: time.Minute},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			w, err := New(&redis.Client{}, tt.pollInterval, []string{"1"}, tt.oo...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, w.pollInterval)
+		})
+	}
+}
+
 func TestWatcher_Watch(t *testing.T) {
 	w, err := New(&redis.Client{}, time.Second, []string{"1"})
 	require.NoError(t, err)
@@ -86,22 +119,22 @@ func TestWatcher_Versioning(t *testing.T) {
 			"key3": "val3.2", // change
 		},
 		{
-			// whole watch does not trigger change (but errors will be logged as != redis.Nil)
-			"key1": errors.New("error key1"), // error occurred -> no change should be triggered
-			"key2": errors.New("error key2"), // error occurred -> no change should be triggered
-			"key3": errors.New("error key3"), // error occurred -> no change should be triggered
+			// the stub returns nil for errors, which triggers the deletion of key1, key2 and key3
+			"key1": errors.New("error key1"),
+			"key2": errors.New("error key2"),
+			"key3": errors.New("error key3"),
 		},
 		{
-			// watch does not trigger change or log because key1 watch will lead to redis.Nil
-			"key2": "val2.2", // no change from previous
-			"key3": "val3.2", // no change from previous
+			// re-creating the previous values of key2 and key3 triggers changes, while key1 stays deleted
+			"key2": "val2.2",
+			"key3": "val3.2",
 		},
 		{
-			// all subscribed keys deleted -> do not trigger change or log because redis.Nil is ignored
+			// key2 and key3 deleted -> triggers their deletion
 			"key4": "val4.1", // no change -> not subscribed to this key
 		},
 		{
-			// all subscribed keys deleted -> do not trigger change or log because redis.Nil is ignored
+			// all subscribed keys deleted -> no change, since their deletion was already triggered
 			"key4": "val4.2", // no change -> not subscribed to this key
 		},
 		{
@@ -120,6 +153,19 @@ func TestWatcher_Versioning(t *testing.T) {
 			change.New(config.SourceRedis, "key2", "val2.2", 2),
 			change.New(config.SourceRedis, "key3", "val3.2", 2),
 		},
+		{
+			change.NewDeleted(config.SourceRedis, "key1", 2),
+			change.NewDeleted(config.SourceRedis, "key2", 3),
+			change.NewDeleted(config.SourceRedis, "key3", 3),
+		},
+		{
+			change.New(config.SourceRedis, "key2", "val2.2", 4),
+			change.New(config.SourceRedis, "key3", "val3.2", 4),
+		},
+		{
+			change.NewDeleted(config.SourceRedis, "key2", 5),
+			change.NewDeleted(config.SourceRedis, "key3", 5),
+		},
 	}
 
 	client := clientStub{t: t, m: sync.Mutex{}, watchedKeys: watchedKeys}
@@ -158,6 +204,119 @@ func TestWatcher_Versioning(t *testing.T) {
 	assert.Equal(t, expected, found)
 }
 
+func TestWatcher_Changes_FetchOrder(t *testing.T) {
+	w, err := New(&redis.Client{}, time.Second, []string{"key1", "key2"})
+	require.NoError(t, err)
+
+	// the second fetch is versioned before the first one, whose value of key1 is outdated
+	assert.Equal(t, []*change.Change{change.New(config.SourceRedis, "key1", "val1.2", 1)},
+		w.changes(2, []int{0}, []any{"val1.2"}))
+	assert.Equal(t, []*change.Change{change.New(config.SourceRedis, "key2", "val2.1", 1)},
+		w.changes(1, []int{0, 1}, []any{"val1.1", "val2.1"}))
+	assert.Equal(t, []*change.Change{change.New(config.SourceRedis, "key1", "val1.3", 2)},
+		w.changes(3, []int{0, 1}, []any{"val1.3", "val2.1"}))
+}
+
+func TestWatcher_Watch_Events(t *testing.T) {
+	tests := map[string]struct {
+		option   Option
+		channels []string
+		message  *redis.Message
+	}{
+		"keyspace notifications": {
+			option:   WithKeyspaceNotifications(2),
+			channels: []string{"__keyspace@2__:key1", "__keyspace@2__:key2"},
+			message:  &redis.Message{Channel: "__keyspace@2__:key2", Payload: "set"},
+		},
+		"channel": {
+			option:   WithChannel("config"),
+			channels: []string{"config"},
+			message:  &redis.Message{Channel: "config", Payload: "key2"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			client := &mapClientStub{values: map[string]string{"key1": "val1.1", "key2": "val2.1"}}
+			w, err := New(client, time.Hour, []string{"key1", "key2"}, tt.option)
+			require.NoError(t, err)
+			msgs := make(chan any)
+			closed := make(chan struct{})
+			w.subscribe = func(_ context.Context, channels ...string) (<-chan any, func() error) {
+				assert.Equal(t, tt.channels, channels)
+				return msgs, func() error {
+					close(closed)
+					return nil
+				}
+			}
+			ch := make(chan []*change.Change)
+			ctx, cancel := context.WithCancel(t.Context())
+			require.NoError(t, w.Watch(ctx, ch))
+
+			// the confirmation of the subscription resyncs all keys
+			msgs <- &redis.Subscription{Kind: "subscribe", Channel: tt.channels[0], Count: 1}
+			assertChanges(t, ch, []*change.Change{
+				change.New(config.SourceRedis, "key1", "val1.1", 1),
+				change.New(config.SourceRedis, "key2", "val2.1", 1),
+			})
+
+			// a notification fetches only the notified key
+			client.set("key1", "val1.2")
+			client.set("key2", "val2.2")
+			msgs <- tt.message
+			assertChanges(t, ch, []*change.Change{change.New(config.SourceRedis, "key2", "val2.2", 2)})
+			assert.Equal(t, []string{"key2"}, client.lastKeys())
+
+			// unknown keys are ignored
+			msgs <- &redis.Message{Channel: "__keyspace@2__:other", Payload: "other"}
+
+			// a reconnect resyncs the keys, whose notifications might have been missed
+			msgs <- &redis.Subscription{Kind: "subscribe", Channel: tt.channels[0], Count: 1}
+			assertChanges(t, ch, []*change.Change{change.New(config.SourceRedis, "key1", "val1.2", 2)})
+			assert.Equal(t, 0, w.Health().ConsecutiveErrors)
+
+			cancel()
+			<-w.Done()
+			<-closed
+		})
+	}
+}
+
+func TestWatcher_GetValues_Typed(t *testing.T) {
+	client := &typedClientStub{
+		mapClientStub: mapClientStub{values: map[string]string{"age": "18"}},
+		hashes:        map[string]map[string]string{"svc-config": {"name": "John", "active": "true"}},
+		docs:          map[string]string{"svc-doc": `{"balance":10.5,"owner":"Jane"}`},
+	}
+	keys := []string{"age", "svc-config#name", "svc-config#active", "svc-config#missing", "svc-doc#$.balance", "svc-doc#$.owner"}
+	w, err := New(client, time.Second, keys, WithKeyspaceNotifications(0),
+		WithHashKeys("svc-config#name", "svc-config#active", "svc-config#missing"),
+		WithJSONKeys("svc-doc#$.balance", "svc-doc#$.owner"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"__keyspace@0__:age", "__keyspace@0__:svc-config", "__keyspace@0__:svc-doc"}, w.sub.channels)
+	ch := make(chan []*change.Change, 1)
+
+	require.True(t, w.getValues(t.Context(), ch))
+	assert.Equal(t, []*change.Change{
+		change.New(config.SourceRedis, "age", "18", 1),
+		change.New(config.SourceRedis, "svc-config#name", "John", 1),
+		change.New(config.SourceRedis, "svc-config#active", "true", 1),
+		change.New(config.SourceRedis, "svc-doc#$.balance", "10.5", 1),
+		change.New(config.SourceRedis, "svc-doc#$.owner", "Jane", 1),
+	}, <-ch)
+	// the whole hash is fetched in one round-trip
+	assert.Equal(t, 1, client.hgetalls)
+
+	client.setField("svc-config", "active", "false")
+	require.True(t, w.getValues(t.Context(), ch, "svc-config"))
+	assert.Equal(t, []*change.Change{change.New(config.SourceRedis, "svc-config#active", "false", 2)}, <-ch)
+	assert.Equal(t, 2, client.hgetalls)
+
+	client.docs["svc-doc"] = `{`
+	require.False(t, w.getValues(t.Context(), ch, "svc-doc"))
+	assert.Equal(t, "redis failed to get json svc-doc: redis json reply of path $.balance is not an array: "+
+		"unexpected end of JSON input", w.Health().LastError)
+}
+
 func TestWatcher_GetValues_EdgeCases(t *testing.T) {
 	ctx := context.Background()
 	ch := make(chan []*change.Change, 1)
@@ -207,6 +366,34 @@ func TestWatcher_GetValues_EdgeCases(t *testing.T) {
 	})
 }
 
+func TestWatcher_Watch_Lifecycle(t *testing.T) {
+	c := &failClientStub{
+		mGetFn: func(_ context.Context, _ ...string) *redis.SliceCmd {
+			return redis.NewSliceResult(nil, errors.New("boom"))
+		},
+	}
+	w, err := New(c, time.Millisecond, []string{"key1"})
+	require.NoError(t, err)
+	errs := make(chan error, 1)
+	w.OnError(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, make(chan []*change.Change)))
+	require.EqualError(t, <-errs, "redis failed to get values: boom")
+	h := w.Health()
+	assert.Equal(t, "redis", h.Name)
+	assert.GreaterOrEqual(t, h.ConsecutiveErrors, 1)
+	assert.Equal(t, "redis failed to get values: boom", h.LastError)
+
+	cancel()
+	<-w.Done()
+}
+
 func TestWatcher_BackoffInterval(t *testing.T) {
 	w, err := New(&redis.Client{}, time.Second, []string{"key1"})
 	require.NoError(t, err)
@@ -236,6 +423,9 @@ func TestWatcher_MonitorBackoffResetsAfterSuccess(t *testing.T) {
 	w, err := New(client, time.Second, []string{"key1"})
 	require.NoError(t, err)
 
+	rec := &testRecorder{}
+	w.SetMetrics(rec)
+
 	intervals := make([]time.Duration, 0, 4)
 	w.sleep = func(_ context.Context, interval time.Duration) bool {
 		intervals = append(intervals, interval)
@@ -255,6 +445,110 @@ func TestWatcher_MonitorBackoffResetsAfterSuccess(t *testing.T) {
 		time.Second,
 	}, intervals)
 	assert.Equal(t, 3, client.calls)
+	assert.Equal(t, intervals, rec.backoffs)
+	assert.Equal(t, 3, rec.polls)
+}
+
+type testRecorder struct {
+	metrics.Noop
+	backoffs []time.Duration
+	polls    int
+}
+
+func (r *testRecorder) Backoff(_ config.Source, interval time.Duration) {
+	r.backoffs = append(r.backoffs, interval)
+}
+
+func (r *testRecorder) PollLatency(config.Source, time.Duration) {
+	r.polls++
+}
+
+func assertChanges(t *testing.T, ch <-chan []*change.Change, expected []*change.Change) {
+	t.Helper()
+	select {
+	case cc := <-ch:
+		assert.Equal(t, expected, cc)
+	case <-time.After(time.Second):
+		require.FailNow(t, "expected changes")
+	}
+}
+
+// mapClientStub serves the values from memory and records the keys of the last MGet.
+type mapClientStub struct {
+	*redis.Client
+	mu     sync.Mutex
+	values map[string]string
+	keys   []string
+}
+
+func (c *mapClientStub) set(key, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = value
+}
+
+func (c *mapClientStub) lastKeys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.keys
+}
+
+func (c *mapClientStub) MGet(_ context.Context, keys ...string) *redis.SliceCmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.keys = keys
+	results := make([]any, len(keys))
+	for i, key := range keys {
+		if v, ok := c.values[key]; ok {
+			results[i] = v
+		}
+	}
+	return redis.NewSliceResult(results, nil)
+}
+
+// typedClientStub serves hashes and RedisJSON documents from memory, in addition to string keys.
+type typedClientStub struct {
+	mapClientStub
+	hashes   map[string]map[string]string
+	docs     map[string]string
+	hgetalls int
+}
+
+func (c *typedClientStub) setField(key, field, value string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hashes[key][field] = value
+}
+
+func (c *typedClientStub) HGetAll(_ context.Context, key string) *redis.MapStringStringCmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hgetalls++
+	return redis.NewMapStringStringResult(maps.Clone(c.hashes[key]), nil)
+}
+
+// JSONGet supports only the JSONPath of a top level field.
+func (c *typedClientStub) JSONGet(_ context.Context, key string, paths ...string) *redis.JSONCmd {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cmd := &redis.JSONCmd{}
+	doc, ok := c.docs[key]
+	if !ok {
+		cmd.SetErr(redis.Nil)
+		return cmd
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal([]byte(doc), &fields) != nil {
+		cmd.SetVal(doc)
+		return cmd
+	}
+	v, ok := fields[strings.TrimPrefix(paths[0], "$.")]
+	if !ok {
+		cmd.SetVal("[]")
+		return cmd
+	}
+	cmd.SetVal("[" + string(v) + "]")
+	return cmd
 }
 
 type failClientStub struct {
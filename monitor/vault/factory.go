@@ -0,0 +1,29 @@
+package vault
+
+import (
+	"errors"
+	"time"
+
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/monitor"
+	"github.com/hashicorp/vault/api"
+)
+
+// Factory returns the factory of a watcher of the keys of the fields tagged with vault, which is passed to
+// harvester.WithWatcherFactory.
+func Factory(client *api.Client, pollInterval time.Duration, oo ...Option) func(cfg *config.Config) (monitor.Watcher, error) {
+	return func(cfg *config.Config) (monitor.Watcher, error) {
+		if pollInterval <= 0 {
+			return nil, errors.New("vault monitor poll interval should be a positive number")
+		}
+		keys := make([]string, 0)
+		for _, field := range cfg.Fields {
+			key, ok := field.Sources()[config.SourceVault]
+			if !ok {
+				continue
+			}
+			keys = append(keys, key)
+		}
+		return New(client, pollInterval, keys, oo...)
+	}
+}
@@ -0,0 +1,38 @@
+package vault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testConfig struct {
+	Password sync.Secret `vault:"secret/data/app#password"`
+	Username sync.String `vault:"secret/data/app#username"`
+}
+
+func TestFactory(t *testing.T) {
+	fv := newFakeVault(t)
+	cfg, err := config.New(&testConfig{}, nil)
+	require.NoError(t, err)
+
+	w, err := Factory(fv.client, time.Minute)(cfg)
+	require.NoError(t, err)
+	vw, ok := w.(*Watcher)
+	require.True(t, ok)
+	require.Len(t, vw.secrets, 1)
+	assert.Equal(t, "secret/data/app", vw.secrets[0].path)
+	assert.Len(t, vw.secrets[0].fields, 2)
+
+	w, err = Factory(fv.client, 0)(cfg)
+	require.EqualError(t, err, "vault monitor poll interval should be a positive number")
+	assert.Nil(t, w)
+
+	w, err = Factory(nil, time.Minute)(cfg)
+	require.EqualError(t, err, "client is nil")
+	assert.Nil(t, w)
+}
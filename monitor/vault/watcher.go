@@ -0,0 +1,416 @@
+// Package vault handles the monitor capabilities of harvester using vault.
+package vault
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/beatlabs/harvester/change"
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/internal/vault"
+	"github.com/beatlabs/harvester/metrics"
+	"github.com/beatlabs/harvester/monitor"
+	"github.com/hashicorp/vault/api"
+)
+
+const maxBackoff = 30 * time.Second
+
+// field of a secret which is watched.
+type field struct {
+	key  string
+	name string
+	hash string
+}
+
+// secret which is watched, along with the state of its last read.
+type secret struct {
+	path    string
+	fields  []*field
+	version uint64
+	leaseID string
+	ttl     time.Duration
+	// renewable leases are renewed at renewAt, other leases are read again. Zero if the secret has no lease.
+	renewable bool
+	renewAt   time.Time
+}
+
+// Watcher of Vault changes.
+type Watcher struct {
+	client       *api.Client
+	secrets      []*secret
+	pollInterval time.Duration
+	errFn        func(error)
+	health       *monitor.HealthTracker
+	metrics      metrics.Recorder
+	seeder       Seeder
+	done         chan struct{}
+}
+
+// Seeder hands over the secrets read while seeding by path, which is implemented by the Vault getter of the seed
+// package.
+type Seeder interface {
+	Secrets() map[string]*vault.Secret
+}
+
+// Option for configuring the watcher.
+type Option func(*Watcher) error
+
+// WithSeeder takes over the secrets read by the seeder when watching starts, instead of reading them again. This keeps
+// the leases of dynamic secrets, e.g. database credentials, which would otherwise be replaced by new ones.
+func WithSeeder(s Seeder) Option {
+	return func(w *Watcher) error {
+		if s == nil {
+			return errors.New("seeder is nil")
+		}
+		w.seeder = s
+		return nil
+	}
+}
+
+// New watcher of keys of the form path#field. Secrets without a lease, e.g. KV v1 and v2 secrets, are read every
+// poll interval. KV v2 secrets change when their version is bumped, which is used as the version of the change.
+// Secrets with a lease, e.g. dynamic database credentials, are renewed before their lease expires and read again
+// once the lease cannot be renewed or is capped by its max TTL.
+func New(client *api.Client, pollInterval time.Duration, keys []string, oo ...Option) (*Watcher, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	if pollInterval <= 0 {
+		return nil, errors.New("poll interval should be a positive number")
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("keys are empty")
+	}
+
+	var ss []*secret
+	paths := make(map[string]*secret)
+	for _, key := range keys {
+		k, err := vault.ParseKey(key)
+		if err != nil {
+			return nil, err
+		}
+		s, ok := paths[k.Path]
+		if !ok {
+			s = &secret{path: k.Path}
+			paths[k.Path] = s
+			ss = append(ss, s)
+		}
+		s.fields = append(s.fields, &field{key: key, name: k.Field})
+	}
+
+	w := &Watcher{
+		client:       client,
+		secrets:      ss,
+		pollInterval: pollInterval,
+		health:       monitor.NewHealthTracker("vault"),
+		metrics:      metrics.Noop{},
+		done:         make(chan struct{}),
+	}
+	for _, o := range oo {
+		err := o(w)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// Watch keys and changes. The secrets are read when watching starts, unless they are taken over from the seeder,
+// see WithSeeder.
+func (w *Watcher) Watch(ctx context.Context, ch chan<- []*change.Change) error {
+	if ctx == nil {
+		return errors.New("context is nil")
+	}
+	if ch == nil {
+		return errors.New("change channel is nil")
+	}
+
+	w.health.Start()
+	wait := w.adopt()
+	go func() {
+		defer close(w.done)
+		w.monitor(ctx, ch, wait)
+	}()
+	return nil
+}
+
+// OnError sets the function which is called with the errors of the polls.
+func (w *Watcher) OnError(fn func(error)) {
+	w.errFn = fn
+}
+
+// Done returns a channel which is closed once polling has stopped, after the context passed to Watch
+// has been cancelled.
+func (w *Watcher) Done() <-chan struct{} {
+	return w.done
+}
+
+// SetMetrics sets the recorder of the poll latency and backoff metrics.
+func (w *Watcher) SetMetrics(r metrics.Recorder) {
+	w.metrics = r
+}
+
+// Health of the polls.
+func (w *Watcher) Health() monitor.WatcherHealth {
+	return w.health.Health()
+}
+
+func (w *Watcher) report(err error) {
+	w.health.Failure(err)
+	if w.errFn != nil {
+		w.errFn(err)
+	}
+}
+
+// adopt the secrets read by the seeder and return the wait until the first poll, which is 0 unless all of them
+// have been taken over.
+func (w *Watcher) adopt() time.Duration {
+	if w.seeder == nil {
+		return 0
+	}
+	seeded := w.seeder.Secrets()
+	all := true
+	for _, s := range w.secrets {
+		sec, ok := seeded[s.path]
+		if !ok {
+			all = false
+			continue
+		}
+		err := s.adopt(sec)
+		if err != nil {
+			slog.Warn("failed to take over seeded secret, reading secret", "path", s.path, "err", err)
+			all = false
+		}
+	}
+	if !all {
+		return 0
+	}
+	w.health.Success()
+	return w.untilRenewal(w.pollInterval)
+}
+
+func (w *Watcher) monitor(ctx context.Context, ch chan<- []*change.Change, wait time.Duration) {
+	consecutiveErrors := 0
+
+	for {
+		if !sleepContext(ctx, wait) {
+			return
+		}
+
+		if w.poll(ctx, ch) {
+			w.health.Success()
+			consecutiveErrors = 0
+			wait = w.untilRenewal(w.pollInterval)
+		} else {
+			consecutiveErrors++
+			wait = w.backoffInterval(consecutiveErrors)
+		}
+		w.metrics.Backoff(config.SourceVault, wait)
+	}
+}
+
+// untilRenewal returns the time until the next lease has to be renewed, if it is earlier than the interval.
+func (w *Watcher) untilRenewal(interval time.Duration) time.Duration {
+	for _, s := range w.secrets {
+		if s.renewAt.IsZero() {
+			continue
+		}
+		interval = min(interval, max(time.Until(s.renewAt), 0))
+	}
+	return interval
+}
+
+func (w *Watcher) poll(ctx context.Context, ch chan<- []*change.Change) bool {
+	ok := true
+	var changes []*change.Change
+	for _, s := range w.secrets {
+		cc, err := w.refresh(ctx, s)
+		if err != nil {
+			slog.Error("failed to refresh secret", "path", s.path, "err", err)
+			w.report(err)
+			ok = false
+			continue
+		}
+		changes = append(changes, cc...)
+	}
+
+	if len(changes) == 0 {
+		return ok
+	}
+
+	select {
+	case <-ctx.Done():
+	case ch <- changes:
+	}
+	return ok
+}
+
+// refresh the secret by renewing its lease or reading it and return the changes of its fields.
+func (w *Watcher) refresh(ctx context.Context, s *secret) ([]*change.Change, error) {
+	if !s.renewAt.IsZero() {
+		if time.Now().Before(s.renewAt) {
+			return nil, nil
+		}
+		if s.renewable {
+			renewed, err := w.renew(ctx, s)
+			if err != nil {
+				slog.Warn("failed to renew lease, reading secret", "path", s.path, "err", err)
+			}
+			if renewed {
+				return nil, nil
+			}
+		}
+	}
+
+	start := time.Now()
+	sec, err := vault.Read(ctx, w.client, s.path)
+	w.metrics.PollLatency(config.SourceVault, time.Since(start))
+	if err != nil {
+		if errors.Is(err, vault.ErrNotFound) {
+			slog.Debug("secret does not exist", "path", s.path)
+			return nil, nil
+		}
+		return nil, fmt.Errorf("vault failed to read secret %s: %w", s.path, err)
+	}
+
+	s.lease(sec)
+	return s.changes(sec)
+}
+
+// renew the lease of the secret. It returns false if the renewed lease is capped by its max TTL,
+// which means that the secret has to be read again.
+func (w *Watcher) renew(ctx context.Context, s *secret) (bool, error) {
+	start := time.Now()
+	sec, err := w.client.Sys().RenewWithContext(ctx, s.leaseID, 0)
+	if err != nil {
+		return false, err
+	}
+	if sec == nil {
+		return false, errors.New("renewal response is empty")
+	}
+	ttl := time.Duration(sec.LeaseDuration) * time.Second
+	if ttl < s.ttl {
+		slog.Debug("lease reached its max ttl, reading secret", "path", s.path, "ttl", ttl)
+		return false, nil
+	}
+	s.renewAt = renewalTime(start, ttl)
+	slog.Debug("lease renewed", "path", s.path, "ttl", ttl)
+	return true, nil
+}
+
+func (s *secret) lease(sec *vault.Secret) {
+	if sec.LeaseID == "" || sec.LeaseDuration <= 0 {
+		s.leaseID, s.ttl, s.renewable, s.renewAt = "", 0, false, time.Time{}
+		return
+	}
+	s.leaseID = sec.LeaseID
+	s.ttl = sec.LeaseDuration
+	s.renewable = sec.Renewable
+	s.renewAt = renewalTime(sec.ReadAt, sec.LeaseDuration)
+}
+
+// adopt the secret read while seeding, which takes over its lease and the hashes of its values.
+func (s *secret) adopt(sec *vault.Secret) error {
+	hashes := make([]string, len(s.fields))
+	for i, f := range s.fields {
+		val, ok, err := sec.Value(f.name)
+		if err != nil {
+			return fmt.Errorf("vault failed to get value of %s: %w", f.key, err)
+		}
+		if ok {
+			hashes[i] = hash(val)
+		}
+	}
+	for i, f := range s.fields {
+		f.hash = hashes[i]
+	}
+	s.version = sec.Version
+	s.lease(sec)
+	return nil
+}
+
+// changes of the fields of the secret. The version of a KV v2 secret is used as the version of the changes,
+// otherwise the version is increased on every change.
+func (s *secret) changes(sec *vault.Secret) ([]*change.Change, error) {
+	if sec.Version != 0 && sec.Version == s.version {
+		return nil, nil
+	}
+
+	type update struct {
+		f    *field
+		val  string
+		hash string
+	}
+	var uu []update
+	for _, f := range s.fields {
+		val, ok, err := sec.Value(f.name)
+		if err != nil {
+			return nil, fmt.Errorf("vault failed to get value of %s: %w", f.key, err)
+		}
+		if !ok {
+			continue
+		}
+		h := hash(val)
+		if h == f.hash {
+			continue
+		}
+		uu = append(uu, update{f: f, val: val, hash: h})
+	}
+
+	switch {
+	case sec.Version != 0:
+		s.version = sec.Version
+	case len(uu) > 0:
+		s.version++
+	}
+
+	cc := make([]*change.Change, 0, len(uu))
+	for _, u := range uu {
+		u.f.hash = u.hash
+		cc = append(cc, change.New(config.SourceVault, u.f.key, u.val, s.version))
+	}
+	return cc, nil
+}
+
+// renewalTime returns the time a lease which started at start is renewed, which is after two thirds of its ttl.
+func renewalTime(start time.Time, ttl time.Duration) time.Time {
+	return start.Add(ttl * 2 / 3)
+}
+
+func hash(value string) string {
+	h := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(h[:])
+}
+
+func (w *Watcher) backoffInterval(consecutiveErrors int) time.Duration {
+	if consecutiveErrors <= 0 {
+		return w.pollInterval
+	}
+
+	interval := w.pollInterval
+	for range consecutiveErrors {
+		if interval >= maxBackoff/2 {
+			return maxBackoff
+		}
+		interval *= 2
+	}
+
+	return interval
+}
+
+func sleepContext(ctx context.Context, interval time.Duration) bool {
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,316 @@
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/harvester/change"
+	"github.com/beatlabs/harvester/monitor"
+	seedvault "github.com/beatlabs/harvester/seed/vault"
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	type args struct {
+		client       *api.Client
+		pollInterval time.Duration
+		keys         []string
+		oo           []Option
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"success":               {args: args{client: &api.Client{}, pollInterval: time.Second, keys: []string{"kv/app#password"}}},
+		"client nil":            {args: args{client: nil, pollInterval: time.Second, keys: []string{"kv/app#password"}}, expectedErr: "client is nil"},
+		"poll interval invalid": {args: args{client: &api.Client{}, pollInterval: 0, keys: []string{"kv/app#password"}}, expectedErr: "poll interval should be a positive number"},
+		"keys are missing":      {args: args{client: &api.Client{}, pollInterval: time.Second, keys: nil}, expectedErr: "keys are empty"},
+		"key is invalid":        {args: args{client: &api.Client{}, pollInterval: time.Second, keys: []string{"kv/app"}}, expectedErr: `vault key "kv/app" should have the form path#field`},
+		"seeder nil":            {args: args{client: &api.Client{}, pollInterval: time.Second, keys: []string{"kv/app#password"}, oo: []Option{WithSeeder(nil)}}, expectedErr: "seeder is nil"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := New(tt.args.client, tt.args.pollInterval, tt.args.keys, tt.args.oo...)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	w, err := New(&api.Client{}, time.Second, []string{"kv/app#password"})
+	require.NoError(t, err)
+	type args struct {
+		ctx context.Context
+		ch  chan<- []*change.Change
+	}
+	tests := map[string]struct {
+		args        args
+		expectedErr string
+	}{
+		"missing context": {args: args{ch: make(chan []*change.Change)}, expectedErr: "context is nil"},
+		"missing chan":    {args: args{ctx: t.Context()}, expectedErr: "change channel is nil"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.EqualError(t, w.Watch(tt.args.ctx, tt.args.ch), tt.expectedErr)
+		})
+	}
+}
+
+func TestWatcher_Watch_KV(t *testing.T) {
+	fv := newFakeVault(t)
+	fv.set("secret/data/app", `{"data":{"data":{"password":"a","user":"admin"},"metadata":{"version":1}}}`)
+	fv.set("kv/app", `{"lease_duration":2764800,"data":{"token":"x"}}`)
+	w, err := New(fv.client, 10*time.Millisecond, []string{"secret/data/app#password", "secret/data/app#user", "kv/app#token"})
+	require.NoError(t, err)
+	ch := make(chan []*change.Change)
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	require.NoError(t, w.Watch(ctx, ch))
+
+	assertChanges(t, ch, []*change.Change{
+		change.New("vault", "secret/data/app#password", "a", 1),
+		change.New("vault", "secret/data/app#user", "admin", 1),
+		change.New("vault", "kv/app#token", "x", 1),
+	})
+
+	fv.set("secret/data/app", `{"data":{"data":{"password":"b","user":"admin"},"metadata":{"version":2}}}`)
+	assertChanges(t, ch, []*change.Change{change.New("vault", "secret/data/app#password", "b", 2)})
+
+	fv.set("kv/app", `{"lease_duration":2764800,"data":{"token":"y"}}`)
+	assertChanges(t, ch, []*change.Change{change.New("vault", "kv/app#token", "y", 2)})
+
+	cancel()
+	<-w.Done()
+}
+
+func TestWatcher_Watch_Lease(t *testing.T) {
+	t.Run("lease not renewable, secret read again", func(t *testing.T) {
+		fv := newFakeVault(t)
+		fv.set("database/creds/app", `{"lease_id":"database/creds/app/1","lease_duration":1,"renewable":false,"data":{"password":"p1"}}`)
+		w, err := New(fv.client, 10*time.Millisecond, []string{"database/creds/app#password"})
+		require.NoError(t, err)
+		ch := make(chan []*change.Change)
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		require.NoError(t, w.Watch(ctx, ch))
+
+		assertChanges(t, ch, []*change.Change{change.New("vault", "database/creds/app#password", "p1", 1)})
+		fv.set("database/creds/app", `{"lease_id":"database/creds/app/2","lease_duration":1,"renewable":false,"data":{"password":"p2"}}`)
+		assertChanges(t, ch, []*change.Change{change.New("vault", "database/creds/app#password", "p2", 2)})
+
+		assert.Equal(t, 2, fv.readCount("database/creds/app"))
+		assert.Equal(t, 0, fv.renewCount())
+	})
+
+	t.Run("lease renewed until capped, secret read again", func(t *testing.T) {
+		fv := newFakeVault(t)
+		fv.set("database/creds/app", `{"lease_id":"database/creds/app/1","lease_duration":1,"renewable":true,"data":{"password":"p1"}}`)
+		fv.setRenewals(1, 0)
+		w, err := New(fv.client, 10*time.Millisecond, []string{"database/creds/app#password"})
+		require.NoError(t, err)
+		ch := make(chan []*change.Change)
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+		require.NoError(t, w.Watch(ctx, ch))
+
+		assertChanges(t, ch, []*change.Change{change.New("vault", "database/creds/app#password", "p1", 1)})
+		fv.set("database/creds/app", `{"lease_id":"database/creds/app/2","lease_duration":60,"renewable":true,"data":{"password":"p2"}}`)
+		assertChanges(t, ch, []*change.Change{change.New("vault", "database/creds/app#password", "p2", 2)})
+
+		assert.Equal(t, 2, fv.readCount("database/creds/app"))
+		assert.Equal(t, 2, fv.renewCount())
+	})
+}
+
+func TestWatcher_Watch_Seeder(t *testing.T) {
+	keys := []string{"database/creds/app#username", "database/creds/app#password"}
+
+	t.Run("seeded lease taken over", func(t *testing.T) {
+		fv := newFakeVault(t)
+		fv.set("database/creds/app", `{"lease_id":"database/creds/app/1","lease_duration":1,"renewable":true,"data":{"username":"u1","password":"p1"}}`)
+		fv.setRenewals(60)
+		g, err := seedvault.New(fv.client)
+		require.NoError(t, err)
+		for _, key := range keys {
+			_, _, err := g.Get(key)
+			require.NoError(t, err)
+		}
+		w, err := New(fv.client, time.Hour, keys, WithSeeder(g))
+		require.NoError(t, err)
+		ch := make(chan []*change.Change)
+		ctx, cancel := context.WithCancel(t.Context())
+		require.NoError(t, w.Watch(ctx, ch))
+		assert.False(t, w.Health().Starting)
+
+		assert.Eventually(t, func() bool { return fv.renewCount() == 1 }, 3*time.Second, 10*time.Millisecond)
+		assert.Equal(t, 1, fv.readCount("database/creds/app"))
+		select {
+		case cc := <-ch:
+			assert.Failf(t, "unexpected changes", "%v", cc)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		cancel()
+		<-w.Done()
+	})
+
+	t.Run("secret not seeded, read when watching starts", func(t *testing.T) {
+		fv := newFakeVault(t)
+		fv.set("database/creds/app", `{"lease_id":"database/creds/app/1","lease_duration":60,"renewable":true,"data":{"username":"u1","password":"p1"}}`)
+		g, err := seedvault.New(fv.client)
+		require.NoError(t, err)
+		w, err := New(fv.client, time.Hour, keys, WithSeeder(g))
+		require.NoError(t, err)
+		ch := make(chan []*change.Change)
+		ctx, cancel := context.WithCancel(t.Context())
+		require.NoError(t, w.Watch(ctx, ch))
+
+		assertChanges(t, ch, []*change.Change{
+			change.New("vault", "database/creds/app#username", "u1", 1),
+			change.New("vault", "database/creds/app#password", "p1", 1),
+		})
+		assert.Equal(t, 1, fv.readCount("database/creds/app"))
+
+		cancel()
+		<-w.Done()
+	})
+}
+
+func TestWatcher_Watch_Error(t *testing.T) {
+	fv := newFakeVault(t)
+	w, err := New(fv.client, 10*time.Millisecond, []string{"failing#password"})
+	require.NoError(t, err)
+	errs := make(chan error, 10)
+	w.OnError(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+	ctx, cancel := context.WithCancel(t.Context())
+	require.NoError(t, w.Watch(ctx, make(chan []*change.Change)))
+
+	select {
+	case err := <-errs:
+		require.ErrorContains(t, err, "vault failed to read secret failing")
+	case <-time.After(time.Second):
+		require.FailNow(t, "expected an error")
+	}
+	assert.Positive(t, w.Health().ConsecutiveErrors)
+
+	cancel()
+	<-w.Done()
+}
+
+func assertChanges(t *testing.T, ch <-chan []*change.Change, expected []*change.Change) {
+	t.Helper()
+	select {
+	case cc := <-ch:
+		assert.Equal(t, expected, cc)
+	case <-time.After(3 * time.Second):
+		require.FailNow(t, "expected changes")
+	}
+}
+
+type fakeVault struct {
+	client   *api.Client
+	mu       sync.Mutex
+	secrets  map[string]string
+	reads    map[string]int
+	renewals []int
+	renewed  int
+}
+
+// newFakeVault starts a stand-in for the Vault HTTP API, which serves the secrets and renews the leases
+// with the given lease durations. Any other path fails with a permission error.
+func newFakeVault(t *testing.T) *fakeVault {
+	fv := &fakeVault{secrets: make(map[string]string), reads: make(map[string]int)}
+	srv := httptest.NewServer(http.HandlerFunc(fv.serveHTTP))
+	t.Cleanup(srv.Close)
+
+	cfg := api.DefaultConfig()
+	cfg.Address = srv.URL
+	cfg.MaxRetries = 0
+	client, err := api.NewClient(cfg)
+	require.NoError(t, err)
+	client.SetToken("token")
+	fv.client = client
+	return fv
+}
+
+func (fv *fakeVault) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+
+	path := r.URL.Path[len("/v1/"):]
+	if r.Method == http.MethodPut && path == "sys/leases/renew" {
+		var req struct {
+			LeaseID string `json:"lease_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		ttl := 0
+		if fv.renewed < len(fv.renewals) {
+			ttl = fv.renewals[fv.renewed]
+		}
+		fv.renewed++
+		_ = json.NewEncoder(w).Encode(map[string]any{"lease_id": req.LeaseID, "lease_duration": ttl, "renewable": true})
+		return
+	}
+
+	body, ok := fv.secrets[path]
+	if !ok {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+	fv.reads[path]++
+	_, _ = w.Write([]byte(body))
+}
+
+func (fv *fakeVault) set(path, body string) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	fv.secrets[path] = body
+}
+
+func (fv *fakeVault) setRenewals(ttls ...int) {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	fv.renewals = ttls
+}
+
+func (fv *fakeVault) readCount(path string) int {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	return fv.reads[path]
+}
+
+func (fv *fakeVault) renewCount() int {
+	fv.mu.Lock()
+	defer fv.mu.Unlock()
+	return fv.renewed
+}
+
+var (
+	_ monitor.ErrorReporter  = &Watcher{}
+	_ monitor.Stopper        = &Watcher{}
+	_ monitor.HealthReporter = &Watcher{}
+	_ monitor.Instrumented   = &Watcher{}
+)
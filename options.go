@@ -2,22 +2,28 @@ package harvester
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/metrics"
 	"github.com/beatlabs/harvester/monitor"
 	"github.com/beatlabs/harvester/monitor/consul"
+	filemon "github.com/beatlabs/harvester/monitor/file"
 	redismon "github.com/beatlabs/harvester/monitor/redis"
 	"github.com/beatlabs/harvester/seed"
 	seedconsul "github.com/beatlabs/harvester/seed/consul"
 	seedredis "github.com/beatlabs/harvester/seed/redis"
+	consulapi "github.com/hashicorp/consul/api"
 	"github.com/redis/go-redis/v9"
 )
 
 type options struct {
-	cfg           *config.Config
-	seedParams    []seed.Param
-	monitorParams []monitor.Watcher
+	cfg            *config.Config
+	seedParams     []seed.Param
+	seedOptions    []seed.Option
+	monitorParams  []monitor.Watcher
+	monitorOptions []monitor.Option
 }
 
 // OptionFunc is used to configure harvester in an optional manner.
@@ -47,19 +53,11 @@ func WithConsulSeed(addr, dataCenter, token string, timeout time.Duration) Optio
 	return WithConsulSeedWithPrefix(addr, dataCenter, token, "", timeout)
 }
 
-// WithConsulFolderPrefixMonitor sets up a Consul monitor to use prefixes.
+// WithConsulFolderPrefixMonitor sets up a Consul monitor to use prefixes. The fields of a struct bound to a prefix
+// with the consul_prefix tag are monitored with a single keyprefix plan.
 func WithConsulFolderPrefixMonitor(addr, dataCenter, token, folderPrefix string, timeout time.Duration) OptionFunc {
 	return func(opts *options) error {
-		items := make([]consul.Item, 0)
-		for _, field := range opts.cfg.Fields {
-			consulKey, ok := field.Sources()[config.SourceConsul]
-			if !ok {
-				continue
-			}
-			items = append(items, consul.NewKeyItemWithPrefix(consulKey, folderPrefix))
-		}
-
-		prm, err := consul.New(addr, dataCenter, token, timeout, items...)
+		prm, err := consul.New(addr, dataCenter, token, timeout, consulItems(opts.cfg, folderPrefix)...)
 		if err != nil {
 			return err
 		}
@@ -75,10 +73,97 @@ func WithConsulMonitor(addr, dataCenter, token string, timeout time.Duration) Op
 	return WithConsulFolderPrefixMonitor(addr, dataCenter, token, "", timeout)
 }
 
+// WithConsulClientSeed sets up a Consul seeder, which uses the client, e.g. in order to share it with the monitor.
+// The datacenter, token, namespace, partition and TLS settings of the client are used. The getter options set the
+// timeout of the requests, 60s by default, and the consistency mode of the reads, e.g. seedconsul.WithStaleRetry.
+func WithConsulClientSeed(client *consulapi.Client, folderPrefix string, oo ...seedconsul.Option) OptionFunc {
+	return func(opts *options) error {
+		getter, err := seedconsul.NewWithClient(client, folderPrefix, oo...)
+		if err != nil {
+			return err
+		}
+
+		prm, err := seed.NewParam(config.SourceConsul, getter)
+		if err != nil {
+			return err
+		}
+
+		opts.seedParams = append(opts.seedParams, *prm)
+
+		return nil
+	}
+}
+
+// WithConsulClientMonitor sets up a Consul monitor, which uses the client, e.g. in order to share it with the seeder.
+// The datacenter, token, namespace, partition and TLS settings of the client are used. The watcher options set the
+// consistency mode of the blocking queries, e.g. consul.WithAllowStale. The HTTP client of the client should not have
+// a timeout, since it would cut the blocking queries short.
+func WithConsulClientMonitor(client *consulapi.Client, folderPrefix string, oo ...consul.Option) OptionFunc {
+	return func(opts *options) error {
+		prm, err := consul.NewWithOptions(client, consulItems(opts.cfg, folderPrefix), oo...)
+		if err != nil {
+			return err
+		}
+
+		opts.monitorParams = append(opts.monitorParams, prm)
+
+		return nil
+	}
+}
+
+// WithConsulConfig sets up a Consul seeder and monitor, which share a client created with the config, e.g.
+// api.DefaultConfig, which reads the standard CONSUL_* environment variables like CONSUL_HTTP_TOKEN_FILE.
+// The seed requests time out after 60s. A timeout of the HTTP client of the config is rejected, since it would cut
+// the blocking queries of the monitor short.
+func WithConsulConfig(cfg *consulapi.Config, folderPrefix string) OptionFunc {
+	return func(opts *options) error {
+		if cfg == nil {
+			return errors.New("consul config is nil")
+		}
+		err := consul.ValidateConfig(cfg)
+		if err != nil {
+			return err
+		}
+		client, err := consulapi.NewClient(cfg)
+		if err != nil {
+			return err
+		}
+
+		err = WithConsulClientSeed(client, folderPrefix)(opts)
+		if err != nil {
+			return err
+		}
+		return WithConsulClientMonitor(client, folderPrefix)(opts)
+	}
+}
+
+// consulItems of the fields with a Consul key, where the fields of a struct bound to a prefix share a prefix item.
+func consulItems(cfg *config.Config, folderPrefix string) []consul.Item {
+	items := make([]consul.Item, 0)
+	prefixes := make(map[string]bool)
+	for _, field := range cfg.Fields {
+		consulKey, ok := field.Sources()[config.SourceConsul]
+		if !ok {
+			continue
+		}
+		prefix := field.Prefix(config.SourceConsul)
+		if prefix == "" {
+			items = append(items, consul.NewKeyItemWithPrefix(consulKey, folderPrefix))
+			continue
+		}
+		if !prefixes[prefix] {
+			prefixes[prefix] = true
+			items = append(items, consul.NewPrefixItemWithPrefix(prefix, folderPrefix))
+		}
+	}
+	return items
+}
+
 // WithRedisSeed sets up a Redis seeder.
 func WithRedisSeed(client redis.UniversalClient) OptionFunc {
 	return func(opts *options) error {
-		getter, err := seedredis.New(client)
+		_, hashes, docs := redisKeys(opts.cfg)
+		getter, err := seedredis.New(client, seedredis.WithHashKeys(hashes...), seedredis.WithJSONKeys(docs...))
 		if err != nil {
 			return err
 		}
@@ -94,22 +179,64 @@ func WithRedisSeed(client redis.UniversalClient) OptionFunc {
 	}
 }
 
-// WithRedisMonitor sets up a Redis monitor.
-func WithRedisMonitor(client redis.UniversalClient, pollInterval time.Duration) OptionFunc {
+// WithRedisMonitor sets up a Redis monitor, which polls the keys every poll interval. The watcher options,
+// e.g. redismon.WithKeyspaceNotifications, make it event-driven, where polling only resyncs the keys, see
+// redismon.WithResyncInterval.
+func WithRedisMonitor(client redis.UniversalClient, pollInterval time.Duration, oo ...redismon.Option) OptionFunc {
 	return func(opts *options) error {
 		if pollInterval <= 0 {
 			return errors.New("redis monitor poll interval should be a positive number")
 		}
 
-		items := make([]string, 0)
+		items, hashes, docs := redisKeys(opts.cfg)
+		oo = append([]redismon.Option{redismon.WithHashKeys(hashes...), redismon.WithJSONKeys(docs...)}, oo...)
+		wtc, err := redismon.New(client, pollInterval, items, oo...)
+		if err != nil {
+			return err
+		}
+
+		opts.monitorParams = append(opts.monitorParams, wtc)
+		return nil
+	}
+}
+
+// redisKeys returns the Redis keys of the fields and the ones of them which are hash fields or RedisJSON paths, which
+// are set with the redis_hash and redis_json tags.
+func redisKeys(cfg *config.Config) ([]string, []string, []string) {
+	keys := make([]string, 0)
+	var hashes, docs []string
+	for _, field := range cfg.Fields {
+		key, ok := field.Sources()[config.SourceRedis]
+		if !ok {
+			continue
+		}
+		keys = append(keys, key)
+		switch field.KeyTag(config.SourceRedis) {
+		case config.RedisHashTag:
+			hashes = append(hashes, key)
+		case config.RedisJSONTag:
+			docs = append(docs, key)
+		}
+	}
+	return keys, hashes, docs
+}
+
+// WithFileMonitor sets up a file monitor.
+func WithFileMonitor(pollInterval time.Duration) OptionFunc {
+	return func(opts *options) error {
+		if pollInterval <= 0 {
+			return errors.New("file monitor poll interval should be a positive number")
+		}
+
+		files := make([]string, 0)
 		for _, field := range opts.cfg.Fields {
-			redisKey, ok := field.Sources()[config.SourceRedis]
+			file, ok := field.Sources()[config.SourceFile]
 			if !ok {
 				continue
 			}
-			items = append(items, redisKey)
+			files = append(files, file)
 		}
-		wtc, err := redismon.New(client, pollInterval, items)
+		wtc, err := filemon.New(pollInterval, files)
 		if err != nil {
 			return err
 		}
@@ -118,3 +245,123 @@ func WithRedisMonitor(client redis.UniversalClient, pollInterval time.Duration)
 		return nil
 	}
 }
+
+// WithTransactionalMonitor makes the monitor apply each batch of changes atomically, e.g. all keys of a Consul
+// key-prefix. If any value of the batch is invalid, the whole batch is rejected.
+func WithTransactionalMonitor() OptionFunc {
+	return func(opts *options) error {
+		opts.monitorOptions = append(opts.monitorOptions, monitor.Transactional())
+		return nil
+	}
+}
+
+// WithMetrics sets the recorder of the seeding and monitoring metrics, e.g. the OpenTelemetry adapter of the
+// metrics/otel package.
+func WithMetrics(r metrics.Recorder) OptionFunc {
+	return func(opts *options) error {
+		if r == nil {
+			return errors.New("metrics recorder is nil")
+		}
+		opts.seedOptions = append(opts.seedOptions, seed.WithMetrics(r))
+		opts.monitorOptions = append(opts.monitorOptions, monitor.WithMetrics(r))
+		return nil
+	}
+}
+
+// WithHealthThresholds sets the thresholds which define when a watcher is degraded or failed.
+func WithHealthThresholds(t monitor.HealthThresholds) OptionFunc {
+	return func(opts *options) error {
+		opts.monitorOptions = append(opts.monitorOptions, monitor.WithHealthThresholds(t))
+		return nil
+	}
+}
+
+// WithSeedGetter sets up a seeder for a registered source.
+func WithSeedGetter(src config.Source, getter seed.Getter) OptionFunc {
+	return func(opts *options) error {
+		if !config.IsRegistered(src) {
+			return fmt.Errorf("source %s is not registered", src)
+		}
+
+		prm, err := seed.NewParam(src, getter)
+		if err != nil {
+			return err
+		}
+
+		opts.seedParams = append(opts.seedParams, *prm)
+
+		return nil
+	}
+}
+
+// WithSeedGetterFunc sets up a seeder for a registered source, with the getter created by the function. An error of
+// the function fails the creation of the harvester.
+func WithSeedGetterFunc(src config.Source, fn func() (seed.Getter, error)) OptionFunc {
+	return func(opts *options) error {
+		if fn == nil {
+			return errors.New("getter function is nil")
+		}
+		getter, err := fn()
+		if err != nil {
+			return err
+		}
+		return WithSeedGetter(src, getter)(opts)
+	}
+}
+
+// WatcherFactory creates a watcher for the config, e.g. for the keys of the fields tagged with its source.
+type WatcherFactory func(cfg *config.Config) (monitor.Watcher, error)
+
+// WithWatcherFactory sets up a monitor with the watcher created by the factory for the config of the harvester.
+func WithWatcherFactory(fn WatcherFactory) OptionFunc {
+	return func(opts *options) error {
+		if fn == nil {
+			return errors.New("watcher factory is nil")
+		}
+		watcher, err := fn(opts.cfg)
+		if err != nil {
+			return err
+		}
+		return WithWatcher(watcher)(opts)
+	}
+}
+
+// WithWatcher sets up a monitor with a custom watcher.
+func WithWatcher(watcher monitor.Watcher) OptionFunc {
+	return func(opts *options) error {
+		if watcher == nil {
+			return errors.New("watcher is nil")
+		}
+
+		opts.monitorParams = append(opts.monitorParams, watcher)
+
+		return nil
+	}
+}
+
+// WithSourcePrecedence sets the order in which the sources are applied during seeding.
+// The given sources are applied last and in the given order, e.g. WithSourcePrecedence(config.SourceConsul, config.SourceEnv)
+// makes env values override Consul values. Sources which are not given keep their registered precedence and are applied first.
+// The order can be overridden per field with the `precedence:"consul,env"` struct tag.
+func WithSourcePrecedence(ss ...config.Source) OptionFunc {
+	return func(opts *options) error {
+		_, err := config.SourceOrder(nil, ss...)
+		if err != nil {
+			return err
+		}
+
+		opts.seedOptions = append(opts.seedOptions, seed.WithPrecedence(ss...))
+
+		return nil
+	}
+}
+
+// WithPrecedenceWhileMonitoring keeps the seeding precedence while monitoring. Once a field is seeded, changes from
+// sources which come before the source of the seeded value are dropped, e.g. a Consul change does not override a value
+// seeded from an env var which comes after Consul. By default, every change of a monitored source is applied.
+func WithPrecedenceWhileMonitoring() OptionFunc {
+	return func(opts *options) error {
+		opts.seedOptions = append(opts.seedOptions, seed.WithPrecedenceWhileMonitoring())
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package harvester
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/seed"
+)
+
+// Source describes a pluggable configuration source.
+type Source struct {
+	// Name of the source, which is also the struct tag used to declare the key of a field.
+	Name config.Source
+	// Precedence defines the order in which the source is applied during seeding.
+	// Sources with a higher precedence override the values of sources with a lower one.
+	Precedence int
+	// Getter is used for seeding fields tagged with the source. It is optional and can also be
+	// provided per harvester instance with WithSeedGetter.
+	Getter seed.Getter
+	// WatcherFactory creates the watcher which monitors the fields tagged with the source, for every harvester, since
+	// a watcher runs for a single harvester. It is optional and the watcher can also be provided per harvester
+	// instance with WithWatcher or WithWatcherFactory.
+	WatcherFactory WatcherFactory
+}
+
+var registry = struct {
+	mu sync.RWMutex
+	ss []Source
+}{}
+
+// RegisterSource registers a source in order to make its struct tag, getter and watcher factory available
+// to every harvester created afterwards. Registering a source with the name of an existing one
+// replaces its getter and watcher factory, as long as the precedence is the same. The names of the built-in sources
+// and of the other struct tags, e.g. on_delete, are reserved.
+func RegisterSource(src Source) error {
+	if src.Name == "" {
+		return errors.New("source name is empty")
+	}
+	err := config.RegisterSource(src.Name, src.Precedence)
+	if err != nil {
+		return err
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	for i, s := range registry.ss {
+		if s.Name == src.Name {
+			registry.ss[i] = src
+			return nil
+		}
+	}
+	registry.ss = append(registry.ss, src)
+	return nil
+}
+
+func registeredSources() []Source {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	return append([]Source(nil), registry.ss...)
+}
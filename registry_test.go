@@ -0,0 +1,221 @@
+package harvester
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/beatlabs/harvester/change"
+	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/monitor"
+	vaultmon "github.com/beatlabs/harvester/monitor/vault"
+	"github.com/beatlabs/harvester/seed"
+	seedvault "github.com/beatlabs/harvester/seed/vault"
+	"github.com/beatlabs/harvester/sync"
+	vaultapi "github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSource         config.Source = "harvester-test-kv"
+	testExistingSource config.Source = "harvester-test-existing"
+)
+
+func TestRegisterSource(t *testing.T) {
+	require.NoError(t, RegisterSource(Source{Name: testExistingSource, Precedence: 450}))
+	tests := map[string]struct {
+		src         Source
+		expectedErr string
+	}{
+		"success":              {src: Source{Name: testSource, Precedence: 450, Getter: &stubGetter{}}},
+		"success replace":      {src: Source{Name: testSource, Precedence: 450, Getter: &stubGetter{value: "foo"}}},
+		"empty name":           {src: Source{}, expectedErr: "source name is empty"},
+		"different precedence": {src: Source{Name: testExistingSource, Precedence: 1}, expectedErr: "source harvester-test-existing is already registered with precedence 450"},
+		"built in":             {src: Source{Name: config.SourceEnv, Precedence: config.PrecedenceEnv}, expectedErr: "source env is built in"},
+		"reserved tag":         {src: Source{Name: "on_delete", Precedence: 450}, expectedErr: "source name on_delete is a reserved tag"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := RegisterSource(tt.src)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNew_RegisteredSource(t *testing.T) {
+	require.NoError(t, RegisterSource(Source{Name: testSource, Precedence: 450, Getter: &stubGetter{value: "Jane Doe"}}))
+
+	cfg := &testConfigRegisteredSource{}
+	h, err := New(cfg, nil)
+	require.NoError(t, err)
+	require.NoError(t, h.Harvest(t.Context()))
+	assert.Equal(t, "Jane Doe", cfg.Name.Get())
+}
+
+func TestNew_RegisteredWatcherFactory(t *testing.T) {
+	var watchers []*stubWatcher
+	require.NoError(t, RegisterSource(Source{
+		Name:       testSource,
+		Precedence: 450,
+		WatcherFactory: func(*config.Config) (monitor.Watcher, error) {
+			w := &stubWatcher{value: "Jane Doe"}
+			watchers = append(watchers, w)
+			return w, nil
+		},
+	}))
+	t.Cleanup(func() {
+		require.NoError(t, RegisterSource(Source{Name: testSource, Precedence: 450}))
+	})
+
+	// every harvester gets its own watcher
+	for range 2 {
+		cfg := &testConfigRegisteredSource{}
+		ch := make(chan config.ChangeNotification, 1)
+		h, err := New(cfg, ch, WithSeedGetter(testSource, &stubGetter{value: "Mary Doe"}))
+		require.NoError(t, err)
+		require.NoError(t, h.Harvest(t.Context()))
+		assert.Equal(t, "Mary Doe", (<-ch).Current)
+		assert.Equal(t, "Jane Doe", (<-ch).Current)
+	}
+	require.Len(t, watchers, 2)
+	assert.NotSame(t, watchers[0], watchers[1])
+
+	require.NoError(t, RegisterSource(Source{
+		Name:       testSource,
+		Precedence: 450,
+		WatcherFactory: func(*config.Config) (monitor.Watcher, error) {
+			return nil, errors.New("keys are empty")
+		},
+	}))
+	_, err := New(&testConfigRegisteredSource{}, nil)
+	require.EqualError(t, err, "failed to create the watcher of source harvester-test-kv: keys are empty")
+}
+
+func TestWithSeedGetter(t *testing.T) {
+	require.NoError(t, RegisterSource(Source{Name: testSource, Precedence: 450}))
+
+	t.Run("success", func(t *testing.T) {
+		cfg := &testConfigRegisteredSource{}
+		h, err := New(cfg, nil, WithSeedGetter(testSource, &stubGetter{value: "Mary Doe"}))
+		require.NoError(t, err)
+		require.NoError(t, h.Harvest(t.Context()))
+		assert.Equal(t, "Mary Doe", cfg.Name.Get())
+	})
+
+	t.Run("source not registered", func(t *testing.T) {
+		_, err := New(&testConfigRegisteredSource{}, nil, WithSeedGetter("unknown", &stubGetter{}))
+		require.EqualError(t, err, "source unknown is not registered")
+	})
+
+	t.Run("getter is nil", func(t *testing.T) {
+		_, err := New(&testConfigRegisteredSource{}, nil, WithSeedGetter(testSource, nil))
+		require.EqualError(t, err, "getter is nil")
+	})
+}
+
+func TestWithWatcher(t *testing.T) {
+	require.NoError(t, RegisterSource(Source{Name: testSource, Precedence: 450}))
+
+	t.Run("success", func(t *testing.T) {
+		cfg := &testConfigRegisteredSource{}
+		ch := make(chan config.ChangeNotification, 1)
+		h, err := New(cfg, ch, WithSeedGetter(testSource, &stubGetter{value: "Mary Doe"}),
+			WithWatcher(&stubWatcher{value: "Jane Doe"}))
+		require.NoError(t, err)
+		require.NoError(t, h.Harvest(t.Context()))
+		assert.Equal(t, "Mary Doe", (<-ch).Current)
+		assert.Equal(t, "Jane Doe", (<-ch).Current)
+		assert.Equal(t, "Jane Doe", cfg.Name.Get())
+	})
+
+	t.Run("watcher is nil", func(t *testing.T) {
+		_, err := New(&testConfigRegisteredSource{}, nil, WithWatcher(nil))
+		require.EqualError(t, err, "watcher is nil")
+	})
+}
+
+func TestWithSeedGetterFunc(t *testing.T) {
+	require.NoError(t, RegisterSource(Source{Name: testSource, Precedence: 450}))
+
+	cfg := &testConfigRegisteredSource{}
+	h, err := New(cfg, nil, WithSeedGetterFunc(testSource, func() (seed.Getter, error) {
+		return &stubGetter{value: "Mary Doe"}, nil
+	}))
+	require.NoError(t, err)
+	require.NoError(t, h.Harvest(t.Context()))
+	assert.Equal(t, "Mary Doe", cfg.Name.Get())
+
+	_, err = New(&testConfigRegisteredSource{}, nil, WithSeedGetterFunc(testSource, nil))
+	require.EqualError(t, err, "getter function is nil")
+	_, err = New(&testConfigRegisteredSource{}, nil, WithSeedGetterFunc(testSource, func() (seed.Getter, error) {
+		return nil, errors.New("client is nil")
+	}))
+	require.EqualError(t, err, "client is nil")
+}
+
+func TestWithWatcherFactory(t *testing.T) {
+	require.NoError(t, RegisterSource(Source{Name: testSource, Precedence: 450}))
+
+	var fields int
+	_, err := New(&testConfigRegisteredSource{}, nil, WithWatcherFactory(func(cfg *config.Config) (monitor.Watcher, error) {
+		fields = len(cfg.Fields)
+		return &stubWatcher{}, nil
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, fields)
+
+	_, err = New(&testConfigRegisteredSource{}, nil, WithWatcherFactory(nil))
+	require.EqualError(t, err, "watcher factory is nil")
+	_, err = New(&testConfigRegisteredSource{}, nil, WithWatcherFactory(func(*config.Config) (monitor.Watcher, error) {
+		return nil, errors.New("keys are empty")
+	}))
+	require.EqualError(t, err, "keys are empty")
+}
+
+func TestWithWatcherFactory_Vault(t *testing.T) {
+	client, err := vaultapi.NewClient(vaultapi.DefaultConfig())
+	require.NoError(t, err)
+	getter, err := seedvault.New(client)
+	require.NoError(t, err)
+
+	h, err := New(&testConfigVault{}, nil, WithSeedGetter(config.SourceVault, getter),
+		WithWatcherFactory(vaultmon.Factory(client, time.Minute, vaultmon.WithSeeder(getter))))
+	require.NoError(t, err)
+	assert.NotNil(t, h)
+
+	_, err = New(&testConfigVault{}, nil, WithWatcherFactory(vaultmon.Factory(client, 0)))
+	require.EqualError(t, err, "vault monitor poll interval should be a positive number")
+}
+
+type testConfigVault struct {
+	Password sync.Secret `vault:"secret/data/app#password"`
+}
+
+type testConfigRegisteredSource struct {
+	Name sync.String `harvester-test-kv:"name"`
+}
+
+type stubGetter struct {
+	value string
+}
+
+func (s *stubGetter) Get(string) (*string, uint64, error) {
+	return &s.value, 0, nil
+}
+
+type stubWatcher struct {
+	value string
+}
+
+func (s *stubWatcher) Watch(_ context.Context, ch chan<- []*change.Change) error {
+	go func() {
+		ch <- []*change.Change{change.New(testSource, "name", s.value, 1)}
+	}()
+	return nil
+}
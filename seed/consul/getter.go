@@ -2,19 +2,29 @@
 package consul
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"path"
+	"strings"
 	"time"
 
+	"github.com/beatlabs/harvester/seed"
 	"github.com/hashicorp/consul/api"
 )
 
+const defaultTimeout = 60 * time.Second
+
 // Getter implementation of the getter interface.
 type Getter struct {
-	kv           *api.KV
-	dc           string
-	token        string
-	folderPrefix string
+	kv                *api.KV
+	dc                string
+	token             string
+	folderPrefix      string
+	timeout           time.Duration
+	allowStale        bool
+	requireConsistent bool
+	staleRetry        bool
 }
 
 // New constructor. Timeout is set to 60s when 0 is provided.
@@ -28,35 +38,181 @@ func NewWithFolderPrefix(addr, dc, token, folderPrefix string, timeout time.Dura
 		return nil, errors.New("address is empty")
 	}
 	if timeout == 0 {
-		timeout = 60 * time.Second
+		timeout = defaultTimeout
 	}
 
 	config := api.DefaultConfig()
 	config.Address = addr
 
-	var err error
-	config.HttpClient, err = api.NewHttpClient(config.Transport, config.TLSConfig)
+	consul, err := api.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+	g, err := NewWithClient(consul, folderPrefix, WithTimeout(timeout))
 	if err != nil {
 		return nil, err
 	}
-	config.HttpClient.Timeout = timeout
+	g.dc = dc
+	g.token = token
+	return g, nil
+}
 
-	consul, err := api.NewClient(config)
+// NewWithConfig constructor, which creates a client with the config, e.g. api.DefaultConfig, which reads the
+// standard CONSUL_* environment variables. The datacenter, token, namespace, partition and TLS settings of the config
+// are used for all requests.
+func NewWithConfig(cfg *api.Config, folderPrefix string, oo ...Option) (*Getter, error) {
+	if cfg == nil {
+		return nil, errors.New("config is nil")
+	}
+	consul, err := api.NewClient(cfg)
 	if err != nil {
 		return nil, err
 	}
-	return &Getter{kv: consul.KV(), dc: dc, token: token, folderPrefix: folderPrefix}, nil
+	return NewWithClient(consul, folderPrefix, oo...)
+}
+
+// NewWithClient constructor, which allows sharing a configured client, e.g. with the Consul monitor.
+// Each request times out after 60s, unless set otherwise with WithTimeout. The HTTP client of a client shared with
+// the monitor should not have a timeout, since it would also cut the blocking queries of the monitor short.
+func NewWithClient(client *api.Client, folderPrefix string, oo ...Option) (*Getter, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	g := &Getter{kv: client.KV(), folderPrefix: folderPrefix, timeout: defaultTimeout}
+	for _, o := range oo {
+		err := o(g)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if g.allowStale && g.requireConsistent {
+		return nil, errors.New("allow stale conflicts with require consistent")
+	}
+	return g, nil
+}
+
+// Option for configuring the getter.
+type Option func(*Getter) error
+
+// WithAllowStale allows any Consul server to serve the reads, which keeps seeding working without a leader,
+// at the cost of possibly stale values.
+func WithAllowStale() Option {
+	return func(g *Getter) error {
+		g.allowStale = true
+		return nil
+	}
+}
+
+// WithRequireConsistent makes the leader verify its leadership before serving the reads.
+func WithRequireConsistent() Option {
+	return func(g *Getter) error {
+		g.requireConsistent = true
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of each request, which is applied through the context of the request.
+func WithTimeout(timeout time.Duration) Option {
+	return func(g *Getter) error {
+		if timeout <= 0 {
+			return errors.New("timeout should be a positive number")
+		}
+		g.timeout = timeout
+		return nil
+	}
+}
+
+// WithStaleRetry retries a failed read with a stale read, e.g. when the read fails during a leader election.
+func WithStaleRetry() Option {
+	return func(g *Getter) error {
+		g.staleRetry = true
+		return nil
+	}
 }
 
 // Get the specific key value from consul.
 func (g *Getter) Get(key string) (*string, uint64, error) {
-	pair, _, err := g.kv.Get(path.Join(g.folderPrefix, key), &api.QueryOptions{Datacenter: g.dc, Token: g.token})
-	if err != nil {
+	v, ok, err := g.GetValue(key)
+	if err != nil || !ok {
 		return nil, 0, err
 	}
+	return &v.Value, v.Version, nil
+}
+
+// GetValue gets the value of the key, which is marked stale if it was read from a follower.
+// It returns false when the key does not exist.
+func (g *Getter) GetValue(key string) (seed.Value, bool, error) {
+	var pair *api.KVPair
+	stale, err := g.read(func(q *api.QueryOptions) (*api.QueryMeta, error) {
+		var meta *api.QueryMeta
+		var err error
+		pair, meta, err = g.kv.Get(path.Join(g.folderPrefix, key), q)
+		return meta, err
+	})
+	if err != nil {
+		return seed.Value{}, false, err
+	}
 	if pair == nil {
-		return nil, 0, nil
+		return seed.Value{}, false, nil
+	}
+	return seed.Value{Value: string(pair.Value), Version: pair.ModifyIndex, Stale: stale}, true, nil
+}
+
+// GetPrefix gets the values of all keys of the prefix with a single recursive list. The keys are returned with the
+// prefix, like the keys passed to Get.
+func (g *Getter) GetPrefix(prefix string) (map[string]seed.Value, error) {
+	full := path.Join(g.folderPrefix, prefix)
+	if strings.HasSuffix(prefix, "/") {
+		full += "/"
 	}
-	val := string(pair.Value)
-	return &val, pair.ModifyIndex, nil
+	var pairs api.KVPairs
+	stale, err := g.read(func(q *api.QueryOptions) (*api.QueryMeta, error) {
+		var meta *api.QueryMeta
+		var err error
+		pairs, meta, err = g.kv.List(full, q)
+		return meta, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]seed.Value, len(pairs))
+	for _, pair := range pairs {
+		values[prefix+strings.TrimPrefix(pair.Key, full)] = seed.Value{
+			Value:   string(pair.Value),
+			Version: pair.ModifyIndex,
+			Stale:   stale,
+		}
+	}
+	return values, nil
+}
+
+// read with the consistency mode of the getter, retrying with a stale read if enabled. Each request times out
+// after the timeout of the getter. It returns true if the result might be stale, which is the case when a follower
+// served a stale read.
+func (g *Getter) read(fn func(q *api.QueryOptions) (*api.QueryMeta, error)) (bool, error) {
+	q := &api.QueryOptions{
+		Datacenter:        g.dc,
+		Token:             g.token,
+		AllowStale:        g.allowStale,
+		RequireConsistent: g.requireConsistent,
+	}
+	meta, err := g.request(q, fn)
+	if err != nil && g.staleRetry && !q.AllowStale {
+		slog.Warn("consul read failed, retrying with a stale read", "err", err)
+		q.AllowStale = true
+		q.RequireConsistent = false
+		meta, err = g.request(q, fn)
+	}
+	if err != nil {
+		return false, err
+	}
+	return q.AllowStale && meta != nil && (!meta.KnownLeader || meta.LastContact > 0), nil
+}
+
+// request calls the function with the query options and a context which times out after the timeout of the getter.
+func (g *Getter) request(q *api.QueryOptions, fn func(q *api.QueryOptions) (*api.QueryMeta, error),
+) (*api.QueryMeta, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+	return fn(q.WithContext(ctx))
 }
@@ -1,9 +1,16 @@
 package consul
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/beatlabs/harvester/seed"
+	"github.com/hashicorp/consul/api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -34,3 +41,169 @@ func TestNew(t *testing.T) {
 		})
 	}
 }
+
+func TestNewWithClient(t *testing.T) {
+	got, err := NewWithClient(nil, "")
+	require.EqualError(t, err, "client is nil")
+	assert.Nil(t, got)
+
+	got, err = NewWithConfig(nil, "")
+	require.EqualError(t, err, "config is nil")
+	assert.Nil(t, got)
+
+	cfg := api.DefaultConfig()
+	cfg.Namespace = "team"
+	got, err = NewWithConfig(cfg, "folder")
+	require.NoError(t, err)
+	assert.Equal(t, "folder", got.folderPrefix)
+}
+
+func TestGetter_GetPrefix(t *testing.T) {
+	pairs := api.KVPairs{
+		{Key: "team/svc/db/Host", Value: []byte("db.local"), ModifyIndex: 3},
+		{Key: "team/svc/db/replica/Port", Value: []byte("5433"), ModifyIndex: 4},
+		{Key: "team/other/Host", Value: []byte("other.local"), ModifyIndex: 5},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Contains(t, r.URL.Query(), "recurse")
+		prefix := strings.TrimPrefix(r.URL.Path, "/v1/kv/")
+		var found api.KVPairs
+		for _, p := range pairs {
+			if strings.HasPrefix(p.Key, prefix) {
+				found = append(found, p)
+			}
+		}
+		if len(found) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(found))
+	}))
+	defer srv.Close()
+
+	gtr, err := NewWithFolderPrefix(srv.URL, "dc", "token", "team", 0)
+	require.NoError(t, err)
+
+	got, err := gtr.GetPrefix("svc/db/")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]seed.Value{
+		"svc/db/Host":         {Value: "db.local", Version: 3},
+		"svc/db/replica/Port": {Value: "5433", Version: 4},
+	}, got)
+
+	got, err = gtr.GetPrefix("svc/missing/")
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestNewWithClient_Options(t *testing.T) {
+	client, err := api.NewClient(api.DefaultConfig())
+	require.NoError(t, err)
+	tests := map[string]struct {
+		oo          []Option
+		expectedErr string
+	}{
+		"stale":                {oo: []Option{WithAllowStale(), WithStaleRetry()}},
+		"consistent":           {oo: []Option{WithRequireConsistent(), WithStaleRetry()}},
+		"timeout":              {oo: []Option{WithTimeout(time.Minute)}},
+		"stale and consistent": {oo: []Option{WithAllowStale(), WithRequireConsistent()}, expectedErr: "allow stale conflicts with require consistent"},
+		"zero timeout":         {oo: []Option{WithTimeout(0)}, expectedErr: "timeout should be a positive number"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := NewWithClient(client, "", tt.oo...)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, got)
+			}
+		})
+	}
+}
+
+func TestGetter_GetValue_Consistency(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !r.URL.Query().Has("stale") {
+			http.Error(w, "No cluster leader", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("X-Consul-KnownLeader", "false")
+		w.Header().Set("X-Consul-LastContact", "150")
+		assert.NoError(t, json.NewEncoder(w).Encode(api.KVPairs{
+			{Key: "svc/db/Host", Value: []byte("db.local"), ModifyIndex: 3},
+		}))
+	}))
+	defer srv.Close()
+	newGetter := func(t *testing.T, oo ...Option) *Getter {
+		cfg := api.DefaultConfig()
+		cfg.Address = srv.URL
+		gtr, err := NewWithConfig(cfg, "", oo...)
+		require.NoError(t, err)
+		return gtr
+	}
+
+	t.Run("consistent read fails", func(t *testing.T) {
+		_, _, err := newGetter(t, WithRequireConsistent()).GetValue("svc/db/Host")
+		require.ErrorContains(t, err, "No cluster leader")
+	})
+
+	t.Run("stale retry", func(t *testing.T) {
+		got, ok, err := newGetter(t, WithRequireConsistent(), WithStaleRetry()).GetValue("svc/db/Host")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, seed.Value{Value: "db.local", Version: 3, Stale: true}, got)
+	})
+
+	t.Run("stale read", func(t *testing.T) {
+		got, ok, err := newGetter(t, WithAllowStale()).GetValue("svc/db/Host")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.True(t, got.Stale)
+	})
+
+	t.Run("stale retry of prefix", func(t *testing.T) {
+		got, err := newGetter(t, WithStaleRetry()).GetPrefix("svc/db/")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]seed.Value{"svc/db/Host": {Value: "db.local", Version: 3, Stale: true}}, got)
+	})
+}
+
+func TestGetter_GetValue_Leader(t *testing.T) {
+	queries := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.RawQuery
+		w.Header().Set("X-Consul-KnownLeader", "true")
+		w.Header().Set("X-Consul-LastContact", "0")
+		assert.NoError(t, json.NewEncoder(w).Encode(api.KVPairs{
+			{Key: "svc/db/Host", Value: []byte("db.local"), ModifyIndex: 3},
+		}))
+	}))
+	defer srv.Close()
+	cfg := api.DefaultConfig()
+	cfg.Address = srv.URL
+	gtr, err := NewWithConfig(cfg, "", WithAllowStale())
+	require.NoError(t, err)
+
+	got, ok, err := gtr.GetValue("svc/db/Host")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.False(t, got.Stale)
+	query := <-queries
+	assert.Contains(t, query, "stale")
+}
+
+func TestGetter_GetValue_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	cfg := api.DefaultConfig()
+	cfg.Address = srv.URL
+	gtr, err := NewWithConfig(cfg, "", WithTimeout(10*time.Millisecond))
+	require.NoError(t, err)
+
+	_, _, err = gtr.GetValue("svc/db/Host")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,65 @@
+// Package etcd handles seeding capabilities with etcd.
+package etcd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/beatlabs/harvester/seed"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// Getter implementation of the getter interface.
+type Getter struct {
+	kv      clientv3.KV
+	timeout time.Duration
+}
+
+// New constructor. Timeout is set to 60s when 0 is provided.
+func New(kv clientv3.KV, timeout time.Duration) (*Getter, error) {
+	if kv == nil {
+		return nil, errors.New("kv is nil")
+	}
+	if timeout == 0 {
+		timeout = 60 * time.Second
+	}
+	return &Getter{kv: kv, timeout: timeout}, nil
+}
+
+// Get the specific key value from etcd. The version is the mod revision of the key.
+// Returns (nil, 0, nil) when the key does not exist.
+func (g *Getter) Get(key string) (*string, uint64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+	resp, err := g.kv.Get(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	kvs := resp.Kvs
+	if len(kvs) == 0 {
+		return nil, 0, nil
+	}
+	kv := kvs[0]
+	val := string(kv.Value)
+	return &val, uint64(kv.ModRevision), nil //nolint:gosec // revisions are positive
+}
+
+// GetPrefix gets the values of all keys of the prefix with a single range request. The version of each value is the
+// mod revision of its key.
+func (g *Getter) GetPrefix(prefix string) (map[string]seed.Value, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+	defer cancel()
+	resp, err := g.kv.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]seed.Value, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		values[string(kv.Key)] = seed.Value{
+			Value:   string(kv.Value),
+			Version: uint64(kv.ModRevision), //nolint:gosec // revisions are positive
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,91 @@
+package etcd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/beatlabs/harvester/seed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func TestNew(t *testing.T) {
+	got, err := New(nil, 0)
+	require.EqualError(t, err, "kv is nil")
+	assert.Nil(t, got)
+
+	got, err = New(&fakeKV{}, 0)
+	require.NoError(t, err)
+	assert.NotNil(t, got)
+}
+
+func TestGetter_Get(t *testing.T) {
+	kv := &fakeKV{kvs: map[string]*mvccpb.KeyValue{
+		"/config/age": {Key: []byte("/config/age"), Value: []byte("18"), ModRevision: 42},
+	}}
+	g, err := New(kv, 0)
+	require.NoError(t, err)
+
+	got, version, err := g.Get("/config/age")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "18", *got)
+	assert.Equal(t, uint64(42), version)
+
+	got, version, err = g.Get("/config/missing")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	assert.Equal(t, uint64(0), version)
+
+	kv.err = errors.New("unavailable")
+	got, version, err = g.Get("/config/age")
+	require.EqualError(t, err, "unavailable")
+	assert.Nil(t, got)
+	assert.Equal(t, uint64(0), version)
+}
+
+func TestGetter_GetPrefix(t *testing.T) {
+	kv := &fakeKV{kvs: map[string]*mvccpb.KeyValue{
+		"/config/db/host": {Key: []byte("/config/db/host"), Value: []byte("db.local"), ModRevision: 42},
+		"/config/db/port": {Key: []byte("/config/db/port"), Value: []byte("5432"), ModRevision: 43},
+		"/config/age":     {Key: []byte("/config/age"), Value: []byte("18"), ModRevision: 44},
+	}}
+	g, err := New(kv, 0)
+	require.NoError(t, err)
+
+	got, err := g.GetPrefix("/config/db/")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]seed.Value{
+		"/config/db/host": {Value: "db.local", Version: 42},
+		"/config/db/port": {Value: "5432", Version: 43},
+	}, got)
+
+	kv.err = errors.New("unavailable")
+	got, err = g.GetPrefix("/config/db/")
+	require.EqualError(t, err, "unavailable")
+	assert.Nil(t, got)
+}
+
+type fakeKV struct {
+	clientv3.KV
+	kvs map[string]*mvccpb.KeyValue
+	err error
+}
+
+func (f *fakeKV) Get(_ context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	prefix := clientv3.OpGet(key, opts...).RangeBytes() != nil
+	resp := &clientv3.GetResponse{}
+	for k, kv := range f.kvs {
+		if k == key || (prefix && strings.HasPrefix(k, key)) {
+			resp.Kvs = append(resp.Kvs, kv)
+		}
+	}
+	return resp, nil
+}
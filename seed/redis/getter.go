@@ -5,26 +5,63 @@ import (
 	"context"
 	"errors"
 
+	internalredis "github.com/beatlabs/harvester/internal/redis"
 	"github.com/redis/go-redis/v9"
 )
 
 // Getter definition.
 type Getter struct {
 	client redis.UniversalClient
+	types  internalredis.Types
 }
 
-// New creates a getter.
-func New(client redis.UniversalClient) (*Getter, error) {
+// Option for configuring the getter.
+type Option func(*Getter)
+
+// WithHashKeys sets the keys which are fields of hashes, of the form key#field, e.g. the keys of the redis_hash tag.
+func WithHashKeys(keys ...string) Option {
+	return func(g *Getter) {
+		g.types.Add(internalredis.TypeHash, keys...)
+	}
+}
+
+// WithJSONKeys sets the keys which are paths of RedisJSON documents, of the form key#path, e.g. the keys of the
+// redis_json tag.
+func WithJSONKeys(keys ...string) Option {
+	return func(g *Getter) {
+		g.types.Add(internalredis.TypeJSON, keys...)
+	}
+}
+
+// New creates a getter. The keys are string keys, unless they are set with WithHashKeys or WithJSONKeys.
+func New(client redis.UniversalClient, oo ...Option) (*Getter, error) {
 	if client == nil {
 		return nil, errors.New("client is nil")
 	}
-	return &Getter{client: client}, nil
+	g := &Getter{client: client, types: make(internalredis.Types)}
+	for _, o := range oo {
+		o(g)
+	}
+	return g, nil
 }
 
-// Get value by key. Returns (nil, 0, nil) when the key does not exist,
-// matching the Getter interface contract.
+// Get value by key, which is a string key, a hash field or a RedisJSON path, see WithHashKeys and WithJSONKeys.
+// Returns (nil, 0, nil) when the key does not exist, matching the Getter interface contract.
 func (g *Getter) Get(key string) (*string, uint64, error) {
-	val, err := g.client.Get(context.Background(), key).Result()
+	k, err := g.types.Parse(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctx := context.Background()
+	var val string
+	switch k.Type {
+	case internalredis.TypeString:
+		val, err = g.client.Get(ctx, k.Name).Result()
+	case internalredis.TypeHash:
+		val, err = g.client.HGet(ctx, k.Name, k.Field).Result()
+	case internalredis.TypeJSON:
+		return g.getJSON(ctx, k)
+	}
 	if err != nil {
 		if errors.Is(err, redis.Nil) {
 			return nil, 0, nil
@@ -33,3 +70,18 @@ func (g *Getter) Get(key string) (*string, uint64, error) {
 	}
 	return &val, 0, nil
 }
+
+func (g *Getter) getJSON(ctx context.Context, k internalredis.Key) (*string, uint64, error) {
+	reply, err := g.client.JSONGet(ctx, k.Name, k.Field).Result()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	val, ok, err := internalredis.JSONValue(reply, k.Field)
+	if err != nil || !ok {
+		return nil, 0, err
+	}
+	return &val, 0, nil
+}
@@ -44,3 +44,26 @@ func TestGetter_Get_MissingKey(t *testing.T) {
 	assert.Nil(t, got)
 	assert.Equal(t, uint64(0), version)
 }
+
+func TestGetter_Get_Hash(t *testing.T) {
+	client := redis.NewClient(&redis.Options{})
+
+	const key = "svc-config"
+
+	_, err := client.HSet(context.Background(), key, "age", "18").Result()
+	require.NoError(t, err)
+	defer func() {
+		_, err := client.Del(context.Background(), key).Result()
+		require.NoError(t, err)
+	}()
+
+	gtr, err := New(client, WithHashKeys("svc-config#age", "svc-config#name"))
+	require.NoError(t, err)
+	got, _, err := gtr.Get("svc-config#age")
+	require.NoError(t, err)
+	assert.Equal(t, "18", *got)
+
+	got, _, err = gtr.Get("svc-config#name")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
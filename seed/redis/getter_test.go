@@ -45,6 +45,41 @@ func (s *stubRedisClient) Get(_ context.Context, _ string) *redis.StringCmd {
 	return redis.NewStringResult(s.result, s.err)
 }
 
+// stubTypedRedisClient serves hashes and RedisJSON replies from memory.
+type stubTypedRedisClient struct {
+	redis.UniversalClient
+	strs   map[string]string
+	hashes map[string]map[string]string
+	docs   map[string]map[string]string
+}
+
+func (s *stubTypedRedisClient) Get(_ context.Context, key string) *redis.StringCmd {
+	v, ok := s.strs[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(v, nil)
+}
+
+func (s *stubTypedRedisClient) HGet(_ context.Context, key, field string) *redis.StringCmd {
+	v, ok := s.hashes[key][field]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(v, nil)
+}
+
+func (s *stubTypedRedisClient) JSONGet(_ context.Context, key string, paths ...string) *redis.JSONCmd {
+	cmd := &redis.JSONCmd{}
+	v, ok := s.docs[key][paths[0]]
+	if !ok {
+		cmd.SetErr(redis.Nil)
+		return cmd
+	}
+	cmd.SetVal(v)
+	return cmd
+}
+
 func TestGetter_Get_Unit(t *testing.T) {
 	sentinel := errors.New("connection refused")
 	tests := map[string]struct {
@@ -81,4 +116,53 @@ func TestGetter_Get_Unit(t *testing.T) {
 	}
 }
 
+func TestGetter_Get_Typed(t *testing.T) {
+	stub := &stubTypedRedisClient{
+		strs:   map[string]string{"hash:svc-config#age": "21"},
+		hashes: map[string]map[string]string{"svc-config": {"age": "18"}},
+		docs: map[string]map[string]string{"svc-doc": {
+			"$.name":    `["John"]`,
+			"$.age":     `[18]`,
+			"$.missing": `[]`,
+			".name":     `"John"`,
+			"$.broken":  `{`,
+		}},
+	}
+	tests := map[string]struct {
+		key         string
+		wantVal     *string
+		expectedErr string
+	}{
+		"hash field":             {key: "svc-config#age", wantVal: strPtr("18")},
+		"hash field missing":     {key: "svc-config#name"},
+		"hash missing":           {key: "other#age"},
+		"hash key invalid":       {key: "svc-config", expectedErr: `redis hash key "svc-config" should have the form key#field`},
+		"json string":            {key: "svc-doc#$.name", wantVal: strPtr("John")},
+		"json number":            {key: "svc-doc#$.age", wantVal: strPtr("18")},
+		"json legacy path":       {key: "svc-doc#.name", wantVal: strPtr("John")},
+		"json path matches none": {key: "svc-doc#$.missing"},
+		"json document missing":  {key: "other#$.name"},
+		"json reply invalid":     {key: "svc-doc#$.broken", expectedErr: "redis json reply of path $.broken is not an array: unexpected end of JSON input"},
+		"json key invalid":       {key: "#$.name", expectedErr: `redis json key "#$.name" should have the form key#field`},
+		"string key":             {key: "hash:svc-config#age", wantVal: strPtr("21")},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			g, err := New(stub,
+				WithHashKeys("svc-config#age", "svc-config#name", "other#age", "svc-config"),
+				WithJSONKeys("svc-doc#$.name", "svc-doc#$.age", "svc-doc#.name", "svc-doc#$.missing", "other#$.name",
+					"svc-doc#$.broken", "#$.name"))
+			require.NoError(t, err)
+			val, version, err := g.Get(tt.key)
+			if tt.expectedErr != "" {
+				require.EqualError(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantVal, val)
+			assert.Equal(t, uint64(0), version)
+		})
+	}
+}
+
 func strPtr(s string) *string { return &s }
@@ -8,9 +8,12 @@ import (
 	"io"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/metrics"
 )
 
 // Getter interface for fetching a value for a specific key.
@@ -18,6 +21,34 @@ type Getter interface {
 	Get(key string) (*string, uint64, error)
 }
 
+// PrefixGetter is implemented by getters which can fetch all values of a key prefix at once, e.g. the Consul getter.
+// It is used instead of Get for the fields which are bound to a prefix, see config.Field.Prefix, which fetches all
+// of them with a single call.
+type PrefixGetter interface {
+	GetPrefix(prefix string) (map[string]Value, error)
+}
+
+// ValueGetter is implemented by getters which describe the values they return, e.g. the Consul getter, which
+// marks stale values. It is used instead of Get and returns false when the key does not exist.
+type ValueGetter interface {
+	GetValue(key string) (Value, bool, error)
+}
+
+// CachingGetter is implemented by getters which cache their reads for a seed pass, e.g. the Vault getter, which reads
+// each secret once, so that all fields of a dynamic secret come from the same lease. ResetCache is called at the start
+// of each pass.
+type CachingGetter interface {
+	ResetCache()
+}
+
+// Value of a key and its version.
+type Value struct {
+	Value   string
+	Version uint64
+	// Stale is true if the value might be outdated, e.g. because it was read from a Consul follower.
+	Stale bool
+}
+
 // Param parameters for setting a getter for a specific source.
 type Param struct {
 	src    config.Source
@@ -34,7 +65,61 @@ func NewParam(src config.Source, getter Getter) (*Param, error) {
 
 // Seeder handles initializing the configuration value.
 type Seeder struct {
-	getters map[config.Source]Getter
+	getters    map[config.Source]Getter
+	precedence []config.Source
+	metrics    metrics.Recorder
+	listings   map[listingKey]*listing
+	// keepPrecedence keeps the precedence of the seeded source while monitoring.
+	keepPrecedence bool
+}
+
+type listingKey struct {
+	src    config.Source
+	prefix string
+}
+
+// listing of the values of a key prefix, which is fetched once per seeding.
+type listing struct {
+	values map[string]Value
+	err    error
+}
+
+// Option for configuring the seeder.
+type Option func(*Seeder) error
+
+// WithPrecedence sets the order in which the sources are applied during seeding.
+// The given sources are applied last and in the given order, which means that the last one overrides all others.
+// Sources which are not given are applied first, in the order of their registered precedence.
+func WithPrecedence(ss ...config.Source) Option {
+	return func(s *Seeder) error {
+		_, err := config.SourceOrder(nil, ss...)
+		if err != nil {
+			return err
+		}
+		s.precedence = ss
+		return nil
+	}
+}
+
+// WithPrecedenceWhileMonitoring keeps the precedence of the sources while monitoring, see config.Field.SetSeedOrder.
+// Once a field is seeded, changes from sources which come before the source of the seeded value are dropped. Without
+// it, every change of a monitored source is applied, whichever source seeded the field.
+func WithPrecedenceWhileMonitoring() Option {
+	return func(s *Seeder) error {
+		s.keepPrecedence = true
+		return nil
+	}
+}
+
+// WithMetrics sets the recorder of the seeding metrics.
+func WithMetrics(r metrics.Recorder) Option {
+	return func(s *Seeder) error {
+		if r == nil {
+			return errors.New("metrics recorder is nil")
+		}
+		s.metrics = r
+		return nil
+	}
 }
 
 // New constructor.
@@ -43,63 +128,97 @@ func New(pp ...Param) *Seeder {
 	for _, p := range pp {
 		gg[p.src] = p.getter
 	}
-	return &Seeder{getters: gg}
+	return &Seeder{getters: gg, metrics: metrics.Noop{}}
+}
+
+// NewWithOptions constructor.
+func NewWithOptions(pp []Param, oo ...Option) (*Seeder, error) {
+	s := New(pp...)
+	for _, o := range oo {
+		err := o(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
 }
 
-type fieldMap map[*config.Field]bool
+// fieldMap holds the source which seeded each field, which is empty if the field has not been seeded.
+type fieldMap map[*config.Field]config.Source
 
 type flagInfo struct {
 	key   string
-	field *config.Field
 	value *string
+	set   bool
 }
 
+type flagMap map[*config.Field]*flagInfo
+
 // Seed the provided config with values for their sources.
+// Sources are applied in the order of their registered precedence, see config.RegisterSource, unless the order
+// is overridden by the seeder or by the precedence tag of a field.
 func (s *Seeder) Seed(cfg *config.Config) error {
+	base, err := config.SourceOrder(config.RegisteredSources(), s.precedence...)
+	if err != nil {
+		return err
+	}
 	seeded := make(fieldMap, len(cfg.Fields))
-	flagSet := flag.NewFlagSet("Harvester flags", flag.ContinueOnError)
-
-	var flagInfos []*flagInfo
-	for _, f := range cfg.Fields {
-		seeded[f] = false
-
-		err := processSeedField(f, seeded)
-		if err != nil {
-			return err
+	s.listings = make(map[listingKey]*listing)
+	for _, g := range s.getters {
+		if cg, ok := g.(CachingGetter); ok {
+			cg.ResetCache()
 		}
-
-		err = processEnvField(f, seeded)
-		if err != nil {
-			return err
+	}
+	flags := processFlags(cfg.Fields)
+	durations := make(map[config.Source]time.Duration, len(base))
+	defer func() {
+		for _, src := range base {
+			s.metrics.SeedDuration(src, durations[src])
 		}
+	}()
 
-		fi, ok := processFlagField(f, flagSet)
-		if ok {
-			flagInfos = append(flagInfos, fi)
-		}
+	for _, f := range cfg.Fields {
+		seeded[f] = ""
 
-		err = processFileField(f, seeded)
+		ss, err := config.SourceOrder(base, f.Precedence()...)
 		if err != nil {
 			return err
 		}
-
-		err = s.processConsulField(f, seeded)
-		if err != nil {
-			return err
+		if s.keepPrecedence {
+			f.SetSeedOrder(ss)
 		}
+		f.ResetAttempts()
 
-		err = s.processRedisField(f, seeded)
-		if err != nil {
-			return err
+		for _, src := range ss {
+			start := time.Now()
+			err := s.processField(src, f, flags, seeded)
+			durations[src] += time.Since(start)
+			if err != nil {
+				var perr *config.ParseError
+				if errors.As(err, &perr) {
+					s.metrics.ParseFailure(f.Name(), src)
+				}
+				return err
+			}
 		}
 	}
 
-	err := processFlags(flagInfos, flagSet, seeded)
-	if err != nil {
-		return err
-	}
+	return evaluateSeedMap(cfg.Fields, seeded)
+}
 
-	return evaluateSeedMap(seeded)
+func (s *Seeder) processField(src config.Source, f *config.Field, flags flagMap, seedMap fieldMap) error {
+	switch src {
+	case config.SourceSeed:
+		return processSeedField(f, seedMap)
+	case config.SourceEnv:
+		return processEnvField(f, seedMap)
+	case config.SourceFile:
+		return processFileField(f, seedMap)
+	case config.SourceFlag:
+		return processFlagField(f, flags, seedMap)
+	default:
+		return s.processGetterField(src, f, seedMap)
+	}
 }
 
 func processSeedField(f *config.Field, seedMap fieldMap) error {
@@ -107,12 +226,12 @@ func processSeedField(f *config.Field, seedMap fieldMap) error {
 	if !ok {
 		return nil
 	}
-	err := f.Set(val, 0)
+	err := f.Set(val, 0, config.WithOrigin(config.SourceSeed, ""), config.WithPhase(config.PhaseSeed))
 	if err != nil {
 		return err
 	}
 	slog.Debug("seed applied", "value", f, "name", f.Name())
-	seedMap[f] = true
+	seedMap[f] = config.SourceSeed
 	return nil
 }
 
@@ -123,7 +242,8 @@ func processEnvField(f *config.Field, seedMap fieldMap) error {
 	}
 	val, ok := os.LookupEnv(key)
 	if !ok {
-		if seedMap[f] {
+		f.RecordAttempt(config.SourceEnv, key, "not found")
+		if seedMap[f] != "" {
 			slog.Debug("env var did not exist", "key", key, "name", f.Name())
 		} else {
 			slog.Debug("env var did not exist and no seed value provided", "key", key, "name", f.Name())
@@ -131,7 +251,8 @@ func processEnvField(f *config.Field, seedMap fieldMap) error {
 		return nil
 	}
 	if val == "" {
-		if seedMap[f] {
+		f.RecordAttempt(config.SourceEnv, key, "empty")
+		if seedMap[f] != "" {
 			slog.Debug("env var was empty", "key", key, "name", f.Name())
 		} else {
 			slog.Debug("env var was empty and no seed value provided", "key", key, "name", f.Name())
@@ -139,12 +260,12 @@ func processEnvField(f *config.Field, seedMap fieldMap) error {
 		return nil
 	}
 
-	err := f.Set(val, 0)
+	err := f.Set(val, 0, config.WithOrigin(config.SourceEnv, key), config.WithPhase(config.PhaseSeed))
 	if err != nil {
 		return err
 	}
 	slog.Debug("env var applied", "value", f, "name", f.Name())
-	seedMap[f] = true
+	seedMap[f] = config.SourceEnv
 	return nil
 }
 
@@ -157,112 +278,196 @@ func processFileField(f *config.Field, seedMap fieldMap) error {
 	body, err := os.ReadFile(key)
 	if err != nil {
 		slog.Error("failed to read file", "file", key, "name", f.Name(), "err", err)
+		f.RecordAttempt(config.SourceFile, key, err.Error())
 		return nil
 	}
 
-	err = f.Set(string(body), 0)
+	err = f.Set(string(body), 0, config.WithOrigin(config.SourceFile, key), config.WithPhase(config.PhaseSeed))
 	if err != nil {
 		return err
 	}
 
 	slog.Debug("file based var applied", "value", f, "field", f.Name())
-	seedMap[f] = true
+	seedMap[f] = config.SourceFile
 	return nil
 }
 
-func (s *Seeder) processConsulField(f *config.Field, seedMap fieldMap) error {
-	key, ok := f.Sources()[config.SourceConsul]
+func (s *Seeder) processGetterField(src config.Source, f *config.Field, seedMap fieldMap) error {
+	key, ok := f.Sources()[src]
 	if !ok {
 		return nil
 	}
-	gtr, ok := s.getters[config.SourceConsul]
+	gtr, ok := s.getters[src]
 	if !ok {
-		return errors.New("consul getter required")
+		return fmt.Errorf("%s getter required", src)
 	}
-	value, version, err := gtr.Get(key)
+	if f.HasEntries(src) {
+		return s.processEntryField(src, gtr, f, key, seedMap)
+	}
+	value, ok, err := s.get(src, gtr, f, key)
 	if err != nil {
-		slog.Error("failed to get consul", "key", key, "field", f.Name(), "err", err)
+		slog.Error("failed to get value", "source", src, "key", key, "field", f.Name(), "err", err)
+		s.metrics.SeedError(src)
+		f.RecordAttempt(src, key, err.Error())
 		return nil
 	}
-	if value == nil {
-		slog.Debug("consul key does not exist", "key", key, "field", f.Name())
+	if !ok {
+		slog.Debug("key does not exist", "source", src, "key", key, "field", f.Name())
+		f.RecordAttempt(src, key, "not found")
 		return nil
 	}
-	err = f.Set(*value, version)
+	oo := []config.SetOption{config.WithOrigin(src, key), config.WithPhase(config.PhaseSeed)}
+	if value.Stale {
+		slog.Warn("stale value applied", "source", src, "key", key, "field", f.Name())
+		oo = append(oo, config.WithStale())
+	}
+	err = f.Set(value.Value, value.Version, oo...)
 	if err != nil {
 		return err
 	}
-	slog.Debug("consul value applied", "value", f, "field", f.Name())
-	seedMap[f] = true
+	slog.Debug("value applied", "source", src, "value", f, "field", f.Name())
+	seedMap[f] = src
 	return nil
 }
 
-func (s *Seeder) processRedisField(f *config.Field, seedMap fieldMap) error {
-	key, ok := f.Sources()[config.SourceRedis]
+// processEntryField seeds the entries of the field from the keys of its prefix, which are added to the value seeded
+// by the previous sources.
+func (s *Seeder) processEntryField(src config.Source, gtr Getter, f *config.Field, prefix string,
+	seedMap fieldMap,
+) error {
+	pg, ok := gtr.(PrefixGetter)
 	if !ok {
+		return fmt.Errorf("%s getter does not support prefixes, field %s", src, f.Name())
+	}
+	values, err := s.list(src, pg, f.Prefix(src))
+	if err != nil {
+		slog.Error("failed to get prefix", "source", src, "prefix", prefix, "field", f.Name(), "err", err)
+		s.metrics.SeedError(src)
+		f.RecordAttempt(src, prefix, err.Error())
 		return nil
 	}
-	gtr, ok := s.getters[config.SourceRedis]
+	keys := make([]string, 0)
+	for key := range values {
+		if entry, ok := strings.CutPrefix(key, prefix); ok && entry != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		slog.Debug("prefix has no keys", "source", src, "prefix", prefix, "field", f.Name())
+		f.RecordAttempt(src, prefix, "not found")
+		return nil
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		value := values[key]
+		oo := []config.SetOption{
+			config.WithOrigin(src, key), config.WithPhase(config.PhaseSeed),
+			config.WithEntry(strings.TrimPrefix(key, prefix)),
+		}
+		if value.Stale {
+			slog.Warn("stale value applied", "source", src, "key", key, "field", f.Name())
+			oo = append(oo, config.WithStale())
+		}
+		err = f.Set(value.Value, value.Version, oo...)
+		if err != nil {
+			return err
+		}
+	}
+	slog.Debug("entries applied", "source", src, "entries", len(keys), "field", f.Name())
+	seedMap[f] = src
+	return nil
+}
+
+// get the value of the key, from the listing of the prefix of the field if the getter supports it.
+func (s *Seeder) get(src config.Source, gtr Getter, f *config.Field, key string) (Value, bool, error) {
+	prefix := f.Prefix(src)
+	pg, ok := gtr.(PrefixGetter)
+	if prefix == "" || !ok {
+		return getValue(gtr, key)
+	}
+	values, err := s.list(src, pg, prefix)
+	if err != nil {
+		return Value{}, false, err
+	}
+	v, ok := values[key]
+	return v, ok, nil
+}
+
+// list the values of the prefix, which are fetched once per seeding.
+func (s *Seeder) list(src config.Source, pg PrefixGetter, prefix string) (map[string]Value, error) {
+	lk := listingKey{src: src, prefix: prefix}
+	l, ok := s.listings[lk]
 	if !ok {
-		return errors.New("redis getter required")
+		values, err := pg.GetPrefix(prefix)
+		l = &listing{values: values, err: err}
+		s.listings[lk] = l
+	}
+	return l.values, l.err
+}
+
+func getValue(gtr Getter, key string) (Value, bool, error) {
+	if vg, ok := gtr.(ValueGetter); ok {
+		return vg.GetValue(key)
 	}
 	value, version, err := gtr.Get(key)
-	if err != nil {
-		slog.Error("failed to get redis", "key", key, "field", f.Name(), "err", err)
+	if err != nil || value == nil {
+		return Value{}, false, err
+	}
+	return Value{Value: *value, Version: version}, true, nil
+}
+
+func processFlagField(f *config.Field, flags flagMap, seedMap fieldMap) error {
+	info, ok := flags[f]
+	if !ok {
 		return nil
 	}
-	if value == nil {
-		slog.Debug("redis key does not exist", "key", key, "field", f.Name())
+	if !info.set || info.value == nil {
+		slog.Debug("flag var did not exist", "key", info.key, "field", f.Name())
+		f.RecordAttempt(config.SourceFlag, info.key, "not set")
 		return nil
 	}
-	err = f.Set(*value, version)
+	err := f.Set(*info.value, 0, config.WithOrigin(config.SourceFlag, info.key), config.WithPhase(config.PhaseSeed))
 	if err != nil {
 		return err
 	}
-	slog.Debug("redis value applied", "value", f, "field", f.Name())
-	seedMap[f] = true
+	slog.Debug("flag value applied", "value", f, "field", f.Name())
+	seedMap[f] = config.SourceFlag
 	return nil
 }
 
-func processFlagField(f *config.Field, flagSet *flag.FlagSet) (*flagInfo, bool) {
-	key, ok := f.Sources()[config.SourceFlag]
-	if !ok {
-		return nil, false
+// processFlags registers and parses the flags of all fields up front, so that their values can be
+// applied in the order of the flag source precedence.
+func processFlags(ff []*config.Field) flagMap {
+	flags := make(flagMap)
+	flagSet := flag.NewFlagSet("Harvester flags", flag.ContinueOnError)
+
+	infos := make([]*flagInfo, 0)
+	for _, f := range ff {
+		key, ok := f.Sources()[config.SourceFlag]
+		if !ok {
+			continue
+		}
+		var val string
+		flagSet.StringVar(&val, key, "", "")
+		info := &flagInfo{key: key, value: &val}
+		infos = append(infos, info)
+		flags[f] = info
 	}
-	var val string
-	flagSet.StringVar(&val, key, "", "")
-	return &flagInfo{key, f, &val}, true
-}
 
-func processFlags(infos []*flagInfo, flagSet *flag.FlagSet, seedMap fieldMap) error {
 	if len(infos) == 0 {
-		return nil
+		return flags
 	}
 
-	if !flagSet.Parsed() {
-		parseFlags(infos, flagSet)
-	}
+	parseFlags(infos, flagSet)
 
-	for _, info := range infos {
-		hasFlag := false
-		flagSet.Visit(func(f *flag.Flag) {
-			if f.Name == info.key {
-				hasFlag = true
-				return
-			}
-		})
-		if hasFlag && info.value != nil {
-			err := info.field.Set(*info.value, 0)
-			if err != nil {
-				return err
+	flagSet.Visit(func(fl *flag.Flag) {
+		for _, info := range infos {
+			if fl.Name == info.key {
+				info.set = true
 			}
-			slog.Debug("flag value applied", "value", info.field, "field", info.field.Name())
-			seedMap[info.field] = true
-		} else {
-			slog.Debug("flag var did not exist", "key", info.key, "field", info.field.Name())
 		}
-	}
-	return nil
+	})
+	return flags
 }
 
 func parseFlags(infos []*flagInfo, flagSet *flag.FlagSet) {
@@ -312,12 +517,31 @@ func parseFlags(infos []*flagInfo, flagSet *flag.FlagSet) {
 	}
 }
 
-func evaluateSeedMap(seedMap fieldMap) error {
+// evaluateSeedMap checks the seeded fields against their policies and reports all violations together.
+// Fields have to be seeded unless they are optional and, if they declare required sources, seeded by one of them.
+func evaluateSeedMap(ff []*config.Field, seedMap fieldMap) error {
 	var errs []error
-	for f, seeded := range seedMap {
-		if !seeded {
+	for _, f := range ff {
+		src := seedMap[f]
+		switch {
+		case len(f.Required()) > 0 && !slices.Contains(f.Required(), src):
+			if src == "" {
+				errs = append(errs, fmt.Errorf("field %s not seeded from required source %s", f.Name(), joinSources(f.Required())))
+				continue
+			}
+			errs = append(errs, fmt.Errorf("field %s seeded from %s instead of required source %s", f.Name(), src,
+				joinSources(f.Required())))
+		case src == "" && !f.Optional():
 			errs = append(errs, fmt.Errorf("field %s not seeded", f.Name()))
 		}
 	}
 	return errors.Join(errs...)
 }
+
+func joinSources(ss []config.Source) string {
+	names := make([]string, 0, len(ss))
+	for _, src := range ss {
+		names = append(names, string(src))
+	}
+	return strings.Join(names, " or ")
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/beatlabs/harvester/config"
+	"github.com/beatlabs/harvester/metrics"
 	"github.com/beatlabs/harvester/sync"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -274,6 +275,16 @@ func TestSeeder_Seed(t *testing.T) {
 		require.Error(t, err)
 	})
 
+	t.Run("invalid by validation, failure", func(t *testing.T) {
+		t.Setenv("ENV_WORKER_COUNT", "-5")
+		invalidCfg, err := config.New(&testInvalidByValidation{}, nil)
+		require.NoError(t, err)
+
+		err = New().Seed(invalidCfg)
+
+		require.EqualError(t, err, "field WorkerCount failed min validation: value should be at least 1")
+	})
+
 	t.Run("invalid file int, failure", func(t *testing.T) {
 		invalidFileIntCfg, err := config.New(&testInvalidFileInt{}, nil)
 		require.NoError(t, err)
@@ -297,6 +308,429 @@ func TestSeeder_Seed(t *testing.T) {
 	})
 }
 
+func TestSeeder_Seed_RegisteredSource(t *testing.T) {
+	require.NoError(t, config.RegisterSource("seed-test-kv", config.PrecedenceConsul+50))
+
+	t.Run("getter applied in precedence order, success", func(t *testing.T) {
+		c := testRegisteredSourceConfig{}
+		cfg, err := config.New(&c, nil)
+		require.NoError(t, err)
+		consulParam, err := NewParam(config.SourceConsul, &stubGetter{})
+		require.NoError(t, err)
+		kvParam, err := NewParam("seed-test-kv", &stubGetter{})
+		require.NoError(t, err)
+
+		err = New(*consulParam, *kvParam).Seed(cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, "XXX", c.Name.Get())
+	})
+
+	t.Run("missing getter, failure", func(t *testing.T) {
+		cfg, err := config.New(&testRegisteredSourceConfig{}, nil)
+		require.NoError(t, err)
+		consulParam, err := NewParam(config.SourceConsul, &stubGetter{})
+		require.NoError(t, err)
+
+		err = New(*consulParam).Seed(cfg)
+
+		require.EqualError(t, err, "seed-test-kv getter required")
+	})
+}
+
+func TestSeeder_Seed_Precedence(t *testing.T) {
+	t.Setenv("ENV_WORK_HOURS", "9h")
+	t.Setenv("ENV_HAS_JOB", "false")
+
+	consulParam, err := NewParam(config.SourceConsul, &stubGetter{})
+	require.NoError(t, err)
+
+	t.Run("default precedence, consul beats env", func(t *testing.T) {
+		c := testPrecedenceConfig{}
+		cfg, err := config.New(&c, nil)
+		require.NoError(t, err)
+
+		err = New(*consulParam).Seed(cfg)
+
+		require.NoError(t, err)
+		assert.True(t, c.HasJob.Get())
+		assert.Equal(t, 9*time.Hour, c.WorkHours.Get())
+	})
+
+	t.Run("seeder precedence, env beats consul", func(t *testing.T) {
+		c := testPrecedenceConfig{}
+		cfg, err := config.New(&c, nil)
+		require.NoError(t, err)
+		sd, err := NewWithOptions([]Param{*consulParam}, WithPrecedence(config.SourceConsul, config.SourceEnv))
+		require.NoError(t, err)
+
+		err = sd.Seed(cfg)
+
+		require.NoError(t, err)
+		assert.False(t, c.HasJob.Get())
+		assert.Equal(t, 9*time.Hour, c.WorkHours.Get())
+	})
+
+	t.Run("field precedence overrides seeder precedence", func(t *testing.T) {
+		c := testFieldPrecedenceConfig{}
+		cfg, err := config.New(&c, nil)
+		require.NoError(t, err)
+		sd, err := NewWithOptions([]Param{*consulParam}, WithPrecedence(config.SourceConsul, config.SourceEnv))
+		require.NoError(t, err)
+
+		err = sd.Seed(cfg)
+
+		require.NoError(t, err)
+		assert.True(t, c.HasJob.Get())
+	})
+
+	t.Run("invalid precedence, failure", func(t *testing.T) {
+		sd, err := NewWithOptions(nil, WithPrecedence("unknown"))
+		require.EqualError(t, err, "source unknown is not registered")
+		assert.Nil(t, sd)
+	})
+}
+
+func TestSeeder_Seed_Policy(t *testing.T) {
+	consulParam, err := NewParam(config.SourceConsul, &stubGetter{})
+	require.NoError(t, err)
+
+	t.Run("optional field keeps zero value, success", func(t *testing.T) {
+		c := testOptionalConfig{}
+		cfg, err := config.New(&c, nil)
+		require.NoError(t, err)
+
+		err = New().Seed(cfg)
+
+		require.NoError(t, err)
+		assert.Empty(t, c.Name.Get())
+	})
+
+	t.Run("optional field seeded, success", func(t *testing.T) {
+		t.Setenv("ENV_OPTIONAL_NAME", "John Doe")
+		c := testOptionalConfig{}
+		cfg, err := config.New(&c, nil)
+		require.NoError(t, err)
+
+		err = New().Seed(cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, "John Doe", c.Name.Get())
+	})
+
+	t.Run("required source provides value, success", func(t *testing.T) {
+		c := testRequiredConfig{}
+		cfg, err := config.New(&c, nil)
+		require.NoError(t, err)
+
+		err = New(*consulParam).Seed(cfg)
+
+		require.NoError(t, err)
+		assert.True(t, c.HasJob.Get())
+	})
+
+	t.Run("all violations reported, failure", func(t *testing.T) {
+		t.Setenv("ENV_REQUIRED_HAS_JOB", "false")
+		c := testRequiredViolationConfig{}
+		cfg, err := config.New(&c, nil)
+		require.NoError(t, err)
+
+		err = New(*consulParam).Seed(cfg)
+
+		require.EqualError(t, err, "field HasJob seeded from env instead of required source consul\n"+
+			"field Missing not seeded from required source consul\n"+
+			"field Unseeded not seeded")
+	})
+}
+
+func TestSeeder_Seed_Notification(t *testing.T) {
+	t.Setenv("ENV_HAS_JOB", "false")
+
+	c := testNotificationConfig{}
+	chNotify := make(chan config.ChangeNotification, 10)
+	cfg, err := config.New(&c, chNotify)
+	require.NoError(t, err)
+
+	err = New().Seed(cfg)
+	require.NoError(t, err)
+
+	close(chNotify)
+	var nn []config.ChangeNotification
+	for n := range chNotify {
+		nn = append(nn, n)
+	}
+	require.Len(t, nn, 2)
+	assert.Equal(t, config.SourceSeed, nn[0].Source)
+	assert.Equal(t, config.PhaseSeed, nn[0].Phase)
+	assert.Equal(t, config.SourceEnv, nn[1].Source)
+	assert.Equal(t, "ENV_HAS_JOB", nn[1].Key)
+	assert.Equal(t, "false", nn[1].Current)
+	assert.Equal(t, config.PhaseSeed, nn[1].Phase)
+}
+
+func TestSeeder_Seed_Attempts(t *testing.T) {
+	c := testAttemptsConfig{}
+	cfg, err := config.New(&c, nil)
+	require.NoError(t, err)
+	consulParam, err := NewParam(config.SourceConsul, &stubGetter{})
+	require.NoError(t, err)
+
+	err = New(*consulParam).Seed(cfg)
+	require.NoError(t, err)
+
+	p, err := cfg.Explain("Name")
+	require.NoError(t, err)
+	assert.Equal(t, "John Doe", p.Value)
+	assert.Equal(t, config.SourceSeed, p.Source)
+	assert.Equal(t, config.PhaseSeed, p.Phase)
+	assert.Equal(t, []config.Attempt{
+		{Source: config.SourceEnv, Key: "ENV_ATTEMPTS_NAME", Reason: "not found"},
+		{Source: config.SourceConsul, Key: "/config/YYY", Reason: "not found"},
+	}, p.Attempts)
+
+	// the attempts of a seed pass replace the previous ones
+	err = New(*consulParam).Seed(cfg)
+	require.NoError(t, err)
+	p, err = cfg.Explain("Name")
+	require.NoError(t, err)
+	assert.Len(t, p.Attempts, 2)
+}
+
+func TestSeeder_Seed_Prefix(t *testing.T) {
+	c := testPrefixConfig{}
+	cfg, err := config.New(&c, nil)
+	require.NoError(t, err)
+	gtr := &stubPrefixGetter{values: map[string]Value{
+		"svc/db/Host":         {Value: "db.local", Version: 3},
+		"svc/db/replica/Port": {Value: "5433", Version: 4},
+	}}
+	consulParam, err := NewParam(config.SourceConsul, gtr)
+	require.NoError(t, err)
+
+	err = New(*consulParam).Seed(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, "true", c.Enabled.String())
+	assert.Equal(t, "db.local", c.DB.Host.Get())
+	assert.Equal(t, int64(5432), c.DB.Port.Get())
+	assert.Equal(t, int64(5433), c.DB.Replica.Port.Get())
+	assert.Equal(t, []string{"svc/db/"}, gtr.prefixes)
+	assert.Equal(t, []string{"/config/enabled"}, gtr.keys)
+
+	p, err := cfg.Explain("DBPort")
+	require.NoError(t, err)
+	assert.Equal(t, []config.Attempt{{Source: config.SourceConsul, Key: "svc/db/Port", Reason: "not found"}}, p.Attempts)
+
+	t.Run("listing fails once", func(t *testing.T) {
+		c := testPrefixConfig{}
+		cfg, err := config.New(&c, nil)
+		require.NoError(t, err)
+		gtr := &stubPrefixGetter{err: errors.New("unavailable")}
+		consulParam, err := NewParam(config.SourceConsul, gtr)
+		require.NoError(t, err)
+
+		err = New(*consulParam).Seed(cfg)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"svc/db/"}, gtr.prefixes)
+		assert.Equal(t, int64(5432), c.DB.Port.Get())
+		p, err := cfg.Explain("DBHost")
+		require.NoError(t, err)
+		assert.Equal(t, []config.Attempt{{Source: config.SourceConsul, Key: "svc/db/Host", Reason: "unavailable"}}, p.Attempts)
+	})
+}
+
+func TestSeeder_Seed_Entries(t *testing.T) {
+	c := testEntriesConfig{}
+	cfg, err := config.New(&c, nil)
+	require.NoError(t, err)
+	gtr := &stubPrefixGetter{values: map[string]Value{
+		"flags/":      {Value: "", Version: 1},
+		"flags/alpha": {Value: "true", Version: 3},
+		"flags/gamma": {Value: "false", Version: 4, Stale: true},
+	}}
+	consulParam, err := NewParam(config.SourceConsul, gtr)
+	require.NoError(t, err)
+
+	err = New(*consulParam).Seed(cfg)
+	require.NoError(t, err)
+
+	// the entries are added to the seeded value
+	assert.Equal(t, map[string]bool{"alpha": true, "beta": true, "gamma": false}, c.Flags.Get())
+	assert.Equal(t, []string{"flags/"}, gtr.prefixes)
+	assert.Empty(t, gtr.keys)
+	p, err := cfg.Explain("Flags")
+	require.NoError(t, err)
+	assert.Equal(t, config.SourceConsul, p.Source)
+	assert.Equal(t, "flags/gamma", p.Key)
+	assert.True(t, p.Stale)
+
+	t.Run("no entries", func(t *testing.T) {
+		c := testEntriesConfig{}
+		cfg, err := config.New(&c, nil)
+		require.NoError(t, err)
+		consulParam, err := NewParam(config.SourceConsul, &stubPrefixGetter{values: map[string]Value{}})
+		require.NoError(t, err)
+
+		err = New(*consulParam).Seed(cfg)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]bool{"beta": true}, c.Flags.Get())
+		p, err := cfg.Explain("Flags")
+		require.NoError(t, err)
+		assert.Equal(t, []config.Attempt{{Source: config.SourceConsul, Key: "flags/", Reason: "not found"}}, p.Attempts)
+	})
+
+	t.Run("invalid entry", func(t *testing.T) {
+		cfg, err := config.New(&testEntriesConfig{}, nil)
+		require.NoError(t, err)
+		gtr := &stubPrefixGetter{values: map[string]Value{"flags/alpha": {Value: "yes", Version: 3}}}
+		consulParam, err := NewParam(config.SourceConsul, gtr)
+		require.NoError(t, err)
+
+		err = New(*consulParam).Seed(cfg)
+		require.EqualError(t, err, `invalid value of key "alpha": strconv.ParseBool: parsing "yes": invalid syntax`)
+	})
+
+	t.Run("getter without prefixes", func(t *testing.T) {
+		cfg, err := config.New(&testEntriesConfig{}, nil)
+		require.NoError(t, err)
+		consulParam, err := NewParam(config.SourceConsul, &stubGetter{})
+		require.NoError(t, err)
+
+		err = New(*consulParam).Seed(cfg)
+		require.EqualError(t, err, "consul getter does not support prefixes, field Flags")
+	})
+}
+
+func TestSeeder_Seed_Stale(t *testing.T) {
+	c := testAttemptsConfig{}
+	cfg, err := config.New(&c, nil)
+	require.NoError(t, err)
+	consulParam, err := NewParam(config.SourceConsul, &stubValueGetter{value: Value{Value: "Jane", Version: 2, Stale: true}})
+	require.NoError(t, err)
+
+	err = New(*consulParam).Seed(cfg)
+	require.NoError(t, err)
+
+	p, err := cfg.Explain("Name")
+	require.NoError(t, err)
+	assert.Equal(t, "Jane", p.Value)
+	assert.Equal(t, config.SourceConsul, p.Source)
+	assert.Equal(t, uint64(2), p.Version)
+	assert.True(t, p.Stale)
+}
+
+func TestSeeder_Seed_CachingGetter(t *testing.T) {
+	c := testAttemptsConfig{}
+	cfg, err := config.New(&c, nil)
+	require.NoError(t, err)
+	gtr := &stubCachingGetter{}
+	consulParam, err := NewParam(config.SourceConsul, gtr)
+	require.NoError(t, err)
+	s := New(*consulParam)
+
+	require.NoError(t, s.Seed(cfg))
+	assert.Equal(t, 1, gtr.resets)
+	require.NoError(t, s.Seed(cfg))
+	assert.Equal(t, 2, gtr.resets)
+}
+
+func TestSeeder_Seed_Metrics(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		cfg, err := config.New(&testAttemptsConfig{}, nil)
+		require.NoError(t, err)
+		consulParam, err := NewParam(config.SourceConsul, &stubGetter{err: true})
+		require.NoError(t, err)
+		rec := &testRecorder{durations: make(map[config.Source]int)}
+		sd, err := NewWithOptions([]Param{*consulParam}, WithMetrics(rec))
+		require.NoError(t, err)
+
+		require.NoError(t, sd.Seed(cfg))
+
+		assert.Equal(t, []config.Source{config.SourceConsul}, rec.errors)
+		assert.Empty(t, rec.parse)
+		for _, src := range config.RegisteredSources() {
+			assert.Equal(t, 1, rec.durations[src], src)
+		}
+	})
+
+	t.Run("parse failure", func(t *testing.T) {
+		cfg, err := config.New(&testParseFailureConfig{}, nil)
+		require.NoError(t, err)
+		rec := &testRecorder{durations: make(map[config.Source]int)}
+		sd, err := NewWithOptions(nil, WithMetrics(rec))
+		require.NoError(t, err)
+
+		require.Error(t, sd.Seed(cfg))
+
+		assert.Equal(t, []string{"Age"}, rec.parse)
+	})
+
+	t.Run("nil recorder", func(t *testing.T) {
+		sd, err := NewWithOptions(nil, WithMetrics(nil))
+		require.EqualError(t, err, "metrics recorder is nil")
+		assert.Nil(t, sd)
+	})
+}
+
+type testRecorder struct {
+	metrics.Noop
+	durations map[config.Source]int
+	errors    []config.Source
+	parse     []string
+}
+
+func (r *testRecorder) SeedDuration(src config.Source, _ time.Duration) {
+	r.durations[src]++
+}
+
+func (r *testRecorder) SeedError(src config.Source) {
+	r.errors = append(r.errors, src)
+}
+
+func (r *testRecorder) ParseFailure(field string, _ config.Source) {
+	r.parse = append(r.parse, field)
+}
+
+type testParseFailureConfig struct {
+	Age sync.Int64 `seed:"XXX"`
+}
+
+type testAttemptsConfig struct {
+	Name sync.String `seed:"John Doe" env:"ENV_ATTEMPTS_NAME" consul:"/config/YYY"`
+}
+
+type testOptionalConfig struct {
+	Name sync.String `env:"ENV_OPTIONAL_NAME" harvester:"optional"`
+}
+
+type testRequiredConfig struct {
+	HasJob sync.Bool `seed:"false" env:"ENV_REQUIRED_HAS_JOB" consul:"/config/has-job" required:"consul"`
+}
+
+type testRequiredViolationConfig struct {
+	HasJob   sync.Bool   `seed:"true" env:"ENV_REQUIRED_HAS_JOB" consul:"/config/has-job" required:"consul" precedence:"consul,env"`
+	Missing  sync.Bool   `consul:"/config/YYY" required:"consul"`
+	Unseeded sync.String `env:"ENV_REQUIRED_UNSEEDED"`
+}
+
+type testNotificationConfig struct {
+	HasJob sync.Bool `seed:"true" env:"ENV_HAS_JOB"`
+}
+
+type testPrecedenceConfig struct {
+	HasJob    sync.Bool         `seed:"true" env:"ENV_HAS_JOB" consul:"/config/has-job"`
+	WorkHours sync.TimeDuration `seed:"10h" env:"ENV_WORK_HOURS" consul:"/config/work_hours"`
+}
+
+type testFieldPrecedenceConfig struct {
+	HasJob sync.Bool `seed:"true" env:"ENV_HAS_JOB" consul:"/config/has-job" precedence:"env,consul"`
+}
+
+type testRegisteredSourceConfig struct {
+	Name sync.String `seed:"John Doe" consul:"/config/has-job" seed-test-kv:"/config/XXX"`
+}
+
 type testConfig struct {
 	Name      sync.String       `seed:"John Doe"`
 	Age       sync.Int64        `seed:"18" env:"ENV_AGE"`
@@ -325,6 +759,10 @@ type testMultipleUnseeded struct {
 	Second sync.String `env:"ENV_SECOND_UNSEEDED"`
 }
 
+type testInvalidByValidation struct {
+	WorkerCount sync.Int64 `seed:"5" env:"ENV_WORKER_COUNT" min:"1"`
+}
+
 type testInvalidFloat struct {
 	Balance sync.Float64 `env:"ENV_XXX"`
 }
@@ -337,6 +775,67 @@ type testMissingValue struct {
 	HasJob sync.Bool `consul:"/config/YYY"`
 }
 
+type testPrefixConfig struct {
+	Enabled sync.Bool `consul:"/config/enabled"`
+	DB      struct {
+		Host    sync.String `seed:"localhost"`
+		Port    sync.Int64  `seed:"5432"`
+		Replica struct {
+			Port sync.Int64 `seed:"5432"`
+		} `consul_prefix:"replica"`
+	} `consul_prefix:"svc/db"`
+}
+
+type testEntriesConfig struct {
+	Flags sync.Map[string, bool] `seed:"beta=true" consul_prefix:"flags"`
+}
+
+// stubValueGetter returns the value for every key from GetValue.
+type stubValueGetter struct {
+	value Value
+}
+
+func (g *stubValueGetter) Get(string) (*string, uint64, error) {
+	return nil, 0, errors.New("GetValue should be used")
+}
+
+func (g *stubValueGetter) GetValue(string) (Value, bool, error) {
+	return g.value, true, nil
+}
+
+// stubPrefixGetter records the keys and prefixes it is called with.
+type stubPrefixGetter struct {
+	values   map[string]Value
+	err      error
+	keys     []string
+	prefixes []string
+}
+
+func (g *stubPrefixGetter) Get(key string) (*string, uint64, error) {
+	g.keys = append(g.keys, key)
+	val := "true"
+	return &val, 1, nil
+}
+
+func (g *stubPrefixGetter) GetPrefix(prefix string) (map[string]Value, error) {
+	g.prefixes = append(g.prefixes, prefix)
+	return g.values, g.err
+}
+
+// stubCachingGetter counts the resets of its cache.
+type stubCachingGetter struct {
+	resets int
+}
+
+func (g *stubCachingGetter) Get(string) (*string, uint64, error) {
+	val := "Jane"
+	return &val, 1, nil
+}
+
+func (g *stubCachingGetter) ResetCache() {
+	g.resets++
+}
+
 type stubGetter struct {
 	err bool
 }
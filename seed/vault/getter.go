@@ -0,0 +1,119 @@
+// Package vault handles seeding capabilities with vault.
+package vault
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/beatlabs/harvester/internal/vault"
+	"github.com/hashicorp/vault/api"
+)
+
+const defaultTimeout = 60 * time.Second
+
+// Getter implementation of the getter interface.
+type Getter struct {
+	client  *api.Client
+	timeout time.Duration
+	mu      sync.Mutex
+	reads   map[string]*read
+}
+
+// read of a secret, which is cached for a seed pass.
+type read struct {
+	secret *vault.Secret
+	err    error
+}
+
+// Option for configuring the getter.
+type Option func(*Getter) error
+
+// WithTimeout sets the timeout of each request, which is applied through the context of the request.
+func WithTimeout(timeout time.Duration) Option {
+	return func(g *Getter) error {
+		if timeout <= 0 {
+			return errors.New("timeout should be a positive number")
+		}
+		g.timeout = timeout
+		return nil
+	}
+}
+
+// New constructor. Each request times out after 60s, unless set otherwise with WithTimeout.
+func New(client *api.Client, oo ...Option) (*Getter, error) {
+	if client == nil {
+		return nil, errors.New("client is nil")
+	}
+	g := &Getter{client: client, timeout: defaultTimeout, reads: make(map[string]*read)}
+	for _, o := range oo {
+		err := o(g)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// Get the value of a key of the form path#field from Vault. KV v1 and v2 secrets are supported, where the path of
+// a KV v2 secret contains the data segment, e.g. secret/data/app#password. The version is the one of a KV v2 secret,
+// 0 for other secrets. Returns (nil, 0, nil) when the secret or its field does not exist.
+//
+// Each secret is read once per seed pass, so that all fields of a dynamic secret come from the same lease.
+func (g *Getter) Get(key string) (*string, uint64, error) {
+	k, err := vault.ParseKey(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	secret, err := g.read(k.Path)
+	if err != nil {
+		if errors.Is(err, vault.ErrNotFound) {
+			return nil, 0, nil
+		}
+		return nil, 0, err
+	}
+	val, ok, err := secret.Value(k.Field)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !ok {
+		return nil, 0, nil
+	}
+	return &val, secret.Version, nil
+}
+
+// ResetCache drops the secrets read so far, which is called by the seeder at the start of each seed pass.
+func (g *Getter) ResetCache() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.reads = make(map[string]*read)
+}
+
+// Secrets read in the last seed pass by path, which are handed over to the Vault monitor with vault.WithSeeder of
+// the monitor package, so that it takes over their leases instead of reading them again.
+func (g *Getter) Secrets() map[string]*vault.Secret {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ss := make(map[string]*vault.Secret, len(g.reads))
+	for path, r := range g.reads {
+		if r.err == nil {
+			ss[path] = r.secret
+		}
+	}
+	return ss
+}
+
+func (g *Getter) read(path string) (*vault.Secret, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	r, ok := g.reads[path]
+	if !ok {
+		ctx, cancel := context.WithTimeout(context.Background(), g.timeout)
+		defer cancel()
+		secret, err := vault.Read(ctx, g.client, path)
+		r = &read{secret: secret, err: err}
+		g.reads[path] = r
+	}
+	return r.secret, r.err
+}
@@ -0,0 +1,155 @@
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	got, err := New(nil)
+	require.EqualError(t, err, "client is nil")
+	assert.Nil(t, got)
+
+	got, err = New(&api.Client{}, WithTimeout(0))
+	require.EqualError(t, err, "timeout should be a positive number")
+	assert.Nil(t, got)
+
+	got, err = New(&api.Client{}, WithTimeout(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, got.timeout)
+
+	got, err = New(&api.Client{})
+	require.NoError(t, err)
+	assert.Equal(t, defaultTimeout, got.timeout)
+}
+
+func TestGetter_Get(t *testing.T) {
+	responses := map[string]string{
+		"/v1/secret/data/app":     `{"data":{"data":{"password":"v2-secret","port":5432,"tags":["a","b"]},"metadata":{"version":3}}}`,
+		"/v1/kv/app":              `{"lease_duration":2764800,"data":{"password":"v1-secret"}}`,
+		"/v1/secret/data/deleted": `{"data":{"data":null,"metadata":{"version":2,"deletion_time":"2026-01-01T00:00:00Z"}}}`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		switch {
+		case r.URL.Path == "/v1/failing":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		case r.URL.Path == "/v1/secret/data/deleted":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(body))
+		case !ok:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		default:
+			_, _ = w.Write([]byte(body))
+		}
+	}))
+	defer srv.Close()
+	cfg := api.DefaultConfig()
+	cfg.Address = srv.URL
+	client, err := api.NewClient(cfg)
+	require.NoError(t, err)
+	client.SetToken("token")
+	g, err := New(client)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		key             string
+		expectedValue   *string
+		expectedVersion uint64
+		expectedErr     string
+	}{
+		"kv v2":           {key: "secret/data/app#password", expectedValue: stringPtr("v2-secret"), expectedVersion: 3},
+		"kv v2 number":    {key: "secret/data/app#port", expectedValue: stringPtr("5432"), expectedVersion: 3},
+		"kv v2 list":      {key: "secret/data/app#tags", expectedValue: stringPtr(`["a","b"]`), expectedVersion: 3},
+		"kv v1":           {key: "kv/app#password", expectedValue: stringPtr("v1-secret")},
+		"missing field":   {key: "kv/app#user"},
+		"missing secret":  {key: "kv/missing#password"},
+		"deleted secret":  {key: "secret/data/deleted#password"},
+		"invalid key":     {key: "kv/app", expectedErr: `vault key "kv/app" should have the form path#field`},
+		"failing request": {key: "failing#password", expectedErr: "permission denied"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, version, err := g.Get(tt.key)
+			if tt.expectedErr != "" {
+				require.ErrorContains(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedValue, got)
+			assert.Equal(t, tt.expectedVersion, version)
+		})
+	}
+}
+
+func TestGetter_Get_Cache(t *testing.T) {
+	var reads atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := reads.Add(1)
+		_, _ = fmt.Fprintf(w, `{"lease_id":"database/creds/app/%d","lease_duration":60,"data":{"username":"u%d","password":"p%d"}}`, n, n, n)
+	}))
+	defer srv.Close()
+	g := newTestGetter(t, srv.URL)
+
+	user, _, err := g.Get("database/creds/app#username")
+	require.NoError(t, err)
+	pass, _, err := g.Get("database/creds/app#password")
+	require.NoError(t, err)
+	assert.Equal(t, "u1", *user)
+	assert.Equal(t, "p1", *pass)
+	assert.Equal(t, int32(1), reads.Load())
+	ss := g.Secrets()
+	require.Contains(t, ss, "database/creds/app")
+	assert.Equal(t, "database/creds/app/1", ss["database/creds/app"].LeaseID)
+
+	g.ResetCache()
+	assert.Empty(t, g.Secrets())
+	pass, _, err = g.Get("database/creds/app#password")
+	require.NoError(t, err)
+	assert.Equal(t, "p2", *pass)
+	assert.Equal(t, int32(2), reads.Load())
+}
+
+func TestGetter_Get_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+		_, _ = w.Write([]byte(`{"data":{"password":"secret"}}`))
+	}))
+	defer srv.Close()
+	g := newTestGetter(t, srv.URL, WithTimeout(10*time.Millisecond))
+
+	got, _, err := g.Get("kv/app#password")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, got)
+}
+
+func newTestGetter(t *testing.T, addr string, oo ...Option) *Getter {
+	t.Helper()
+	cfg := api.DefaultConfig()
+	cfg.Address = addr
+	cfg.MaxRetries = 0
+	client, err := api.NewClient(cfg)
+	require.NoError(t, err)
+	client.SetToken("token")
+	g, err := New(client, oo...)
+	require.NoError(t, err)
+	return g
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
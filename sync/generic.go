@@ -26,6 +26,12 @@ func (v *Value[T]) Set(value T) {
 	v.value = value
 }
 
+// Reset the value to its zero value.
+func (v *Value[T]) Reset() {
+	var zero T
+	v.Set(zero)
+}
+
 // MarshalJSON returns the JSON encoding of the value.
 func (v *Value[T]) MarshalJSON() ([]byte, error) {
 	v.rw.RLock()
@@ -0,0 +1,176 @@
+package sync
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Map is a map type with concurrent access support, whose entries are parsed one by one. Bound to a Consul key
+// prefix with the consul_prefix tag, it holds an entry per key of the prefix, which is added, updated and deleted
+// while monitoring. Keys and values are parsed and formatted with the same codecs as Var.
+type Map[K comparable, V any] struct {
+	rw sync.RWMutex
+	m  map[K]V
+}
+
+// Get returns a copy of the map.
+func (m *Map[K, V]) Get() map[K]V {
+	m.rw.RLock()
+	defer m.rw.RUnlock()
+	cp := make(map[K]V, len(m.m))
+	for k, v := range m.m {
+		cp[k] = v
+	}
+	return cp
+}
+
+// Load returns the value of the key and true if it exists.
+func (m *Map[K, V]) Load(key K) (V, bool) {
+	m.rw.RLock()
+	defer m.rw.RUnlock()
+	v, ok := m.m[key]
+	return v, ok
+}
+
+// Len returns the number of entries.
+func (m *Map[K, V]) Len() int {
+	m.rw.RLock()
+	defer m.rw.RUnlock()
+	return len(m.m)
+}
+
+// Set the map.
+func (m *Map[K, V]) Set(value map[K]V) {
+	cp := make(map[K]V, len(value))
+	for k, v := range value {
+		cp[k] = v
+	}
+	m.rw.Lock()
+	defer m.rw.Unlock()
+	m.m = cp
+}
+
+// Reset the map to an empty one.
+func (m *Map[K, V]) Reset() {
+	m.rw.Lock()
+	defer m.rw.Unlock()
+	m.m = make(map[K]V)
+}
+
+// String returns a string representation of the value, with the entries sorted by key.
+func (m *Map[K, V]) String() string {
+	m.rw.RLock()
+	defer m.rw.RUnlock()
+	pairs := make([]string, 0, len(m.m))
+	for k, v := range m.m {
+		pairs = append(pairs, codecFor[K]().Format(k)+"="+codecFor[V]().Format(v))
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// SetString parses and sets a value from string type.
+// The expected format is `key=value,key=value`, like the one of StringMap.
+func (m *Map[K, V]) SetString(val string) error {
+	dict := make(map[K]V)
+	if strings.TrimSpace(val) == "" {
+		m.rw.Lock()
+		defer m.rw.Unlock()
+		m.m = dict
+		return nil
+	}
+	for _, pair := range strings.Split(val, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("map must be formatted as `key=value`, got %q", pair)
+		}
+		k, v, err := parseEntry[K, V](strings.TrimSpace(key), strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		dict[k] = v
+	}
+	m.rw.Lock()
+	defer m.rw.Unlock()
+	m.m = dict
+	return nil
+}
+
+// SetEntry parses and sets the value of an entry.
+func (m *Map[K, V]) SetEntry(key, value string) error {
+	k, v, err := parseEntry[K, V](key, value)
+	if err != nil {
+		return err
+	}
+	m.rw.Lock()
+	defer m.rw.Unlock()
+	if m.m == nil {
+		m.m = make(map[K]V)
+	}
+	m.m[k] = v
+	return nil
+}
+
+// DeleteEntry deletes an entry, if it exists.
+func (m *Map[K, V]) DeleteEntry(key string) error {
+	k, err := codecFor[K]().Parse(key)
+	if err != nil {
+		return fmt.Errorf("invalid key %q: %w", key, err)
+	}
+	m.rw.Lock()
+	defer m.rw.Unlock()
+	delete(m.m, k)
+	return nil
+}
+
+// Entries returns the formatted values of the entries by formatted key, which SetEntry parses back. Unlike String,
+// it holds values which contain commas or equal signs, e.g. JSON documents.
+func (m *Map[K, V]) Entries() map[string]string {
+	m.rw.RLock()
+	defer m.rw.RUnlock()
+	entries := make(map[string]string, len(m.m))
+	for k, v := range m.m {
+		entries[codecFor[K]().Format(k)] = codecFor[V]().Format(v)
+	}
+	return entries
+}
+
+// MarshalJSON returns the JSON encoding of the value.
+func (m *Map[K, V]) MarshalJSON() ([]byte, error) {
+	m.rw.RLock()
+	defer m.rw.RUnlock()
+	return json.Marshal(m.m)
+}
+
+func parseEntry[K comparable, V any](key, value string) (K, V, error) {
+	var v V
+	k, err := codecFor[K]().Parse(key)
+	if err != nil {
+		return k, v, fmt.Errorf("invalid key %q: %w", key, err)
+	}
+	v, err = codecFor[V]().Parse(value)
+	if err != nil {
+		return k, v, fmt.Errorf("invalid value of key %q: %w", key, err)
+	}
+	return k, v, nil
+}
+
+// format the value with encoding.TextMarshaler, if it implements it, or fmt.Sprint.
+func format(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case encoding.TextMarshaler:
+		b, err := t.MarshalText()
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,88 @@
+package sync
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMap_SetString(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		want  map[string]bool
+		str   string
+		err   string
+	}{
+		"empty":         {input: "", want: map[string]bool{}, str: ""},
+		"whitespace":    {input: "  ", want: map[string]bool{}, str: ""},
+		"entries":       {input: "beta=true, alpha = false", want: map[string]bool{"alpha": false, "beta": true}, str: "alpha=false,beta=true"},
+		"missing value": {input: "alpha", err: "map must be formatted as `key=value`, got \"alpha\""},
+		"invalid value": {input: "alpha=yes", err: "invalid value of key \"alpha\": strconv.ParseBool: parsing \"yes\": invalid syntax"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var m Map[string, bool]
+			err := m.SetString(tt.input)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m.Get())
+			assert.Equal(t, tt.str, m.String())
+		})
+	}
+}
+
+func TestMap_Entries(t *testing.T) {
+	var m Map[string, time.Duration]
+	require.NoError(t, m.SetEntry("timeout", "1s"))
+	require.NoError(t, m.SetEntry("interval", "500ms"))
+	require.EqualError(t, m.SetEntry("retry", "often"), `invalid value of key "retry": time: invalid duration "often"`)
+	assert.Equal(t, 2, m.Len())
+	assert.Equal(t, "interval=500ms,timeout=1s", m.String())
+
+	v, ok := m.Load("timeout")
+	assert.True(t, ok)
+	assert.Equal(t, time.Second, v)
+
+	require.NoError(t, m.DeleteEntry("timeout"))
+	require.NoError(t, m.DeleteEntry("missing"))
+	_, ok = m.Load("timeout")
+	assert.False(t, ok)
+	assert.Equal(t, map[string]time.Duration{"interval": 500 * time.Millisecond}, m.Get())
+
+	// the returned map is a copy
+	m.Get()["interval"] = time.Hour
+	v, _ = m.Load("interval")
+	assert.Equal(t, 500*time.Millisecond, v)
+
+	m.Set(map[string]time.Duration{"a": time.Minute})
+	assert.Equal(t, "a=1m0s", m.String())
+	assert.Equal(t, map[string]string{"a": "1m0s"}, m.Entries())
+	b, err := m.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"a":60000000000}`, string(b))
+}
+
+func TestMap_Types(t *testing.T) {
+	var ints Map[int, float64]
+	require.NoError(t, ints.SetString("1=0.5,2=1.5"))
+	assert.Equal(t, map[int]float64{1: 0.5, 2: 1.5}, ints.Get())
+	assert.Equal(t, "1=0.5,2=1.5", ints.String())
+	require.EqualError(t, ints.DeleteEntry("one"), `invalid key "one": strconv.ParseInt: parsing "one": invalid syntax`)
+
+	var ips Map[string, net.IP]
+	require.NoError(t, ips.SetEntry("primary", "10.0.0.1"))
+	require.Error(t, ips.SetEntry("secondary", "10.0.0"))
+	assert.Equal(t, "primary=10.0.0.1", ips.String())
+
+	var slices Map[string, []int]
+	require.NoError(t, slices.SetEntry("a", "1,2"))
+	require.EqualError(t, slices.SetEntry("b", "1,x"),
+		`invalid value of key "b": invalid element "x": strconv.ParseInt: parsing "x": invalid syntax`)
+	assert.Equal(t, map[string][]int{"a": {1, 2}}, slices.Get())
+}
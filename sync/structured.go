@@ -0,0 +1,201 @@
+package sync
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"go.yaml.in/yaml/v3"
+)
+
+// JSON is a structured type with concurrent access support, which decodes the whole value as a JSON document into T.
+type JSON[T any] struct {
+	Value[T]
+	document
+}
+
+// String returns the value encoded as JSON.
+func (j *JSON[T]) String() string {
+	b, err := json.Marshal(j.Get())
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// SetString decodes the JSON document and sets the value. An empty document sets the zero value.
+// The value is kept if the document is invalid, has unknown fields in strict mode or does not match the schema.
+func (j *JSON[T]) SetString(val string) error {
+	var v T
+	if strings.TrimSpace(val) != "" {
+		err := j.validate(val, decodeJSON)
+		if err != nil {
+			return err
+		}
+		err = decodeJSON(val, &v, j.isStrict())
+		if err != nil {
+			return err
+		}
+	}
+	j.Set(v)
+	return nil
+}
+
+// UnmarshalJSON decodes the JSON document like SetString, so that the strict mode and the schema apply.
+func (j *JSON[T]) UnmarshalJSON(d []byte) error {
+	return j.SetString(string(d))
+}
+
+// YAML is a structured type with concurrent access support, which decodes the whole value as a YAML document into T.
+type YAML[T any] struct {
+	Value[T]
+	document
+}
+
+// String returns the value encoded as YAML.
+func (y *YAML[T]) String() string {
+	b, err := yaml.Marshal(y.Get())
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(b), "\n")
+}
+
+// SetString decodes the YAML document and sets the value. An empty document sets the zero value.
+// The value is kept if the document is invalid, has unknown fields in strict mode or does not match the schema.
+func (y *YAML[T]) SetString(val string) error {
+	var v T
+	if strings.TrimSpace(val) != "" {
+		err := y.validate(val, decodeYAML)
+		if err != nil {
+			return err
+		}
+		err = decodeYAML(val, &v, y.isStrict())
+		if err != nil {
+			return err
+		}
+	}
+	y.Set(v)
+	return nil
+}
+
+// UnmarshalJSON decodes the JSON document, which is also a YAML document, like SetString, so that the strict mode and
+// the schema apply.
+func (y *YAML[T]) UnmarshalJSON(d []byte) error {
+	return y.SetString(string(d))
+}
+
+// document holds the decoding options of the structured types.
+type document struct {
+	mu     sync.RWMutex
+	strict bool
+	schema *jsonschema.Schema
+}
+
+// SetStrict enables or disables the strict mode, which rejects documents with fields unknown to the type.
+func (d *document) SetStrict(strict bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.strict = strict
+}
+
+// SetSchema compiles the JSON Schema of the file, which the documents are validated against before they are decoded.
+func (d *document) SetSchema(path string) error {
+	sch, err := compileSchema(path)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.schema = sch
+	return nil
+}
+
+func (d *document) isStrict() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.strict
+}
+
+// validate the document against the schema, if any, after decoding it into its generic form.
+func (d *document) validate(val string, decode func(string, any, bool) error) error {
+	d.mu.RLock()
+	sch := d.schema
+	d.mu.RUnlock()
+	if sch == nil {
+		return nil
+	}
+	var doc any
+	err := decode(val, &doc, false)
+	if err != nil {
+		return err
+	}
+	// round trip through JSON, so that the document consists of the types the validator supports
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	err = sch.Validate(inst)
+	if err != nil {
+		return fmt.Errorf("document does not match schema: %w", err)
+	}
+	return nil
+}
+
+func decodeJSON(val string, v any, strict bool) error {
+	dec := json.NewDecoder(strings.NewReader(val))
+	if strict {
+		dec.DisallowUnknownFields()
+	}
+	err := dec.Decode(v)
+	if err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("invalid character after top-level value")
+	}
+	return nil
+}
+
+func decodeYAML(val string, v any, strict bool) error {
+	dec := yaml.NewDecoder(strings.NewReader(val))
+	dec.KnownFields(strict)
+	err := dec.Decode(v)
+	if err != nil {
+		return err
+	}
+	var next any
+	if !errors.Is(dec.Decode(&next), io.EOF) {
+		return errors.New("multiple documents are not supported")
+	}
+	return nil
+}
+
+var schemas = struct {
+	mu sync.Mutex
+	m  map[string]*jsonschema.Schema
+}{m: make(map[string]*jsonschema.Schema)}
+
+// compileSchema compiles the schema of the file once, since the field types are instantiated for every validation.
+func compileSchema(path string) (*jsonschema.Schema, error) {
+	schemas.mu.Lock()
+	defer schemas.mu.Unlock()
+	if sch, ok := schemas.m[path]; ok {
+		return sch, nil
+	}
+	sch, err := jsonschema.NewCompiler().Compile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema %s: %w", path, err)
+	}
+	schemas.m[path] = sch
+	return sch, nil
+}
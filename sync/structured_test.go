@@ -0,0 +1,152 @@
+package sync
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLimits struct {
+	Name  string         `json:"name" yaml:"name"`
+	Rates map[string]int `json:"rates" yaml:"rates"`
+}
+
+const testLimitsSchema = `{
+	"type": "object",
+	"required": ["name"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"rates": {"type": "object", "additionalProperties": {"type": "integer", "minimum": 0}}
+	}
+}`
+
+func writeSchema(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "limits.json")
+	require.NoError(t, os.WriteFile(path, []byte(testLimitsSchema), 0o600))
+	return path
+}
+
+func TestJSON_SetString(t *testing.T) {
+	want := testLimits{Name: "default", Rates: map[string]int{"search": 10}}
+	tests := map[string]struct {
+		input  string
+		strict bool
+		schema bool
+		err    string
+	}{
+		"valid":              {input: `{"name":"default","rates":{"search":10}}`},
+		"unknown field":      {input: `{"name":"default","rates":{"search":10},"burst":5}`},
+		"strict":             {input: `{"name":"default","rates":{"search":10}}`, strict: true},
+		"strict unknown":     {input: `{"name":"default","burst":5}`, strict: true, err: `json: unknown field "burst"`},
+		"invalid":            {input: `{"name":`, err: "unexpected EOF"},
+		"trailing data":      {input: `{"name":"default"} {}`, err: "invalid character after top-level value"},
+		"wrong type":         {input: `{"name":1}`, err: "json: cannot unmarshal number into Go struct field testLimits.name of type string"},
+		"schema":             {input: `{"name":"default","rates":{"search":10}}`, schema: true},
+		"schema mismatch":    {input: `{"name":"default","rates":{"search":-1}}`, schema: true, err: "document does not match schema"},
+		"schema missing key": {input: `{"rates":{"search":1}}`, schema: true, err: "document does not match schema"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var j JSON[testLimits]
+			j.SetStrict(tt.strict)
+			if tt.schema {
+				require.NoError(t, j.SetSchema(writeSchema(t)))
+			}
+			err := j.SetString(tt.input)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				assert.Equal(t, testLimits{}, j.Get())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, want, j.Get())
+			assert.JSONEq(t, `{"name":"default","rates":{"search":10}}`, j.String())
+		})
+	}
+}
+
+func TestJSON_KeepsValue(t *testing.T) {
+	var j JSON[testLimits]
+	j.SetStrict(true)
+	require.NoError(t, j.SetString(`{"name":"default"}`))
+	require.Error(t, j.SetString(`{"name":"default","burst":5}`))
+	assert.Equal(t, testLimits{Name: "default"}, j.Get())
+
+	require.NoError(t, j.SetString(" "))
+	assert.Equal(t, testLimits{}, j.Get())
+
+	b, err := j.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"","rates":null}`, string(b))
+}
+
+func TestYAML_SetString(t *testing.T) {
+	want := testLimits{Name: "default", Rates: map[string]int{"search": 10}}
+	tests := map[string]struct {
+		input  string
+		strict bool
+		schema bool
+		err    string
+	}{
+		"valid":           {input: "name: default\nrates:\n  search: 10\n"},
+		"unknown field":   {input: "name: default\nrates:\n  search: 10\nburst: 5\n"},
+		"strict":          {input: "name: default\nrates:\n  search: 10\n", strict: true},
+		"strict unknown":  {input: "name: default\nburst: 5\n", strict: true, err: "field burst not found in type sync.testLimits"},
+		"invalid":         {input: "name: [", err: "yaml:"},
+		"multiple":        {input: "name: default\n---\nname: other\n", err: "multiple documents are not supported"},
+		"schema":          {input: "name: default\nrates:\n  search: 10\n", schema: true},
+		"schema mismatch": {input: "name: default\nrates:\n  search: -1\n", schema: true, err: "document does not match schema"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var y YAML[testLimits]
+			y.SetStrict(tt.strict)
+			if tt.schema {
+				require.NoError(t, y.SetSchema(writeSchema(t)))
+			}
+			err := y.SetString(tt.input)
+			if tt.err != "" {
+				require.ErrorContains(t, err, tt.err)
+				assert.Equal(t, testLimits{}, y.Get())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, want, y.Get())
+			assert.Equal(t, "name: default\nrates:\n    search: 10", y.String())
+		})
+	}
+}
+
+func TestStructured_UnmarshalJSON(t *testing.T) {
+	var j JSON[testLimits]
+	j.SetStrict(true)
+	require.NoError(t, j.SetSchema(writeSchema(t)))
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"default"}`), &j))
+	assert.Equal(t, testLimits{Name: "default"}, j.Get())
+	require.ErrorContains(t, json.Unmarshal([]byte(`{"name":"default","burst":5}`), &j), `json: unknown field "burst"`)
+	require.ErrorContains(t, json.Unmarshal([]byte(`{"rates":{"search":1}}`), &j), "document does not match schema")
+	assert.Equal(t, testLimits{Name: "default"}, j.Get())
+
+	var y YAML[testLimits]
+	y.SetStrict(true)
+	require.NoError(t, y.SetSchema(writeSchema(t)))
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"default","rates":{"search":10}}`), &y))
+	assert.Equal(t, testLimits{Name: "default", Rates: map[string]int{"search": 10}}, y.Get())
+	require.ErrorContains(t, json.Unmarshal([]byte(`{"name":"default","burst":5}`), &y), "field burst not found")
+	require.ErrorContains(t, json.Unmarshal([]byte(`{"name":""}`), &y), "document does not match schema")
+	assert.Equal(t, testLimits{Name: "default", Rates: map[string]int{"search": 10}}, y.Get())
+}
+
+func TestSetSchema_Invalid(t *testing.T) {
+	var j JSON[testLimits]
+	require.ErrorContains(t, j.SetSchema(filepath.Join(t.TempDir(), "missing.json")), "failed to compile schema")
+
+	path := filepath.Join(t.TempDir(), "invalid.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"type": 1}`), 0o600))
+	require.ErrorContains(t, j.SetSchema(path), "failed to compile schema")
+}
@@ -0,0 +1,280 @@
+package sync
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Var is a generic type with concurrent access support, which parses and formats its value with the codec of T.
+// It implements config.CfgType for any T, e.g. Var[int], Var[net.IP] or Var[[]time.Duration].
+type Var[T any] struct {
+	Value[T]
+}
+
+// String returns a string representation of the value.
+func (v *Var[T]) String() string {
+	return codecFor[T]().Format(v.Get())
+}
+
+// SetString parses and sets a value from string type.
+func (v *Var[T]) SetString(val string) error {
+	value, err := codecFor[T]().Parse(val)
+	if err != nil {
+		return err
+	}
+	v.Set(value)
+	return nil
+}
+
+// Codec parses and formats the values of a type.
+type Codec[T any] struct {
+	Parse  func(string) (T, error)
+	Format func(T) string
+}
+
+// TextCodec returns the codec of a type which implements encoding.TextUnmarshaler with a pointer receiver. Values are
+// formatted with encoding.TextMarshaler, if the type implements it, or fmt.Sprint.
+func TextCodec[T any]() Codec[T] {
+	return Codec[T]{
+		Parse: func(s string) (T, error) {
+			var v T
+			u, ok := any(&v).(encoding.TextUnmarshaler)
+			if !ok {
+				return v, fmt.Errorf("type %T does not implement encoding.TextUnmarshaler", v)
+			}
+			return v, u.UnmarshalText([]byte(s))
+		},
+		Format: func(v T) string {
+			return format(v)
+		},
+	}
+}
+
+// JSONCodec returns the codec which decodes and encodes the values as JSON.
+func JSONCodec[T any]() Codec[T] {
+	return Codec[T]{
+		Parse: func(s string) (T, error) {
+			var v T
+			return v, json.Unmarshal([]byte(s), &v)
+		},
+		Format: func(v T) string {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Sprint(v)
+			}
+			return string(b)
+		},
+	}
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = make(map[reflect.Type]any)
+)
+
+// RegisterCodec registers the codec of T, which Var[T] and the entries of Map use instead of the default one.
+// By default, values are parsed with encoding.TextUnmarshaler, as strings, booleans, integers, floats or durations,
+// as comma separated slices of those, or as JSON otherwise.
+func RegisterCodec[T any](c Codec[T]) error {
+	if c.Parse == nil || c.Format == nil {
+		return errors.New("codec functions are nil")
+	}
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[reflect.TypeFor[T]()] = c
+	return nil
+}
+
+// codecFor returns the registered codec of T or the default one.
+func codecFor[T any]() Codec[T] {
+	codecsMu.RLock()
+	c, ok := codecs[reflect.TypeFor[T]()]
+	codecsMu.RUnlock()
+	if ok {
+		//nolint:forcetypeassert // the codecs are registered by type
+		return c.(Codec[T])
+	}
+	rc := reflectCodec(reflect.TypeFor[T]())
+	return Codec[T]{
+		Parse: func(s string) (T, error) {
+			var v T
+			rv, err := rc.parse(s)
+			if err != nil {
+				return v, err
+			}
+			reflect.ValueOf(&v).Elem().Set(rv)
+			return v, nil
+		},
+		Format: func(v T) string {
+			return rc.format(reflect.ValueOf(&v).Elem())
+		},
+	}
+}
+
+// codec of a type, which parses and formats reflected values, so that it can be derived for the elements of slices.
+type codec struct {
+	parse  func(s string) (reflect.Value, error)
+	format func(v reflect.Value) string
+}
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+func reflectCodec(t reflect.Type) codec {
+	codecsMu.RLock()
+	_, registered := codecs[t]
+	codecsMu.RUnlock()
+	switch {
+	case registered:
+		return registeredCodec(t)
+	case reflect.PointerTo(t).Implements(textUnmarshalerType):
+		return codec{
+			parse: func(s string) (reflect.Value, error) {
+				p := reflect.New(t)
+				//nolint:forcetypeassert // the pointer implements encoding.TextUnmarshaler
+				err := p.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+				return p.Elem(), err
+			},
+			format: func(v reflect.Value) string {
+				return format(v.Interface())
+			},
+		}
+	case t == durationType:
+		return codec{
+			parse: func(s string) (reflect.Value, error) {
+				d, err := time.ParseDuration(s)
+				return reflect.ValueOf(d), err
+			},
+			format: func(v reflect.Value) string {
+				return time.Duration(v.Int()).String()
+			},
+		}
+	}
+	if c, ok := kindCodec(t); ok {
+		return c
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		return sliceCodec(t)
+	}
+	return codec{
+		parse: func(s string) (reflect.Value, error) {
+			p := reflect.New(t)
+			err := json.Unmarshal([]byte(s), p.Interface())
+			return p.Elem(), err
+		},
+		format: func(v reflect.Value) string {
+			b, err := json.Marshal(v.Interface())
+			if err != nil {
+				return fmt.Sprint(v.Interface())
+			}
+			return string(b)
+		},
+	}
+}
+
+// registeredCodec adapts the registered codec of a type, e.g. for the elements of a slice.
+func registeredCodec(t reflect.Type) codec {
+	codecsMu.RLock()
+	c := reflect.ValueOf(codecs[t])
+	codecsMu.RUnlock()
+	parse, format := c.FieldByName("Parse"), c.FieldByName("Format")
+	return codec{
+		parse: func(s string) (reflect.Value, error) {
+			out := parse.Call([]reflect.Value{reflect.ValueOf(s)})
+			err, _ := out[1].Interface().(error)
+			return out[0], err
+		},
+		format: func(v reflect.Value) string {
+			return format.Call([]reflect.Value{v})[0].String()
+		},
+	}
+}
+
+// kindCodec returns the codec of a type whose kind is a string, boolean, integer or float.
+func kindCodec(t reflect.Type) (codec, bool) {
+	var parse func(s string, v reflect.Value) error
+	var format func(v reflect.Value) string
+	switch t.Kind() {
+	case reflect.String:
+		parse = func(s string, v reflect.Value) error {
+			v.SetString(s)
+			return nil
+		}
+		format = reflect.Value.String
+	case reflect.Bool:
+		parse = func(s string, v reflect.Value) error {
+			b, err := strconv.ParseBool(s)
+			v.SetBool(b)
+			return err
+		}
+		format = func(v reflect.Value) string { return strconv.FormatBool(v.Bool()) }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parse = func(s string, v reflect.Value) error {
+			i, err := strconv.ParseInt(s, 10, t.Bits())
+			v.SetInt(i)
+			return err
+		}
+		format = func(v reflect.Value) string { return strconv.FormatInt(v.Int(), 10) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parse = func(s string, v reflect.Value) error {
+			u, err := strconv.ParseUint(s, 10, t.Bits())
+			v.SetUint(u)
+			return err
+		}
+		format = func(v reflect.Value) string { return strconv.FormatUint(v.Uint(), 10) }
+	case reflect.Float32, reflect.Float64:
+		parse = func(s string, v reflect.Value) error {
+			f, err := strconv.ParseFloat(s, t.Bits())
+			v.SetFloat(f)
+			return err
+		}
+		format = func(v reflect.Value) string { return strconv.FormatFloat(v.Float(), 'g', -1, t.Bits()) }
+	default:
+		return codec{}, false
+	}
+	return codec{
+		parse: func(s string) (reflect.Value, error) {
+			v := reflect.New(t).Elem()
+			err := parse(s, v)
+			return v, err
+		},
+		format: format,
+	}, true
+}
+
+// sliceCodec returns the codec of a slice, whose elements are separated by commas, like the ones of StringSlice.
+func sliceCodec(t reflect.Type) codec {
+	elem := reflectCodec(t.Elem())
+	return codec{
+		parse: func(s string) (reflect.Value, error) {
+			v := reflect.MakeSlice(t, 0, 0)
+			if strings.TrimSpace(s) == "" {
+				return v, nil
+			}
+			for _, item := range strings.Split(s, ",") {
+				e, err := elem.parse(strings.TrimSpace(item))
+				if err != nil {
+					return v, fmt.Errorf("invalid element %q: %w", item, err)
+				}
+				v = reflect.Append(v, e)
+			}
+			return v, nil
+		},
+		format: func(v reflect.Value) string {
+			items := make([]string, 0, v.Len())
+			for i := range v.Len() {
+				items = append(items, elem.format(v.Index(i)))
+			}
+			return strings.Join(items, ",")
+		},
+	}
+}
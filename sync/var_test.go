@@ -0,0 +1,139 @@
+package sync
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLevel int
+
+const (
+	testLevelLow testLevel = iota
+	testLevelHigh
+)
+
+func (l testLevel) MarshalText() ([]byte, error) {
+	if l == testLevelHigh {
+		return []byte("high"), nil
+	}
+	return []byte("low"), nil
+}
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = testLevelLow
+	case "high":
+		*l = testLevelHigh
+	default:
+		return errors.New("unknown level")
+	}
+	return nil
+}
+
+type testPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type testUpper string
+
+func TestVar_SetString(t *testing.T) {
+	t.Run("int", func(t *testing.T) {
+		var v Var[int]
+		require.NoError(t, v.SetString("42"))
+		assert.Equal(t, 42, v.Get())
+		assert.Equal(t, "42", v.String())
+		require.EqualError(t, v.SetString("x"), `strconv.ParseInt: parsing "x": invalid syntax`)
+		assert.Equal(t, 42, v.Get())
+	})
+	t.Run("uint8 out of range", func(t *testing.T) {
+		var v Var[uint8]
+		require.Error(t, v.SetString("256"))
+	})
+	t.Run("float", func(t *testing.T) {
+		var v Var[float32]
+		require.NoError(t, v.SetString("0.5"))
+		assert.InDelta(t, 0.5, v.Get(), 0)
+		assert.Equal(t, "0.5", v.String())
+	})
+	t.Run("ip", func(t *testing.T) {
+		var v Var[net.IP]
+		require.NoError(t, v.SetString("10.0.0.1"))
+		assert.Equal(t, "10.0.0.1", v.String())
+		require.Error(t, v.SetString("10.0.0"))
+		assert.Equal(t, "10.0.0.1", v.String())
+	})
+	t.Run("enum", func(t *testing.T) {
+		var v Var[testLevel]
+		require.NoError(t, v.SetString("high"))
+		assert.Equal(t, testLevelHigh, v.Get())
+		assert.Equal(t, "high", v.String())
+		require.EqualError(t, v.SetString("medium"), "unknown level")
+	})
+	t.Run("durations", func(t *testing.T) {
+		var v Var[[]time.Duration]
+		require.NoError(t, v.SetString("1s, 500ms"))
+		assert.Equal(t, []time.Duration{time.Second, 500 * time.Millisecond}, v.Get())
+		assert.Equal(t, "1s,500ms", v.String())
+		require.EqualError(t, v.SetString("1s,often"), `invalid element "often": time: invalid duration "often"`)
+		require.NoError(t, v.SetString(""))
+		assert.Empty(t, v.Get())
+	})
+	t.Run("json", func(t *testing.T) {
+		var v Var[testPoint]
+		require.NoError(t, v.SetString(`{"x":1,"y":2}`))
+		assert.Equal(t, testPoint{X: 1, Y: 2}, v.Get())
+		assert.JSONEq(t, `{"x":1,"y":2}`, v.String())
+		require.Error(t, v.SetString(`{"x":`))
+	})
+}
+
+func TestRegisterCodec(t *testing.T) {
+	require.EqualError(t, RegisterCodec(Codec[testUpper]{}), "codec functions are nil")
+	require.NoError(t, RegisterCodec(Codec[testUpper]{
+		Parse: func(s string) (testUpper, error) {
+			return testUpper(strings.ToUpper(s)), nil
+		},
+		Format: func(v testUpper) string {
+			return strings.ToLower(string(v))
+		},
+	}))
+
+	var v Var[testUpper]
+	require.NoError(t, v.SetString("abc"))
+	assert.Equal(t, testUpper("ABC"), v.Get())
+	assert.Equal(t, "abc", v.String())
+
+	// registered codecs apply to slice elements and map entries too
+	var s Var[[]testUpper]
+	require.NoError(t, s.SetString("a,b"))
+	assert.Equal(t, []testUpper{"A", "B"}, s.Get())
+	assert.Equal(t, "a,b", s.String())
+
+	var m Map[string, testUpper]
+	require.NoError(t, m.SetEntry("k", "v"))
+	assert.Equal(t, map[string]testUpper{"k": "V"}, m.Get())
+}
+
+func TestCodecs(t *testing.T) {
+	text := TextCodec[testLevel]()
+	l, err := text.Parse("high")
+	require.NoError(t, err)
+	assert.Equal(t, testLevelHigh, l)
+	assert.Equal(t, "low", text.Format(testLevelLow))
+	_, err = TextCodec[int]().Parse("1")
+	require.EqualError(t, err, "type int does not implement encoding.TextUnmarshaler")
+
+	js := JSONCodec[[]int]()
+	ii, err := js.Parse("[1,2]")
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ii)
+	assert.Equal(t, "[1,2]", js.Format(ii))
+}